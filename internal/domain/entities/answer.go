@@ -2,20 +2,99 @@ package entity
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Attempt states for TestAnswer.Status.
+const (
+	AttemptInProgress = "in_progress"
+	AttemptSubmitted  = "submitted"
+	AttemptExpired    = "expired" // Closed by the server when the time ran out
+)
+
 type TestAnswer struct {
-	ID                 primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	TestId             primitive.ObjectID `json:"test_id,omitempty" bson:"test_id,omitempty"`
-	EmailID            string             `json:"email_id,omitempty" bson:"email_id,omitempty"`
-	Email              string             `json:"email,omitempty" bson:"email,omitempty"`
-	ListQuestionAnswer []QuestionAnswer   `json:"question_answer,omitempty" bson:"question_answer,omitempty"`
-	TotalScore         float32            `json:"score" bson:"score,omitempty"`
-	StartTime          time.Time          `json:"start_time" bson:"start_time,omitempty"`
-	EndTime            time.Time          `json:"end_time" bson:"end_time,omitempty"`
+	ID                 primitive.ObjectID   `json:"_id,omitempty" bson:"_id,omitempty"`
+	TestId             primitive.ObjectID   `json:"test_id,omitempty" bson:"test_id,omitempty"`
+	ClassID            primitive.ObjectID   `json:"class_id,omitempty" bson:"class_id,omitempty"`
+	EmailID            string               `json:"email_id,omitempty" bson:"email_id,omitempty"`
+	Email              string               `json:"email,omitempty" bson:"email,omitempty"`
+	AttemptNumber      int                  `json:"attempt_number" bson:"attempt_number,omitempty"` // Lần làm bài, bắt đầu từ 1
+	ListQuestionAnswer []QuestionAnswer     `json:"question_answer,omitempty" bson:"question_answer,omitempty"`
+	TotalScore         float32              `json:"score" bson:"score"`
+	MaxScore           float32              `json:"max_score" bson:"max_score,omitempty"`
+	StartTime          time.Time            `json:"start_time" bson:"start_time,omitempty"`
+	EndTime            time.Time            `json:"end_time" bson:"end_time,omitempty"`
+	Status             string               `json:"status" bson:"status,omitempty"`
+	Deadline           time.Time            `json:"deadline" bson:"deadline,omitempty"` // Hạn nộp bài tính lúc bắt đầu
+	Late               bool                 `json:"late" bson:"late,omitempty"`         // Submitted after the deadline under the flag policy
+	LastSavedAt        time.Time            `json:"last_saved_at" bson:"last_saved_at,omitempty"`
+	Shuffle            *AttemptShuffle      `json:"shuffle,omitempty" bson:"shuffle,omitempty"` // Thứ tự câu hỏi và lựa chọn đã hiển thị
+	DrawnQuestions     []DrawnQuestion      `json:"drawn_questions,omitempty" bson:"drawn_questions,omitempty"`
+	QuestionVersions   []QuestionVersionRef `json:"question_versions,omitempty" bson:"question_versions,omitempty"` // Phiên bản câu hỏi đã hiển thị
+	GradingPending     bool                 `json:"grading_pending" bson:"grading_pending"`                         // Còn câu tự luận chưa chấm
+	GradeLog           []GradeChange        `json:"grade_log,omitempty" bson:"grade_log,omitempty"`
+	GradeVersion       int                  `json:"-" bson:"grade_version,omitempty"`             // Bumped by every manual grade
+	Sections           []SectionProgress    `json:"sections,omitempty" bson:"sections,omitempty"` // Tiến độ từng phần thi
+	CurrentSection     int                  `json:"current_section" bson:"current_section,omitempty"`
+}
+
+// GradeChange records a manual grade given to a response.
+type GradeChange struct {
+	QuestionID    primitive.ObjectID `json:"question_id" bson:"question_id"`
+	PreviousScore float32            `json:"previous_score" bson:"previous_score"`
+	Score         float32            `json:"score" bson:"score"`
+	Comment       string             `json:"comment,omitempty" bson:"comment,omitempty"`
+	GradedBy      string             `json:"graded_by" bson:"graded_by"`
+	GradedAt      time.Time          `json:"graded_at" bson:"graded_at"`
+}
+
+// DrawnQuestion is a question picked for the attempt by the test's draw rules.
+type DrawnQuestion struct {
+	QuestionID primitive.ObjectID `json:"question_id" bson:"question_id"`
+	Score      float32            `json:"score,omitempty" bson:"score,omitempty"` // Overrides Question.Score when set
+}
+
+// QuestionIDs returns the questions of the attempt: the drawn questions when the
+// test uses draw rules, otherwise the test's fixed list.
+func (a TestAnswer) QuestionIDs(testQuestionIDs []primitive.ObjectID) []primitive.ObjectID {
+	if len(a.DrawnQuestions) == 0 {
+		return testQuestionIDs
+	}
+	ids := make([]primitive.ObjectID, len(a.DrawnQuestions))
+	for i, drawn := range a.DrawnQuestions {
+		ids[i] = drawn.QuestionID
+	}
+	return ids
+}
+
+// VersionOf returns the version of the question pinned on the attempt, 0 when none was pinned.
+func (a TestAnswer) VersionOf(questionID primitive.ObjectID) int {
+	for _, ref := range a.QuestionVersions {
+		if ref.QuestionID == questionID {
+			return ref.Version
+		}
+	}
+	return 0
+}
+
+// AttemptShuffle records the order the questions and their choices were shown in,
+// so reloads, grading and review screens can reproduce exactly what the student saw.
+type AttemptShuffle struct {
+	Seed      int64                 `json:"seed" bson:"seed"`
+	Questions []QuestionPermutation `json:"questions" bson:"questions"` // In the order shown
+}
+
+// QuestionPermutation is the shown order of a question's choices, by ID.
+type QuestionPermutation struct {
+	QuestionID   primitive.ObjectID   `json:"question_id" bson:"question_id"`
+	Options      []primitive.ObjectID `json:"options,omitempty" bson:"options,omitempty"`
+	OrderItems   []primitive.ObjectID `json:"order_items,omitempty" bson:"order_items,omitempty"`
+	MatchItems   []primitive.ObjectID `json:"match_items,omitempty" bson:"match_items,omitempty"`
+	MatchOptions []primitive.ObjectID `json:"match_options,omitempty" bson:"match_options,omitempty"`
+	MatrixRows   []primitive.ObjectID `json:"matrix_rows,omitempty" bson:"matrix_rows,omitempty"`
 }
 
 type QuestionAnswer struct {
@@ -24,6 +103,54 @@ type QuestionAnswer struct {
 	FillInTheBlanks []FillInTheBlank   `json:"fill_in_the_blank,omitempty" bson:"fill_in_the_blank,omitempty"`
 	Options         []OptionAnswer     `json:"options,omitempty" bson:"options,omitempty"`
 	Match           []MatchAnswer      `json:"match,omitempty" bson:"match,omitempty"`
+	Text            string             `json:"text,omitempty" bson:"text,omitempty"` // essay, short answer and numeric, e.g. "12,5 cm"
+	TrueFalse       *bool              `json:"true_false,omitempty" bson:"true_false,omitempty"`
+	Points          []HotspotPoint     `json:"points,omitempty" bson:"points,omitempty"` // hotspot clicks
+	Matrix          []MatrixAnswer     `json:"matrix,omitempty" bson:"matrix,omitempty"`
+	Score           float32            `json:"score" bson:"score"` // Điểm chấm bởi server
+	GradeStatus     string             `json:"grade_status,omitempty" bson:"grade_status,omitempty"`
+	Comment         string             `json:"comment,omitempty" bson:"comment,omitempty"`
+	GradedBy        string             `json:"graded_by,omitempty" bson:"graded_by,omitempty"`
+	GradedAt        time.Time          `json:"graded_at,omitempty" bson:"graded_at,omitempty"`
+}
+
+// Grading states for QuestionAnswer.GradeStatus, used by manually graded questions.
+const (
+	GradePending = "pending"
+	GradeGraded  = "graded"
+)
+
+// ResponseOnly drops the grading fields a client must not set.
+func (qa QuestionAnswer) ResponseOnly() QuestionAnswer {
+	qa.Score = 0
+	qa.GradeStatus = ""
+	qa.Comment = ""
+	qa.GradedBy = ""
+	qa.GradedAt = time.Time{}
+	return qa
+}
+
+// ResponsesOnly applies QuestionAnswer.ResponseOnly to every answer.
+func ResponsesOnly(answers []QuestionAnswer) []QuestionAnswer {
+	responses := make([]QuestionAnswer, len(answers))
+	for i, answer := range answers {
+		responses[i] = answer.ResponseOnly()
+	}
+	return responses
+}
+
+// RecomputeScore sums the per-question scores into TotalScore and the section scores,
+// and refreshes GradingPending.
+func (a *TestAnswer) RecomputeScore() {
+	a.TotalScore = 0
+	a.GradingPending = false
+	for _, qa := range a.ListQuestionAnswer {
+		a.TotalScore += qa.Score
+		if qa.GradeStatus == GradePending {
+			a.GradingPending = true
+		}
+	}
+	a.recomputeSectionScores()
 }
 
 // Option represents each option in the question.
@@ -31,7 +158,15 @@ type OptionAnswer struct {
 	ID primitive.ObjectID `json:"id" bson:"id,omitempty"`
 }
 
+// MatrixAnswer is the column picked for a matrix row.
+type MatrixAnswer struct {
+	RowID    primitive.ObjectID `json:"row_id" bson:"row_id"`
+	ColumnID primitive.ObjectID `json:"column_id" bson:"column_id"`
+}
+
+// MatchAnswer pairs a match option (ID) with the match item chosen for it (MatchId).
 type MatchAnswer struct {
+	ID      primitive.ObjectID `json:"id" bson:"id,omitempty"`
 	MatchId primitive.ObjectID `json:"matchid" bson:"matchid,omitempty"`
 }
 
@@ -46,11 +181,78 @@ func CreateNewAnswer(testId primitive.ObjectID, emailID string, email string) (*
 	newAnswer.Email = email
 	newAnswer.EmailID = emailID
 	newAnswer.TotalScore = 0
+	newAnswer.Status = AttemptInProgress
 	return &newAnswer, nil
 }
 
+// IsOpen reports whether the attempt still accepts answers.
+// Answers created before attempts had a status are treated as open.
+func (a TestAnswer) IsOpen() bool {
+	return a.Status == "" || a.Status == AttemptInProgress
+}
+
+// ComputeDeadline returns StartTime + the test's time limit clamped to the test's EndTime,
+// or the zero time when the attempt has no time limit.
+func (a TestAnswer) ComputeDeadline(test Test) time.Time {
+	var deadline time.Time
+	if minutes := test.TimeLimitMinutes(); minutes > 0 && !a.StartTime.IsZero() {
+		deadline = a.StartTime.Add(time.Duration(minutes) * time.Minute)
+	}
+	if endAt, ok := test.EndAt(); ok && (deadline.IsZero() || endAt.Before(deadline)) {
+		deadline = endAt
+	}
+	return deadline
+}
+
+// CloseAt returns the time after which no submission is accepted: the deadline,
+// extended by the late window when the test flags late submissions.
+func (a TestAnswer) CloseAt(test Test) time.Time {
+	deadline := a.ComputeDeadline(test)
+	if deadline.IsZero() {
+		return deadline
+	}
+	return deadline.Add(test.LateWindow())
+}
+
+// HasExpired checks if the attempt can no longer be submitted.
+func (a TestAnswer) HasExpired(test Test, now time.Time) bool {
+	closeAt := a.CloseAt(test)
+	return !closeAt.IsZero() && now.After(closeAt)
+}
+
+// IsLate checks if a submission at now is past the deadline.
+func (a TestAnswer) IsLate(test Test, now time.Time) bool {
+	deadline := a.ComputeDeadline(test)
+	return !deadline.IsZero() && now.After(deadline)
+}
+
+// SaveProgress replaces the autosaved answers of an open attempt, see AcceptAnswers.
+func (a *TestAnswer) SaveProgress(answers []QuestionAnswer) error {
+	if !a.IsOpen() {
+		return errors.New("attempt is closed")
+	}
+	for _, answer := range answers {
+		if answer.QuestionID.IsZero() {
+			return errors.New("invalid QuestionID")
+		}
+	}
+	a.AcceptAnswers(answers)
+	a.LastSavedAt = time.Now()
+	return nil
+}
+
+// Expire closes the attempt with whatever was autosaved.
+func (a *TestAnswer) Expire(deadline time.Time) {
+	a.Status = AttemptExpired
+	a.EndTime = deadline
+	a.closeActiveSection(deadline)
+}
+
 func SubmitAnswer(answer TestAnswer) (*TestAnswer, error) {
 	answer.EndTime = time.Now()
+	answer.Status = AttemptSubmitted
+	answer.Sections = slices.Clone(answer.Sections)
+	answer.closeActiveSection(answer.EndTime)
 
 	// Validate QuestionIDs elements
 	for _, QuestionIDs := range answer.ListQuestionAnswer {
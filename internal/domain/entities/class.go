@@ -21,6 +21,77 @@ type Class struct {
 	Tags          []string             `json:"tags" bson:"tags"`
 	CodeClass     string
 	Test          []Test `json:"test" bson:"test"`
+
+	Accommodations []Accommodation `json:"accommodations,omitempty" bson:"accommodations,omitempty"`
+	Members        []ClassMember   `json:"members,omitempty" bson:"members,omitempty"` // Danh sách thành viên và vai trò, see Roster
+}
+
+// Accommodation overrides the timing of a class's tests for one student,
+// e.g. extra time for a disability or a different window for another time zone.
+type Accommodation struct {
+	Email        string             `json:"email" bson:"email"`
+	TestID       primitive.ObjectID `json:"test_id,omitempty" bson:"test_id,omitempty"` // Rỗng = áp dụng cho mọi bài trong lớp
+	ExtraMinutes int                `json:"extra_minutes,omitempty" bson:"extra_minutes,omitempty"`
+	StartTime    string             `json:"start_time,omitempty" bson:"start_time,omitempty"` // ISO 8601, replaces Test.StartTime
+	EndTime      string             `json:"end_time,omitempty" bson:"end_time,omitempty"`     // ISO 8601, replaces Test.EndTime
+	Note         string             `json:"note,omitempty" bson:"note,omitempty"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+func (a Accommodation) Validate() error {
+	if a.Email == "" {
+		return errors.New("accommodation email is required")
+	}
+	if a.ExtraMinutes < 0 {
+		return errors.New("extra minutes cannot be negative")
+	}
+	start, hasStart := parseTestTime(a.StartTime)
+	end, hasEnd := parseTestTime(a.EndTime)
+	if (a.StartTime != "" && !hasStart) || (a.EndTime != "" && !hasEnd) {
+		return errors.New("invalid accommodation time format")
+	}
+	if hasStart && hasEnd && !start.Before(end) {
+		return errors.New("accommodation start time must be before end time")
+	}
+	return nil
+}
+
+// AnswersReleaseAt returns when the after_end answers of the test are released: the end of
+// the window of the student who finishes last, accommodations included. It reports false
+// when the test has no end time.
+func (c Class) AnswersReleaseAt(test Test) (time.Time, bool) {
+	latest, ok := test.EndAt()
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, acc := range c.Accommodations {
+		if acc.TestID != test.ID && !acc.TestID.IsZero() {
+			continue
+		}
+		// Only the accommodation that applies to the student moves their window
+		if endAt, ok := test.WithAccommodation(c.AccommodationFor(acc.Email, test.ID)).EndAt(); ok && endAt.After(latest) {
+			latest = endAt
+		}
+	}
+	return latest, true
+}
+
+// AccommodationFor returns the student's accommodation for the test.
+// A test-specific accommodation wins over a class-wide one.
+func (c Class) AccommodationFor(email string, testID primitive.ObjectID) *Accommodation {
+	var classWide *Accommodation
+	for i, acc := range c.Accommodations {
+		if acc.Email != email {
+			continue
+		}
+		if acc.TestID == testID {
+			return &c.Accommodations[i]
+		}
+		if acc.TestID.IsZero() {
+			classWide = &c.Accommodations[i]
+		}
+	}
+	return classWide
 }
 
 func (c *Class) Validate() error {
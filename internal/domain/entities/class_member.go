@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Member roles for ClassMember.Role.
+const (
+	RoleOwner     = "owner"
+	RoleCoTeacher = "co_teacher"
+	RoleAssistant = "assistant" // Trợ giảng
+	RoleStudent   = "student"
+)
+
+// Member states for ClassMember.Status.
+const (
+	MemberActive  = "active"
+	MemberPending = "pending" // Đang chờ duyệt
+	MemberBanned  = "banned"  // Cannot join again until removed from the roster
+)
+
+// ClassMember is an entry of the class roster, keyed by email.
+// StudentAccept and StudentsWait are kept in step with the members for older clients.
+type ClassMember struct {
+	Email     string    `json:"email" bson:"email"`
+	Role      string    `json:"role" bson:"role"`
+	Status    string    `json:"status" bson:"status"`
+	AddedBy   string    `json:"added_by,omitempty" bson:"added_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// IsStaffRole reports whether the role teaches the class rather than takes it.
+func IsStaffRole(role string) bool {
+	return role == RoleOwner || role == RoleCoTeacher || role == RoleAssistant
+}
+
+// IsValidRole checks a role that can be given to a member. The owner role cannot.
+func IsValidRole(role string) bool {
+	return role == RoleCoTeacher || role == RoleAssistant || role == RoleStudent
+}
+
+// NormalizeEmail trims and lowercases an email so roster entries compare equal.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Roster returns the members of the class, owner first. Students that were only
+// stored in StudentAccept or StudentsWait are listed as active or pending students.
+func (c Class) Roster() []ClassMember {
+	roster := make([]ClassMember, 0, len(c.Members)+len(c.StudentAccept)+len(c.StudentsWait)+1)
+	seen := make(map[string]bool, cap(roster))
+	add := func(member ClassMember) {
+		key := NormalizeEmail(member.Email)
+		if key == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		roster = append(roster, member)
+	}
+
+	add(ClassMember{Email: c.AuthorMail, Role: RoleOwner, Status: MemberActive, UpdatedAt: c.CreatedAt})
+	for _, member := range c.Members {
+		add(member)
+	}
+	for _, email := range c.StudentAccept {
+		add(ClassMember{Email: email, Role: RoleStudent, Status: MemberActive})
+	}
+	for _, email := range c.StudentsWait {
+		add(ClassMember{Email: email, Role: RoleStudent, Status: MemberPending})
+	}
+	return roster
+}
+
+// Member returns the roster entry of the email.
+func (c Class) Member(email string) (ClassMember, bool) {
+	for _, member := range c.Roster() {
+		if NormalizeEmail(member.Email) == NormalizeEmail(email) {
+			return member, true
+		}
+	}
+	return ClassMember{}, false
+}
+
+// RoleOf returns the role of the user in the class, or "" when the user is not an active member.
+// The owner is recognised by EmailID, everyone else by email.
+func (c Class) RoleOf(emailID, email string) string {
+	if emailID != "" && emailID == c.EmailID {
+		return RoleOwner
+	}
+	member, ok := c.Member(email)
+	if !ok || member.Status != MemberActive || member.Role == RoleOwner {
+		return ""
+	}
+	return member.Role
+}
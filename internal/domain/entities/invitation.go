@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation states. Expired is not stored, see Invitation.State.
+const (
+	InvitationPending  = "pending" // Created, the mail is not sent yet
+	InvitationSent     = "sent"
+	InvitationFailed   = "failed" // The mail could not be sent, the invitation can be resent
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation asks a student, by email, to join a class.
+type Invitation struct {
+	ID         primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	ClassID    primitive.ObjectID `json:"class_id" bson:"class_id"`
+	Email      string             `json:"email" bson:"email"`
+	InvitedBy  string             `json:"invited_by" bson:"invited_by"`
+	Status     string             `json:"status" bson:"status"`
+	SendError  string             `json:"send_error,omitempty" bson:"send_error,omitempty"`
+	SendCount  int                `json:"send_count" bson:"send_count"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	SentAt     time.Time          `json:"sent_at" bson:"sent_at,omitempty"`
+	ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+	AcceptedAt time.Time          `json:"accepted_at" bson:"accepted_at,omitempty"`
+}
+
+// State returns the status of the invitation at the given time, expired once the link is too old.
+func (i Invitation) State(at time.Time) string {
+	if i.IsOpen() && !at.Before(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
+
+// IsOpen reports whether the invitation can still be accepted or resent.
+func (i Invitation) IsOpen() bool {
+	return i.Status == InvitationPending || i.Status == InvitationSent || i.Status == InvitationFailed
+}
+
+// Mail is a plain text message sent by a Mailer.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States reported by JoinCode.Status.
+const (
+	JoinCodeActive  = "active"
+	JoinCodeExpired = "expired"
+	JoinCodeRevoked = "revoked"
+	JoinCodeUsedUp  = "used_up"
+)
+
+// Outcomes recorded in JoinCodeUse.Outcome. The refusals reuse the code states.
+const (
+	JoinJoined           = "joined"
+	JoinPending          = "pending" // Waiting for approval, the class is not public
+	JoinAlreadyMember    = "already_member"
+	JoinBanned           = "banned"
+	JoinDomainNotAllowed = "domain_not_allowed"
+	JoinFailed           = "failed" // The join itself failed, the use was given back
+)
+
+// JoinCode lets students join a class by typing a short code.
+type JoinCode struct {
+	ID             primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	Code           string             `json:"code" bson:"code"`
+	ClassID        primitive.ObjectID `json:"class_id" bson:"class_id"`
+	CreatedBy      string             `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt      time.Time          `json:"expires_at" bson:"expires_at,omitempty"` // Zero = không hết hạn
+	MaxUses        int                `json:"max_uses" bson:"max_uses"`               // 0 = unlimited, 1 = single use
+	Uses           int                `json:"uses" bson:"uses"`
+	AllowedDomains []string           `json:"allowed_domains,omitempty" bson:"allowed_domains,omitempty"` // e.g. "school.edu", empty = any email
+	RevokedAt      time.Time          `json:"revoked_at" bson:"revoked_at,omitempty"`
+	RevokedBy      string             `json:"revoked_by,omitempty" bson:"revoked_by,omitempty"`
+}
+
+// Status tells whether the code can still be used at the given time.
+func (c JoinCode) Status(at time.Time) string {
+	switch {
+	case !c.RevokedAt.IsZero():
+		return JoinCodeRevoked
+	case !c.ExpiresAt.IsZero() && !at.Before(c.ExpiresAt):
+		return JoinCodeExpired
+	case c.MaxUses > 0 && c.Uses >= c.MaxUses:
+		return JoinCodeUsedUp
+	}
+	return JoinCodeActive
+}
+
+// AllowsEmail checks the email against the domain allow-list of the code.
+func (c JoinCode) AllowsEmail(email string) bool {
+	if len(c.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	return slices.Contains(c.AllowedDomains, NormalizeEmail(email[at+1:]))
+}
+
+// NormalizeJoinCode uppercases a code typed by a student and drops spaces and dashes.
+func NormalizeJoinCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// NormalizeDomain turns "@School.edu" into "school.edu".
+func NormalizeDomain(domain string) string {
+	return strings.TrimPrefix(NormalizeEmail(domain), "@")
+}
+
+// JoinCodeUse is an entry of the audit log of a class: someone used one of its codes.
+type JoinCodeUse struct {
+	ID      primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	CodeID  primitive.ObjectID `json:"code_id" bson:"code_id"`
+	Code    string             `json:"code" bson:"code"`
+	ClassID primitive.ObjectID `json:"class_id" bson:"class_id"`
+	Email   string             `json:"email" bson:"email"`
+	Outcome string             `json:"outcome" bson:"outcome"`
+	At      time.Time          `json:"at" bson:"at"`
+}
@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJoinCodeStatus(t *testing.T) {
+	now := time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		code JoinCode
+		want string
+	}{
+		{"no limits", JoinCode{}, JoinCodeActive},
+		{"before expiry", JoinCode{ExpiresAt: now.Add(time.Minute)}, JoinCodeActive},
+		{"at expiry", JoinCode{ExpiresAt: now}, JoinCodeExpired},
+		{"after expiry", JoinCode{ExpiresAt: now.Add(-time.Minute)}, JoinCodeExpired},
+		{"uses left", JoinCode{MaxUses: 3, Uses: 2}, JoinCodeActive},
+		{"used up", JoinCode{MaxUses: 3, Uses: 3}, JoinCodeUsedUp},
+		{"single use, used", JoinCode{MaxUses: 1, Uses: 1}, JoinCodeUsedUp},
+		{"unlimited uses", JoinCode{Uses: 1000}, JoinCodeActive},
+		{"revoked", JoinCode{RevokedAt: now.Add(-time.Hour)}, JoinCodeRevoked},
+		{"revoked wins over expired and used up", JoinCode{RevokedAt: now, ExpiresAt: now.Add(-time.Hour), MaxUses: 1, Uses: 1}, JoinCodeRevoked},
+		{"expired wins over used up", JoinCode{ExpiresAt: now.Add(-time.Hour), MaxUses: 1, Uses: 1}, JoinCodeExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.code.Status(now); got != tt.want {
+				t.Errorf("Status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinCodeAllowsEmail(t *testing.T) {
+	code := JoinCode{AllowedDomains: []string{"school.edu"}}
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"an@school.edu", true},
+		{"An@School.EDU", true},
+		{"an@mail.school.edu", false},
+		{"an@gmail.com", false},
+		{"no-at-sign", false},
+	}
+	for _, tt := range tests {
+		if got := code.AllowsEmail(tt.email); got != tt.want {
+			t.Errorf("AllowsEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+	if !(JoinCode{}).AllowsEmail("an@gmail.com") {
+		t.Errorf("a code without domains should allow any email")
+	}
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Permissions checked by the authorization service.
+const (
+	PermView         = "view"
+	PermEdit         = "edit"
+	PermGrade        = "grade"
+	PermManageRoster = "manage_roster"
+
+	// PermOwn covers deleting a class or test and sharing it. Only the owner holds it, it is never granted.
+	PermOwn = "own"
+)
+
+// Resources a permission can be granted on.
+const (
+	ResourceClass = "class"
+	ResourceTest  = "test" // A test of the author's bank, and the copies of it added to classes
+)
+
+// Grant gives a user permissions on one class or test, on top of any role they have there.
+type Grant struct {
+	ID           primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	ResourceType string             `json:"resource_type" bson:"resource_type"`
+	ResourceID   primitive.ObjectID `json:"resource_id" bson:"resource_id"`
+	Email        string             `json:"email" bson:"email"`
+	Permissions  []string           `json:"permissions" bson:"permissions"`
+	GrantedBy    string             `json:"granted_by" bson:"granted_by"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Has reports whether the grant includes perm.
+func (g Grant) Has(perm string) bool {
+	return slices.Contains(g.Permissions, perm)
+}
+
+// IsGrantable checks a permission that can be granted on the resource type.
+// The roster only exists on classes.
+func IsGrantable(resourceType, perm string) bool {
+	switch perm {
+	case PermView, PermEdit, PermGrade:
+		return resourceType == ResourceClass || resourceType == ResourceTest
+	case PermManageRoster:
+		return resourceType == ResourceClass
+	}
+	return false
+}
+
+// RolePermissions returns what a role of the class roster may do without any grant.
+// Students have no permission on the class itself, they take its tests.
+func RolePermissions(role string) []string {
+	switch role {
+	case RoleOwner:
+		return []string{PermView, PermEdit, PermGrade, PermManageRoster, PermOwn}
+	case RoleCoTeacher:
+		return []string{PermView, PermEdit, PermGrade, PermManageRoster}
+	case RoleAssistant:
+		return []string{PermView, PermGrade}
+	}
+	return nil
+}
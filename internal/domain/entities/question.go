@@ -1,20 +1,39 @@
 package entity
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Question types understood by the application.
+const (
+	QuestionTypeSingleChoice   = "single_choice_question"
+	QuestionTypeMultipleChoice = "multiple_choice_question"
+	QuestionTypeFillInTheBlank = "fill_in_the_blank"
+	QuestionTypeOrder          = "order_question"
+	QuestionTypeMatchChoice    = "match_choice_question"
+	QuestionTypeEssay          = "essay_question"        // Graded by a teacher
+	QuestionTypeShortAnswer    = "short_answer_question" // Graded by a teacher
+	QuestionTypeTrueFalse      = "true_false_question"
+	QuestionTypeNumeric        = "numeric_question"
+	QuestionTypeHotspot        = "hotspot_question" // Click regions on QuestionContent.ImageURL
+	QuestionTypeMatrix         = "matrix_question"  // Grid of rows and columns, also used for Likert scales
+)
+
 // Question represents the main question structure.
 type Question struct {
 	ID              primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	Type            string             `json:"type" bson:"type,omitempty"` // "fill_in_the_blank", "multiple_choice_single", ...
+	Type            string             `json:"type" bson:"type,omitempty"` // One of the QuestionType* names, see service.LookupQuestionType
 	QuestionContent QuestionContent    `json:"question_content,omitempty" bson:"question_content,omitempty"`
 	Metadata        Metadata           `json:"metadata,omitempty" bson:"metadata,omitempty"`
 	Tags            []string           `json:"tags,omitempty" bson:"tags,omitempty"`
 	Suggestion      []string           `json:"suggestion,omitempty" bson:"suggestion,omitempty"`
 	Score           float32            `json:"score,omitempty" bson:"score,omitempty"`
+	Difficulty      string             `json:"difficulty,omitempty" bson:"difficulty,omitempty"` // "easy", "medium", "hard"
+	ScoringPolicy   *ScoringPolicy     `json:"scoring_policy,omitempty" bson:"scoring_policy,omitempty"`
+	Version         int                `json:"version,omitempty" bson:"version,omitempty"` // Tăng mỗi lần sửa, see QuestionVersion
 	Created_At      time.Time          `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	Updated_At      time.Time          `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
 
@@ -24,9 +43,63 @@ type Question struct {
 	OrderItems      []OrderItem      `json:"order_items,omitempty" bson:"order_items,omitempty"`               // for ordering_question
 	MatchItems      []MatchItem      `json:"match_items,omitempty" bson:"match_items,omitempty"`               // for match_choice
 	MatchOptions    []MatchOption    `json:"match_options,omitempty" bson:"match_options,omitempty"`           // for match_choice
+	TrueFalse       *bool            `json:"true_false,omitempty" bson:"true_false,omitempty"`                 // for true_false: the correct answer
+	Numeric         *NumericAnswer   `json:"numeric,omitempty" bson:"numeric,omitempty"`                       // for numeric
+	Hotspots        []Hotspot        `json:"hotspots,omitempty" bson:"hotspots,omitempty"`                     // for hotspot
+	Matrix          *Matrix          `json:"matrix,omitempty" bson:"matrix,omitempty"`                         // for matrix
 	// CorrectMap      map[string]string `json:"correct_map,omitempty" bson:"correct_map,omitempty"`     // e.g. for match/map-based validation
 }
 
+// ValidateCommon checks the fields shared by every question type. The rules of each
+// type are checked by the type itself, see service.PrepareQuestion.
+func (q Question) ValidateCommon(errs *ValidationErrors) {
+	if q.Score <= 0 {
+		errs.Add("score", "must be positive")
+	}
+	if strings.TrimSpace(q.QuestionContent.Text) == "" && q.QuestionContent.ImageURL == "" {
+		errs.Add("question_content.text", "question needs a text or an image")
+	}
+	switch q.Difficulty {
+	case "", DifficultyEasy, DifficultyMedium, DifficultyHard:
+	default:
+		errs.Add("difficulty", "must be easy, medium or hard")
+	}
+	if q.ScoringPolicy != nil {
+		switch q.ScoringPolicy.Mode {
+		case "", ScoringAllOrNothing, ScoringProportional, ScoringRightMinusWrong, ScoringWeighted:
+		default:
+			errs.Add("scoring_policy.mode", "unknown scoring mode %q", q.ScoringPolicy.Mode)
+		}
+		if q.ScoringPolicy.Penalty < 0 {
+			errs.Add("scoring_policy.penalty", "cannot be negative")
+		}
+	}
+}
+
+// Difficulty levels for Question.Difficulty.
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// Scoring modes for ScoringPolicy.Mode.
+const (
+	ScoringAllOrNothing    = "all_or_nothing"    // Full score only when every part is right
+	ScoringProportional    = "proportional"      // Score split evenly between the parts, each wrong tick takes one off
+	ScoringRightMinusWrong = "right_minus_wrong" // Like proportional, wrong parts remove points
+	ScoringWeighted        = "weighted"          // Score split by the Weight of each part, wrong ticks take theirs off
+)
+
+// ScoringPolicy controls how partially correct answers are scored.
+type ScoringPolicy struct {
+	Mode string `json:"mode" bson:"mode,omitempty"`
+	// Penalty is the fraction of a part's value removed for each wrong part (right_minus_wrong only, default 1).
+	Penalty float32 `json:"penalty,omitempty" bson:"penalty,omitempty"`
+	// AllowNegative lets right_minus_wrong go below zero for the question.
+	AllowNegative bool `json:"allow_negative,omitempty" bson:"allow_negative,omitempty"`
+}
+
 // Metadata represents the metadata for the question.
 type Metadata struct {
 	Author string `json:"author" bson:"author,omitempty"`
@@ -40,6 +113,7 @@ type MatchOption struct {
 	ID      primitive.ObjectID `json:"id" bson:"id,omitempty"`
 	Text    string             `json:"text" bson:"text,omitempty"`
 	MatchId string             `json:"match_id" bson:"match_id,omitempty"`
+	Weight  float32            `json:"weight,omitempty" bson:"weight,omitempty"` // Trọng số khi chấm weighted
 }
 
 // FillInTheBlank represents a fill-in-the-blank part of the question.
@@ -49,6 +123,43 @@ type FillInTheBlank struct {
 	Blank         string             `json:"blank" bson:"blank,omitempty"`
 	CorrectAnswer string             `json:"correct_answer" bson:"correct_answer,omitempty"`
 	TextAfter     string             `json:"text_after" bson:"text_after,omitempty"`
+	Weight        float32            `json:"weight,omitempty" bson:"weight,omitempty"`
+
+	// AcceptedAnswers are alternatives accepted in addition to CorrectAnswer
+	AcceptedAnswers []string        `json:"accepted_answers,omitempty" bson:"accepted_answers,omitempty"`
+	Matching        *AnswerMatching `json:"matching,omitempty" bson:"matching,omitempty"`
+}
+
+// AcceptedValues returns CorrectAnswer followed by the non-empty AcceptedAnswers.
+func (f FillInTheBlank) AcceptedValues() []string {
+	values := make([]string, 0, len(f.AcceptedAnswers)+1)
+	if f.CorrectAnswer != "" {
+		values = append(values, f.CorrectAnswer)
+	}
+	for _, value := range f.AcceptedAnswers {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Matching modes for AnswerMatching.Mode.
+const (
+	MatchModeText    = "text"    // So sánh chuỗi (mặc định)
+	MatchModeRegex   = "regex"   // Accepted values are regular expressions matched against the whole answer
+	MatchModeNumeric = "numeric" // Accepted values are numbers, compared within Tolerance
+)
+
+// AnswerMatching configures how a blank's answer is compared with the accepted values.
+// The zero value is a strict comparison.
+type AnswerMatching struct {
+	Mode            string  `json:"mode,omitempty" bson:"mode,omitempty"`
+	CaseInsensitive bool    `json:"case_insensitive,omitempty" bson:"case_insensitive,omitempty"`
+	NormalizeSpace  bool    `json:"normalize_space,omitempty" bson:"normalize_space,omitempty"`     // Trim and collapse whitespace
+	FoldDiacritics  bool    `json:"fold_diacritics,omitempty" bson:"fold_diacritics,omitempty"`     // "Hà Nội" == "Ha Noi"
+	Tolerance       float64 `json:"tolerance,omitempty" bson:"tolerance,omitempty"`                 // numeric mode only
+	MaxEditDistance int     `json:"max_edit_distance,omitempty" bson:"max_edit_distance,omitempty"` // text mode only, typos allowed
 }
 
 // Option represents each option in the question.
@@ -57,12 +168,14 @@ type Option struct {
 	Text      string             `json:"text,omitempty" bson:"text,omitempty"`
 	ImageURL  string             `json:"imageurl,omitempty" bson:"imageurl,omitempty"`
 	IsCorrect bool               `json:"iscorrect,omitempty" bson:"iscorrect,omitempty"`
+	Weight    float32            `json:"weight,omitempty" bson:"weight,omitempty"`
 }
 
 type OrderItem struct {
-	ID    primitive.ObjectID `json:"id" bson:"id,omitempty"`
-	Text  string             `json:"text" bson:"text,omitempty"`
-	Order int                `json:"order" bson:"order,omitempty"` // Đáp án đúng theo thứ tự
+	ID     primitive.ObjectID `json:"id" bson:"id,omitempty"`
+	Text   string             `json:"text" bson:"text,omitempty"`
+	Order  int                `json:"order" bson:"order,omitempty"` // Đáp án đúng theo thứ tự
+	Weight float32            `json:"weight,omitempty" bson:"weight,omitempty"`
 }
 
 // QuestionContent represents the content of the question.
@@ -72,3 +185,89 @@ type QuestionContent struct {
 	VideoURL string `json:"video_url" bson:"video_url,omitempty"`
 	AudioURL string `json:"audio_url" bson:"audio_url,omitempty"`
 }
+
+// EffectiveScoringPolicy returns the question's policy, falling back to defaultMode,
+// the default of the question's type.
+func (q Question) EffectiveScoringPolicy(defaultMode string) ScoringPolicy {
+	if q.ScoringPolicy != nil && q.ScoringPolicy.Mode != "" {
+		policy := *q.ScoringPolicy
+		if policy.Penalty <= 0 {
+			policy.Penalty = 1
+		}
+		return policy
+	}
+	if defaultMode == "" {
+		defaultMode = ScoringAllOrNothing
+	}
+	return ScoringPolicy{Mode: defaultMode, Penalty: 1}
+}
+
+// NumericAnswer is the expected value of a numeric question.
+// Answers in one of Units are converted to Unit before comparing within Tolerance.
+type NumericAnswer struct {
+	Value       float64       `json:"value" bson:"value"`
+	Tolerance   float64       `json:"tolerance,omitempty" bson:"tolerance,omitempty"`
+	Unit        string        `json:"unit,omitempty" bson:"unit,omitempty"` // Đơn vị gốc, ví dụ "m"
+	Units       []NumericUnit `json:"units,omitempty" bson:"units,omitempty"`
+	RequireUnit bool          `json:"require_unit,omitempty" bson:"require_unit,omitempty"`
+}
+
+// NumericUnit is an accepted unit: a value in this unit times Factor gives the value in the base unit.
+type NumericUnit struct {
+	Name   string  `json:"name" bson:"name"`
+	Factor float64 `json:"factor" bson:"factor"`
+}
+
+// Hotspot shapes.
+const (
+	HotspotRect   = "rect"
+	HotspotCircle = "circle"
+)
+
+// Hotspot is a click region on the question image.
+// Coordinates are fractions of the image size, from 0 to 1.
+type Hotspot struct {
+	ID        primitive.ObjectID `json:"id" bson:"id,omitempty"`
+	Shape     string             `json:"shape" bson:"shape"`
+	X         float64            `json:"x" bson:"x"` // rect: left edge, circle: center
+	Y         float64            `json:"y" bson:"y"` // rect: top edge, circle: center
+	Width     float64            `json:"width,omitempty" bson:"width,omitempty"`
+	Height    float64            `json:"height,omitempty" bson:"height,omitempty"`
+	Radius    float64            `json:"radius,omitempty" bson:"radius,omitempty"`
+	IsCorrect bool               `json:"iscorrect,omitempty" bson:"iscorrect,omitempty"`
+	Weight    float32            `json:"weight,omitempty" bson:"weight,omitempty"`
+}
+
+// HotspotPoint is a click on the question image, in the same coordinates as Hotspot.
+type HotspotPoint struct {
+	X float64 `json:"x" bson:"x"`
+	Y float64 `json:"y" bson:"y"`
+}
+
+// Contains reports whether the point lies inside the hotspot.
+func (h Hotspot) Contains(p HotspotPoint) bool {
+	if h.Shape == HotspotCircle {
+		dx, dy := p.X-h.X, p.Y-h.Y
+		return dx*dx+dy*dy <= h.Radius*h.Radius
+	}
+	return p.X >= h.X && p.X <= h.X+h.Width && p.Y >= h.Y && p.Y <= h.Y+h.Height
+}
+
+// Matrix is a grid question: the student picks one column per row.
+// Rows without a CorrectColumn accept any column (Likert scales).
+type Matrix struct {
+	Rows    []MatrixRow    `json:"rows" bson:"rows"`
+	Columns []MatrixColumn `json:"columns" bson:"columns"`
+}
+
+type MatrixRow struct {
+	ID            primitive.ObjectID `json:"id" bson:"id,omitempty"`
+	Text          string             `json:"text" bson:"text,omitempty"`
+	CorrectColumn primitive.ObjectID `json:"correct_column,omitempty" bson:"correct_column,omitempty"`
+	Weight        float32            `json:"weight,omitempty" bson:"weight,omitempty"`
+}
+
+type MatrixColumn struct {
+	ID   primitive.ObjectID `json:"id" bson:"id,omitempty"`
+	Text string             `json:"text" bson:"text,omitempty"`
+}
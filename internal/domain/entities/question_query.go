@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not produced by the same query.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort keys for QuestionQuery.SortBy.
+const (
+	QuestionSortCreated = "created_at" // Mặc định, theo thứ tự tạo (_id)
+	QuestionSortUpdated = "updated_at"
+	QuestionSortScore   = "score"
+)
+
+// QuestionQuery filters an author's question bank. Zero fields do not filter.
+type QuestionQuery struct {
+	Author     string
+	Types      []string // Any of these types
+	Tags       []string // Every one of these tags
+	Text       string   // Full-text search over QuestionContent.Text
+	Difficulty string
+	MinScore   *float32
+	MaxScore   *float32
+
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+
+	SortBy string
+	Desc   bool
+	Limit  int
+	Cursor string // NextCursor of the previous page, empty for the first one
+}
+
+// QuestionPage is one page of a QuestionQuery. Total counts every match, not only this page.
+type QuestionPage struct {
+	Questions  []Question `json:"questions"`
+	Total      int64      `json:"total"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
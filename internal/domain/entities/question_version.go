@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuestionVersion is an immutable snapshot of a question, stored on every create, update and restore.
+// Snapshots outlive the question so attempts can still be graded and reviewed after a delete.
+type QuestionVersion struct {
+	ID           primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	QuestionID   primitive.ObjectID `json:"question_id" bson:"question_id"`
+	Version      int                `json:"version" bson:"version"`
+	Question     Question           `json:"question" bson:"question"`
+	EditedBy     string             `json:"edited_by" bson:"edited_by"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	RestoredFrom int                `json:"restored_from,omitempty" bson:"restored_from,omitempty"` // Version copied by a restore
+}
+
+// QuestionVersionRef pins the version of a question an attempt was shown.
+type QuestionVersionRef struct {
+	QuestionID primitive.ObjectID `json:"question_id" bson:"question_id"`
+	Version    int                `json:"version" bson:"version"`
+}
+
+// CurrentVersion returns the question's version. Questions stored before versioning are version 1.
+func (q Question) CurrentVersion() int {
+	return max(q.Version, 1)
+}
+
+// FieldChange is one top-level field that differs between two versions of a question.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
@@ -2,6 +2,7 @@ package entity
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -22,6 +23,237 @@ type Test struct {
 	EmailID         string               `json:"email_id" bson:"email_id"`
 	EmailName       string               `json:"author_mail" bson:"author_mail"`
 	AnswerUser      []string             `json:"answer_user" bson:"answer_user"` // Email user done test
+	RetakePolicy    *RetakePolicy        `json:"retake_policy,omitempty" bson:"retake_policy,omitempty"`
+	LatePolicy      string               `json:"late_policy,omitempty" bson:"late_policy,omitempty"`   // "reject" (mặc định) hoặc "flag"
+	LateMinutes     int                  `json:"late_minutes,omitempty" bson:"late_minutes,omitempty"` // flag only: how long late submissions are accepted
+	DrawRules       []DrawRule           `json:"draw_rules,omitempty" bson:"draw_rules,omitempty"`     // Câu hỏi rút ngẫu nhiên cho mỗi lượt làm bài
+	ReviewPolicy    string               `json:"review_policy,omitempty" bson:"review_policy,omitempty"`
+	AnswersReleased bool                 `json:"answers_released,omitempty" bson:"answers_released,omitempty"` // manual policy: set by the teacher
+	IsTemplate      bool                 `json:"is_template,omitempty" bson:"is_template,omitempty"`           // Khuôn mẫu, dùng để tạo bài cho nhiều lớp
+	TemplateID      primitive.ObjectID   `json:"template_id,omitempty" bson:"template_id,omitempty"`           // The test this one was cloned from
+	Sections        []TestSection        `json:"sections,omitempty" bson:"sections,omitempty"`                 // Phần thi làm lần lượt, QuestionIDs lists all their questions
+	BlindGrading    bool                 `json:"blind_grading,omitempty" bson:"blind_grading,omitempty"`       // Graders do not see student emails, set by the class owner
+
+	// ReleaseAt is when after_end answers are released, see Class.AnswersReleaseAt.
+	// Set when the test is loaded for a student, never stored.
+	ReleaseAt time.Time `json:"-" bson:"-"`
+}
+
+// Shift moves StartTime and EndTime by offset. Unset or invalid times are left as they are.
+func (t *Test) Shift(offset time.Duration) {
+	if startAt, ok := t.StartAt(); ok {
+		t.StartTime = startAt.Add(offset).Format(time.RFC3339)
+	}
+	if endAt, ok := t.EndAt(); ok {
+		t.EndTime = endAt.Add(offset).Format(time.RFC3339)
+	}
+}
+
+// Review policies for Test.ReviewPolicy: when students may see the answer keys after submitting.
+const (
+	ReviewImmediately = "immediately"
+	ReviewAfterEnd    = "after_end" // Once EndTime has passed
+	ReviewManual      = "manual"    // Once the teacher releases the answers
+	ReviewNever       = "never"
+)
+
+// EffectiveReviewPolicy defaults to immediately for practice and after_end for graded tests.
+func (t Test) EffectiveReviewPolicy() string {
+	if t.ReviewPolicy != "" {
+		return t.ReviewPolicy
+	}
+	if t.IsTest {
+		return ReviewAfterEnd
+	}
+	return ReviewImmediately
+}
+
+// AnswersVisible reports whether the answer keys may be shown to a student who submitted.
+// after_end waits for ReleaseAt when set, otherwise for EndTime; a test without an end is never released.
+func (t Test) AnswersVisible(now time.Time) bool {
+	switch t.EffectiveReviewPolicy() {
+	case ReviewImmediately:
+		return true
+	case ReviewAfterEnd:
+		endAt := t.ReleaseAt
+		if endAt.IsZero() {
+			var ok bool
+			if endAt, ok = t.EndAt(); !ok {
+				return false
+			}
+		}
+		return now.After(endAt)
+	case ReviewManual:
+		return t.AnswersReleased
+	default:
+		return false
+	}
+}
+
+// DrawRule draws Count random questions from the author's bank for every attempt.
+// A question matches when it has all Tags and, if set, the Type and Difficulty.
+type DrawRule struct {
+	Count      int      `json:"count" bson:"count"`
+	Tags       []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	Type       string   `json:"type,omitempty" bson:"type,omitempty"`
+	Difficulty string   `json:"difficulty,omitempty" bson:"difficulty,omitempty"`
+	Score      float32  `json:"score,omitempty" bson:"score,omitempty"` // Points per drawn question, 0 keeps Question.Score
+}
+
+// ValidateDrawRules checks the draw rules of the test.
+func (t Test) ValidateDrawRules() error {
+	return validateDrawRules(t.DrawRules)
+}
+
+func validateDrawRules(rules []DrawRule) error {
+	for _, rule := range rules {
+		if rule.Count <= 0 {
+			return errors.New("draw rule count must be positive")
+		}
+		if rule.Score < 0 {
+			return errors.New("draw rule score cannot be negative")
+		}
+	}
+	return nil
+}
+
+// Late submission policies for Test.LatePolicy.
+const (
+	LatePolicyReject = "reject"
+	LatePolicyFlag   = "flag"
+)
+
+// EndAt parses EndTime, reporting false when the test has no valid end time.
+func (t Test) EndAt() (time.Time, bool) {
+	return parseTestTime(t.EndTime)
+}
+
+// StartAt parses StartTime, reporting false when the test has no valid start time.
+func (t Test) StartAt() (time.Time, bool) {
+	return parseTestTime(t.StartTime)
+}
+
+// WithAccommodation returns a copy of the test with the student's overrides applied.
+// Extra time also pushes the end of the window back unless the accommodation sets its own EndTime,
+// and is given to every timed section.
+func (t Test) WithAccommodation(acc *Accommodation) Test {
+	if acc == nil {
+		return t
+	}
+	if acc.StartTime != "" {
+		t.StartTime = acc.StartTime
+	}
+	if acc.ExtraMinutes > 0 {
+		if t.DurationMinutes > 0 {
+			t.DurationMinutes += acc.ExtraMinutes
+		}
+		t.Sections = slices.Clone(t.Sections)
+		for i := range t.Sections {
+			if t.Sections[i].DurationMinutes > 0 {
+				t.Sections[i].DurationMinutes += acc.ExtraMinutes
+			}
+		}
+		if endAt, ok := t.EndAt(); ok && acc.EndTime == "" {
+			t.EndTime = endAt.Add(time.Duration(acc.ExtraMinutes) * time.Minute).Format(time.RFC3339)
+		}
+	}
+	if acc.EndTime != "" {
+		t.EndTime = acc.EndTime
+	}
+	return t
+}
+
+// IsOpenAt checks if now is inside the test's StartTime/EndTime window.
+func (t Test) IsOpenAt(now time.Time) bool {
+	startAt, okStart := t.StartAt()
+	endAt, okEnd := t.EndAt()
+	if !okStart || !okEnd {
+		return false
+	}
+	return startAt.Before(now) && endAt.After(now)
+}
+
+// LateWindow is how long after the deadline submissions are still accepted and flagged.
+func (t Test) LateWindow() time.Duration {
+	if t.LatePolicy != LatePolicyFlag || t.LateMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(t.LateMinutes) * time.Minute
+}
+
+// parseTestTime parses the ISO 8601 times stored on a test, e.g. "2025-04-25T18:00:00.000Z".
+func parseTestTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Score modes for RetakePolicy.ScoreMode.
+const (
+	ScoreModeBest    = "best"
+	ScoreModeLatest  = "latest"
+	ScoreModeAverage = "average"
+)
+
+// RetakePolicy controls how many attempts a student gets and which score counts.
+type RetakePolicy struct {
+	MaxAttempts     int    `json:"max_attempts" bson:"max_attempts"`         // 0 = không giới hạn
+	CooldownMinutes int    `json:"cooldown_minutes" bson:"cooldown_minutes"` // Time between the end of an attempt and the next start
+	ScoreMode       string `json:"score_mode" bson:"score_mode"`
+}
+
+// EffectiveRetakePolicy returns the test's policy or the default one:
+// a single attempt for graded tests, unlimited attempts for practice tests.
+func (t Test) EffectiveRetakePolicy() RetakePolicy {
+	policy := RetakePolicy{MaxAttempts: 1, ScoreMode: ScoreModeLatest}
+	if !t.IsTest {
+		policy.MaxAttempts = 0
+	}
+	if t.RetakePolicy != nil {
+		policy = *t.RetakePolicy
+	}
+	if policy.ScoreMode == "" {
+		policy.ScoreMode = ScoreModeLatest
+	}
+	return policy
+}
+
+// FinalScore combines the scores of the closed attempts according to ScoreMode.
+// attempts must be ordered by AttemptNumber.
+func (p RetakePolicy) FinalScore(attempts []TestAnswer) float32 {
+	var closed []TestAnswer
+	for _, attempt := range attempts {
+		if !attempt.IsOpen() {
+			closed = append(closed, attempt)
+		}
+	}
+	if len(closed) == 0 {
+		return 0
+	}
+
+	switch p.ScoreMode {
+	case ScoreModeBest:
+		best := closed[0].TotalScore
+		for _, attempt := range closed[1:] {
+			if attempt.TotalScore > best {
+				best = attempt.TotalScore
+			}
+		}
+		return best
+	case ScoreModeAverage:
+		var sum float32
+		for _, attempt := range closed {
+			sum += attempt.TotalScore
+		}
+		return sum / float32(len(closed))
+	default:
+		return closed[len(closed)-1].TotalScore
+	}
 }
 
 func CreateNewTest(test Test) (Test, error) {
@@ -36,6 +268,9 @@ func CreateNewTest(test Test) (Test, error) {
 			return Test{}, errors.New("invalid QuestionID")
 		}
 	}
+	if err := test.ValidateDrawRules(); err != nil {
+		return Test{}, err
+	}
 
 	// Set CreatedAt and UpdatedAt timestamps
 	test.CreatedAt = time.Now()
@@ -0,0 +1,255 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Navigation modes for TestSection.Navigation.
+const (
+	NavigationFree   = "free"   // Move between the section's questions freely
+	NavigationLinear = "linear" // One question at a time, no going back
+)
+
+// TestSection is an ordered, separately timed part of a test, e.g. the listening,
+// reading and writing parts of a language exam. Sections are taken one after another.
+type TestSection struct {
+	ID              primitive.ObjectID   `json:"id" bson:"id"`
+	Title           string               `json:"title" bson:"title"`
+	Instructions    string               `json:"instructions,omitempty" bson:"instructions,omitempty"`
+	QuestionIDs     []primitive.ObjectID `json:"question_ids" bson:"question_ids"`
+	DrawRules       []DrawRule           `json:"draw_rules,omitempty" bson:"draw_rules,omitempty"`
+	DurationMinutes int                  `json:"duration_minutes,omitempty" bson:"duration_minutes,omitempty"` // 0 = chỉ áp dụng giới hạn của bài
+	Navigation      string               `json:"navigation,omitempty" bson:"navigation,omitempty"`             // "free" (mặc định) hoặc "linear"
+	MinScore        float32              `json:"min_score,omitempty" bson:"min_score,omitempty"`               // Needed in this section to unlock the next one
+}
+
+// EffectiveNavigation defaults to free navigation.
+func (s TestSection) EffectiveNavigation() string {
+	if s.Navigation == "" {
+		return NavigationFree
+	}
+	return s.Navigation
+}
+
+// Section returns the section with the given ID.
+func (t Test) Section(id primitive.ObjectID) (TestSection, bool) {
+	for _, section := range t.Sections {
+		if section.ID == id {
+			return section, true
+		}
+	}
+	return TestSection{}, false
+}
+
+// TimeLimitMinutes is the time allowed for an attempt: DurationMinutes, or the sum of
+// the section limits when every section is timed. 0 means no limit.
+func (t Test) TimeLimitMinutes() int {
+	if t.DurationMinutes > 0 || len(t.Sections) == 0 {
+		return t.DurationMinutes
+	}
+	total := 0
+	for _, section := range t.Sections {
+		if section.DurationMinutes <= 0 {
+			return 0
+		}
+		total += section.DurationMinutes
+	}
+	return total
+}
+
+// PrepareSections checks the sections, gives new ones an ID and sets QuestionIDs to the
+// fixed questions of all sections, so code that only knows QuestionIDs still sees them.
+func (t *Test) PrepareSections() error {
+	if len(t.Sections) == 0 {
+		return nil
+	}
+	if len(t.DrawRules) > 0 {
+		return errors.New("draw rules of a test with sections belong to the sections")
+	}
+
+	sectionIDs := make(map[primitive.ObjectID]struct{}, len(t.Sections))
+	questionIDs := make([]primitive.ObjectID, 0, len(t.QuestionIDs))
+	for i := range t.Sections {
+		section := &t.Sections[i]
+		if section.ID.IsZero() {
+			section.ID = primitive.NewObjectID()
+		}
+		if _, ok := sectionIDs[section.ID]; ok {
+			return fmt.Errorf("section %d: duplicate section id", i+1)
+		}
+		sectionIDs[section.ID] = struct{}{}
+
+		if strings.TrimSpace(section.Title) == "" {
+			return fmt.Errorf("section %d: title is required", i+1)
+		}
+		if nav := section.EffectiveNavigation(); nav != NavigationFree && nav != NavigationLinear {
+			return fmt.Errorf("section %d: navigation must be free or linear", i+1)
+		}
+		if section.DurationMinutes < 0 {
+			return fmt.Errorf("section %d: duration cannot be negative", i+1)
+		}
+		if section.MinScore < 0 {
+			return fmt.Errorf("section %d: min score cannot be negative", i+1)
+		}
+		if len(section.QuestionIDs) == 0 && len(section.DrawRules) == 0 {
+			return fmt.Errorf("section %d: no questions", i+1)
+		}
+		if err := validateDrawRules(section.DrawRules); err != nil {
+			return fmt.Errorf("section %d: %w", i+1, err)
+		}
+		for _, id := range section.QuestionIDs {
+			if id.IsZero() {
+				return fmt.Errorf("section %d: invalid QuestionID", i+1)
+			}
+			if slices.Contains(questionIDs, id) {
+				return fmt.Errorf("section %d: question %s is used twice", i+1, id.Hex())
+			}
+			questionIDs = append(questionIDs, id)
+		}
+	}
+	t.QuestionIDs = questionIDs
+	return nil
+}
+
+// MapQuestionIDs replaces the fixed questions of the test and its sections with mapID,
+// dropping those it reports false for. The sections are copied, not changed in place.
+func (t *Test) MapQuestionIDs(mapID func(primitive.ObjectID) (primitive.ObjectID, bool)) {
+	mapIDs := func(ids []primitive.ObjectID) []primitive.ObjectID {
+		mapped := make([]primitive.ObjectID, 0, len(ids))
+		for _, id := range ids {
+			if newID, ok := mapID(id); ok {
+				mapped = append(mapped, newID)
+			}
+		}
+		return mapped
+	}
+
+	t.QuestionIDs = mapIDs(t.QuestionIDs)
+	t.Sections = slices.Clone(t.Sections)
+	for i := range t.Sections {
+		t.Sections[i].QuestionIDs = mapIDs(t.Sections[i].QuestionIDs)
+	}
+}
+
+// Section states for SectionProgress.Status.
+const (
+	SectionPending   = "pending"
+	SectionActive    = "in_progress"
+	SectionCompleted = "completed"
+	SectionLocked    = "locked" // An earlier section scored below its MinScore
+)
+
+// SectionProgress is the state of one test section within an attempt.
+type SectionProgress struct {
+	SectionID   primitive.ObjectID   `json:"section_id" bson:"section_id"`
+	QuestionIDs []primitive.ObjectID `json:"question_ids" bson:"question_ids"` // Fixed and drawn questions, in the order shown
+	Navigation  string               `json:"navigation" bson:"navigation"`
+	Status      string               `json:"status" bson:"status"`
+	Position    int                  `json:"position" bson:"position"` // linear: index of the current question
+	StartedAt   time.Time            `json:"started_at" bson:"started_at,omitempty"`
+	Deadline    time.Time            `json:"deadline" bson:"deadline,omitempty"`
+	EndedAt     time.Time            `json:"ended_at" bson:"ended_at,omitempty"`
+	Score       float32              `json:"score" bson:"score"`
+	MaxScore    float32              `json:"max_score" bson:"max_score"`
+}
+
+// ActiveSection returns the section the student is working on.
+func (a *TestAnswer) ActiveSection() (*SectionProgress, bool) {
+	if a.CurrentSection < 0 || a.CurrentSection >= len(a.Sections) {
+		return nil, false
+	}
+	progress := &a.Sections[a.CurrentSection]
+	if progress.Status != SectionActive {
+		return nil, false
+	}
+	return progress, true
+}
+
+// OpenSection starts the i-th section at the given time. Its deadline is the section's
+// own limit, clamped to the deadline of the attempt.
+func (a *TestAnswer) OpenSection(i int, at time.Time, section TestSection) {
+	progress := &a.Sections[i]
+	progress.Status = SectionActive
+	progress.StartedAt = at
+	progress.Deadline = a.Deadline
+	if section.DurationMinutes > 0 {
+		deadline := at.Add(time.Duration(section.DurationMinutes) * time.Minute)
+		if progress.Deadline.IsZero() || deadline.Before(progress.Deadline) {
+			progress.Deadline = deadline
+		}
+	}
+	a.CurrentSection = i
+}
+
+// closeActiveSection completes the section in progress when the attempt closes.
+func (a *TestAnswer) closeActiveSection(at time.Time) {
+	if progress, ok := a.ActiveSection(); ok {
+		progress.Status = SectionCompleted
+		progress.EndedAt = at
+	}
+}
+
+// LockRemainingSections locks the sections after the current one.
+func (a *TestAnswer) LockRemainingSections() {
+	for i := a.CurrentSection + 1; i < len(a.Sections); i++ {
+		a.Sections[i].Status = SectionLocked
+	}
+}
+
+// VisibleQuestionIDs returns the questions the student may see while the attempt is open:
+// the active section, or only its current question under linear navigation.
+func (a *TestAnswer) VisibleQuestionIDs() []primitive.ObjectID {
+	progress, ok := a.ActiveSection()
+	if !ok {
+		return nil
+	}
+	if progress.Navigation != NavigationLinear {
+		return progress.QuestionIDs
+	}
+	if progress.Position >= len(progress.QuestionIDs) {
+		return nil
+	}
+	return progress.QuestionIDs[progress.Position : progress.Position+1]
+}
+
+// AcceptAnswers replaces the answers to the questions the student may still change,
+// see VisibleQuestionIDs. The stored answers of every other question are kept.
+func (a *TestAnswer) AcceptAnswers(answers []QuestionAnswer) {
+	if len(a.Sections) == 0 {
+		a.ListQuestionAnswer = ResponsesOnly(answers)
+		return
+	}
+
+	editable := a.VisibleQuestionIDs()
+	accepted := make([]QuestionAnswer, 0, len(a.ListQuestionAnswer)+len(answers))
+	for _, stored := range a.ListQuestionAnswer {
+		if !slices.Contains(editable, stored.QuestionID) {
+			accepted = append(accepted, stored)
+		}
+	}
+	for _, answer := range answers {
+		if slices.Contains(editable, answer.QuestionID) {
+			accepted = append(accepted, answer.ResponseOnly())
+		}
+	}
+	a.ListQuestionAnswer = accepted
+}
+
+// recomputeSectionScores sums the per-question scores of every section.
+func (a *TestAnswer) recomputeSectionScores() {
+	for i := range a.Sections {
+		progress := &a.Sections[i]
+		progress.Score = 0
+		for _, qa := range a.ListQuestionAnswer {
+			if slices.Contains(progress.QuestionIDs, qa.QuestionID) {
+				progress.Score += qa.Score
+			}
+		}
+	}
+}
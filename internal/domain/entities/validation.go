@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is a rule broken by one field of a request body.
+// Field uses the JSON names, e.g. "options[1].text".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every FieldError of an entity so clients can show them all at once.
+type ValidationErrors []FieldError
+
+func (v *ValidationErrors) Add(field, format string, args ...any) {
+	*v = append(*v, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v ValidationErrors) Error() string {
+	parts := make([]string, 0, len(v))
+	for _, fe := range v {
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Err returns nil when nothing was collected, so callers can `return errs.Err()`.
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
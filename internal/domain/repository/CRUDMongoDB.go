@@ -10,6 +10,7 @@ import (
 
 type CRUDMongoDB interface {
 	Create(ctx context.Context, document any) (any, error)
+	CreateMany(ctx context.Context, documents []any) ([]any, error)
 	GetAll(ctx context.Context, filter any) ([]any, error)
 	GetAllWithOption(ctx context.Context, filter any, opts *options.FindOptions) ([]any, error)
 	GetFilter(ctx context.Context, filter any) (any, error)
@@ -18,4 +19,6 @@ type CRUDMongoDB interface {
 	Update(ctx context.Context, filter, update any) (*mongo.UpdateResult, error)
 	UpdateMany(ctx context.Context, filter, update any) (*mongo.UpdateResult, error)
 	Delete(ctx context.Context, filter any) (*mongo.DeleteResult, error)
+	Count(ctx context.Context, filter any) (int64, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel) error
 }
@@ -0,0 +1,9 @@
+package repository
+
+import "errors"
+
+// ErrDuplicateKey is returned, wrapped, when a write would break a unique index.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrFileNotFound is returned, wrapped, by FileStorage.GetFile for a missing file.
+var ErrFileNotFound = errors.New("file not found")
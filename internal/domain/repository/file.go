@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"io"
 	entity "quiz-app/internal/domain/entities"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,3 +19,11 @@ type FileRepository interface {
 	GetAllImageFile(ctx context.Context, email string) ([]any, error)
 	GetAllFile(ctx context.Context, email string) (any, error)
 }
+
+// FileStorage holds the content of the files, keyed by the uploader's email and the filename.
+// GetFile returns ErrFileNotFound when there is no such file.
+type FileStorage interface {
+	CreateFile(ctx context.Context, file *entity.File, body io.ReadSeeker) (primitive.ObjectID, error)
+	GetFile(ctx context.Context, email string, filename string) (io.ReadCloser, error)
+	DeleteFile(ctx context.Context, email string, filename string) error
+}
@@ -10,6 +10,12 @@ import (
 type AnswerRepository interface {
 	CreateAnswer(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error)
 	UpdateAnswer(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error)
+	// UpdateOpenAnswer stores the attempt only while the stored one is still in progress,
+	// and reports whether it did. Every write made during an attempt, or closing it, goes through it.
+	UpdateOpenAnswer(ctx context.Context, answer entity.TestAnswer) (bool, error)
+	// UpdateGradedAnswer stores a manually graded attempt only while the stored GradeVersion is
+	// still answer.GradeVersion, bumps it, and reports whether it did.
+	UpdateGradedAnswer(ctx context.Context, answer entity.TestAnswer) (bool, error)
 	GetAnswer(ctx context.Context, filter bson.M) (entity.TestAnswer, error)
 	GetAllAnswer(ctx context.Context, infoAnswer bson.M) ([]entity.TestAnswer, error)
 }
@@ -11,10 +11,20 @@ type ClassRepository interface {
 	CreateClass(ctx context.Context, class *entity.Class) (primitive.ObjectID, error)
 	GetClassByAuthorEmail(ctx context.Context, email string) ([]any, error)
 	UpdateClass(ctx context.Context, class *entity.Class) (any, error)
-	DeleteClass(ctx context.Context, emailID string, id primitive.ObjectID) error
+	DeleteClass(ctx context.Context, id primitive.ObjectID) error
 	GetAllClassByEmail(ctx context.Context, email string) ([]any, error)
-	JoinClass(ctx context.Context, classID primitive.ObjectID, email string) error
+
+	SaveMember(ctx context.Context, classID primitive.ObjectID, member entity.ClassMember) error
+	RemoveMember(ctx context.Context, classID primitive.ObjectID, email string) error
 
 	GetAllTestOfClass(ctx context.Context, email string, id primitive.ObjectID) ([]any, error)
 	GetQuestionOfTest(ctx context.Context, class, id primitive.ObjectID, email string) ([]primitive.ObjectID, primitive.M, error)
+	GetTestOfClass(ctx context.Context, classID, testID primitive.ObjectID) (*entity.Test, error)
+	GetClassByID(ctx context.Context, id primitive.ObjectID) (*entity.Class, error)
+	SetAnswersReleased(ctx context.Context, classID, testID primitive.ObjectID, released bool) error
+	SetBlindGrading(ctx context.Context, classID, testID primitive.ObjectID, blind bool) error
+	AddTestToClass(ctx context.Context, classID primitive.ObjectID, test entity.Test) error
+
+	SetAccommodation(ctx context.Context, classID primitive.ObjectID, acc entity.Accommodation) error
+	RemoveAccommodation(ctx context.Context, classID primitive.ObjectID, email string, testID primitive.ObjectID) error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GrantRepository interface {
+	// SaveGrant creates the grant of the email on the resource, or replaces its permissions.
+	SaveGrant(ctx context.Context, grant *entity.Grant) error
+	DeleteGrant(ctx context.Context, resourceType string, resourceID primitive.ObjectID, email string) error
+
+	// GetGrant returns nil when the email has no grant on the resource.
+	GetGrant(ctx context.Context, resourceType string, resourceID primitive.ObjectID, email string) (*entity.Grant, error)
+	GetGrantsByResource(ctx context.Context, resourceType string, resourceID primitive.ObjectID) ([]entity.Grant, error)
+	GetGrantsByEmail(ctx context.Context, email string) ([]entity.Grant, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InvitationRepository interface {
+	// SaveInvitation inserts an invitation without an ID, and otherwise replaces the stored one.
+	SaveInvitation(ctx context.Context, invitation *entity.Invitation) error
+
+	// GetInvitation returns nil when the invitation does not exist.
+	GetInvitation(ctx context.Context, id primitive.ObjectID) (*entity.Invitation, error)
+	// GetInvitationsByClass lists the invitations of the class, newest first.
+	GetInvitationsByClass(ctx context.Context, classID primitive.ObjectID) ([]entity.Invitation, error)
+}
+
+// Mailer sends the mails of the application, over SMTP or to a local stand-in.
+type Mailer interface {
+	Send(ctx context.Context, mail entity.Mail) error
+}
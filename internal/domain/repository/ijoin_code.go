@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	entity "quiz-app/internal/domain/entities"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JoinCodeRepository interface {
+	CreateJoinCode(ctx context.Context, code *entity.JoinCode) error
+
+	// GetJoinCode and GetJoinCodeByID return nil when the code does not exist.
+	GetJoinCode(ctx context.Context, code string) (*entity.JoinCode, error)
+	GetJoinCodeByID(ctx context.Context, id primitive.ObjectID) (*entity.JoinCode, error)
+	GetJoinCodesByClass(ctx context.Context, classID primitive.ObjectID) ([]entity.JoinCode, error)
+
+	// UseJoinCode counts one use of the code unless, at the given time, it is revoked, expired
+	// or used up. It reports whether the use was counted.
+	UseJoinCode(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error)
+	// ReleaseJoinCodeUse gives back a use counted by UseJoinCode.
+	ReleaseJoinCodeUse(ctx context.Context, id primitive.ObjectID) error
+	RevokeJoinCode(ctx context.Context, id primitive.ObjectID, by string, at time.Time) error
+
+	AddJoinCodeUse(ctx context.Context, use entity.JoinCodeUse) error
+	// GetJoinCodeUses lists the log of the class, newest first, for one code unless codeID is zero.
+	GetJoinCodeUses(ctx context.Context, classID, codeID primitive.ObjectID) ([]entity.JoinCodeUse, error)
+}
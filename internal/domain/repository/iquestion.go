@@ -11,6 +11,9 @@ import (
 type QuestionRepository interface {
 	CreateQuestion(ctx context.Context, question *entity.Question) (any, error)
 
+	// CreateQuestions inserts the questions, with their IDs already set, in one batch.
+	CreateQuestions(ctx context.Context, questions []entity.Question) error
+
 	GetAllQuestionsByUser(ctx context.Context, userID string, limit, page int) ([]any, error)
 
 	UpdateQuestion(ctx context.Context, question *entity.Question) (any, error)
@@ -18,4 +21,10 @@ type QuestionRepository interface {
 	DeleteQuestion(ctx context.Context, question *entity.Question) error
 
 	GetAllQuestions(ctx context.Context, question_ids []primitive.ObjectID) ([]bson.M, error)
+
+	GetQuestionsByIDs(ctx context.Context, question_ids []primitive.ObjectID) ([]entity.Question, error)
+
+	GetQuestionIDsByRule(ctx context.Context, author string, rule entity.DrawRule) ([]primitive.ObjectID, error)
+
+	SearchQuestions(ctx context.Context, query entity.QuestionQuery) (entity.QuestionPage, error)
 }
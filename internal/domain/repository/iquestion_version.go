@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type QuestionVersionRepository interface {
+	// CreateVersion returns ErrDuplicateKey when the version of the question already exists.
+	CreateVersion(ctx context.Context, version entity.QuestionVersion) error
+	DeleteVersion(ctx context.Context, questionID primitive.ObjectID, version int) error
+	CreateVersions(ctx context.Context, versions []entity.QuestionVersion) error
+
+	// GetVersion returns nil when the version does not exist.
+	GetVersion(ctx context.Context, questionID primitive.ObjectID, version int) (*entity.QuestionVersion, error)
+
+	// ListVersions returns the versions of a question, oldest first.
+	ListVersions(ctx context.Context, questionID primitive.ObjectID) ([]entity.QuestionVersion, error)
+
+	GetVersions(ctx context.Context, refs []entity.QuestionVersionRef) ([]entity.QuestionVersion, error)
+}
@@ -12,5 +12,9 @@ type TestRepository interface {
 	GetTestsByAuthorEmail(ctx context.Context, email string) ([]any, error)
 	UpdateTest(ctx context.Context, test *entity.Test) (any, error)
 	DeleteTest(ctx context.Context, id primitive.ObjectID, email string) error
+	GetTestByID(ctx context.Context, id primitive.ObjectID) (*entity.Test, error)
+
+	// InsertTest stores the whole test as it is, with its ID already set.
+	InsertTest(ctx context.Context, test *entity.Test) error
 	
 }
@@ -2,6 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
 	entity "quiz-app/internal/domain/entities"
 	"quiz-app/internal/domain/repository"
 
@@ -9,12 +15,42 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrAttemptNotFound = errors.New("attempt not found")
+	ErrAttemptClosed   = errors.New("attempt is already closed")
+	ErrAttemptExpired  = errors.New("attempt time is over")
+	ErrNoAttemptsLeft  = errors.New("no attempts left for this test")
+	ErrAttemptCooldown = errors.New("next attempt is not available yet")
+	ErrTestNotOpen     = errors.New("test is not open")
+)
+
+// AttemptHistory lists a student's attempts at a test and the score that counts.
+type AttemptHistory struct {
+	Attempts      []entity.TestAnswer `json:"attempts"`
+	ScoreMode     string              `json:"score_mode"`
+	FinalScore    float32             `json:"final_score"`
+	AttemptsLeft  int                 `json:"attempts_left"` // -1 when unlimited
+	NextAttemptAt time.Time           `json:"next_attempt_at"`
+}
+
 type AnswerUseCase struct {
-	repo repository.AnswerRepository
+	repo         repository.AnswerRepository
+	questionRepo repository.QuestionRepository
+	versionRepo  repository.QuestionVersionRepository
+	classRepo    repository.ClassRepository
+	authz        *AuthorizationService
+	grader       *GradingService
 }
 
-func NewAnswerUseCase(repo repository.AnswerRepository) *AnswerUseCase {
-	return &AnswerUseCase{repo: repo}
+func NewAnswerUseCase(repo repository.AnswerRepository, questionRepo repository.QuestionRepository, versionRepo repository.QuestionVersionRepository, classRepo repository.ClassRepository, authz *AuthorizationService) *AnswerUseCase {
+	return &AnswerUseCase{
+		repo:         repo,
+		questionRepo: questionRepo,
+		versionRepo:  versionRepo,
+		classRepo:    classRepo,
+		authz:        authz,
+		grader:       NewGradingService(),
+	}
 }
 
 func (au *AnswerUseCase) CreateNewAnswer(ctx context.Context, answer *entity.TestAnswer) error {
@@ -32,15 +68,537 @@ func (au *AnswerUseCase) UpdateAnswer(ctx context.Context, answer entity.TestAns
 	if err != nil {
 		return err
 	}
-	_, err = au.repo.UpdateAnswer(ctx, *newAnswer)
-	return err
+	return au.storeOpenAttempt(ctx, newAnswer)
+}
+
+// StartAttempt resumes the student's open attempt, or starts a new one when the
+// test's retake policy allows it. An attempt whose time ran out is closed first.
+// When no new attempt is allowed the latest closed attempt is returned with the error.
+// The attempt returned is the student's view, see StudentAttempt.
+func (au *AnswerUseCase) StartAttempt(ctx context.Context, classID, testID primitive.ObjectID, emailID, email string) (*entity.TestAnswer, error) {
+	test, err := au.studentTest(ctx, classID, testID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := au.listAttempts(ctx, testID, emailID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attempts) > 0 {
+		latest := &attempts[len(attempts)-1]
+		if _, err := au.closeIfExpired(ctx, latest, test); err != nil {
+			return nil, err
+		}
+		if latest.IsOpen() {
+			return StudentAttempt(latest, *test, time.Now()), nil
+		}
+
+		policy := test.EffectiveRetakePolicy()
+		if policy.MaxAttempts > 0 && len(attempts) >= policy.MaxAttempts {
+			return StudentAttempt(latest, *test, time.Now()), ErrNoAttemptsLeft
+		}
+		if nextAt := nextAttemptAt(policy, *latest); time.Now().Before(nextAt) {
+			return StudentAttempt(latest, *test, time.Now()), ErrAttemptCooldown
+		}
+	}
+
+	// New attempts only start inside the test window, moved by the student's accommodation
+	if !test.IsOpenAt(time.Now()) {
+		return nil, ErrTestNotOpen
+	}
+
+	attempt, err := entity.CreateNewAnswer(testID, emailID, email)
+	if err != nil {
+		return nil, err
+	}
+	attempt.ClassID = classID
+	attempt.AttemptNumber = len(attempts) + 1
+	attempt.Deadline = attempt.ComputeDeadline(*test)
+	if len(test.Sections) > 0 {
+		if err := au.planSections(ctx, attempt, *test); err != nil {
+			return nil, err
+		}
+	} else if len(test.DrawRules) > 0 {
+		attempt.DrawnQuestions, err = au.drawQuestions(ctx, attempt.ID, *test)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Pin the versions shown so later edits don't change how the attempt is graded and reviewed
+	if questionIDs := attempt.QuestionIDs(test.QuestionIDs); len(questionIDs) > 0 {
+		questions, err := au.questionRepo.GetQuestionsByIDs(ctx, questionIDs)
+		if err != nil {
+			return nil, fmt.Errorf("load questions: %w", err)
+		}
+		attempt.QuestionVersions = questionVersionRefs(questions)
+		if test.IsTest {
+			attempt.Shuffle = NewAttemptShuffle(attempt.ID, sortQuestionsByIDs(questions, questionIDs))
+			groupShuffleBySection(attempt.Shuffle, attempt.Sections)
+		}
+	}
+
+	if _, err := au.repo.CreateAnswer(ctx, *attempt); errors.Is(err, repository.ErrDuplicateKey) {
+		// Another start created this attempt first: resume it
+		return au.concurrentAttempt(ctx, test, emailID)
+	} else if err != nil {
+		return nil, fmt.Errorf("create attempt: %w", err)
+	}
+	return StudentAttempt(attempt, *test, time.Now()), nil
+}
+
+// concurrentAttempt returns the attempt created by a concurrent StartAttempt, or
+// ErrNoAttemptsLeft when it is already closed.
+func (au *AnswerUseCase) concurrentAttempt(ctx context.Context, test *entity.Test, emailID string) (*entity.TestAnswer, error) {
+	attempts, err := au.listAttempts(ctx, test.ID, emailID)
+	if err != nil {
+		return nil, err
+	}
+	if len(attempts) == 0 {
+		return nil, ErrNoAttemptsLeft
+	}
+	latest := StudentAttempt(&attempts[len(attempts)-1], *test, time.Now())
+	if !latest.IsOpen() {
+		return latest, ErrNoAttemptsLeft
+	}
+	return latest, nil
+}
+
+// AttemptQuestions returns the student view of the attempt's questions, in the order they
+// were shown. Answer keys are only included when the attempt is closed and the review policy allows it.
+// While a test with sections is in progress only the current section, or question, is returned.
+func (au *AnswerUseCase) AttemptQuestions(ctx context.Context, attempt *entity.TestAnswer, questionIDs []primitive.ObjectID) ([]StudentQuestion, error) {
+	questions, err := au.attemptQuestions(ctx, attempt, questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	questions = visibleQuestions(questions, attempt)
+
+	var test entity.Test
+	if !attempt.IsOpen() {
+		loaded, err := au.studentTest(ctx, attempt.ClassID, attempt.TestId, attempt.Email)
+		if err != nil {
+			return nil, err
+		}
+		test = *loaded
+	}
+	return StudentQuestions(questions, *attempt, test, time.Now()), nil
+}
+
+// attemptQuestions returns the full questions of the attempt in the order and the version
+// they were shown, so a reload shows the same thing. Attempts without a shuffle use the test order.
+// Drawn questions replace the test's questionIDs.
+func (au *AnswerUseCase) attemptQuestions(ctx context.Context, attempt *entity.TestAnswer, questionIDs []primitive.ObjectID) ([]entity.Question, error) {
+	questionIDs = attempt.QuestionIDs(questionIDs)
+	questions, err := au.questionRepo.GetQuestionsByIDs(ctx, questionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("load questions: %w", err)
+	}
+	questions, err = au.pinQuestions(ctx, *attempt, questionIDs, questions)
+	if err != nil {
+		return nil, err
+	}
+	applyDrawnScores(questions, attempt.DrawnQuestions)
+	return ApplyShuffle(sortQuestionsByIDs(questions, questionIDs), attempt.Shuffle), nil
+}
+
+// SaveProgress autosaves the answers of an open attempt without grading them.
+// The attempt returned is the student's view, see StudentAttempt.
+func (au *AnswerUseCase) SaveProgress(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error) {
+	attempt, test, err := au.loadAttempt(ctx, answer)
+	if err != nil {
+		return nil, err
+	}
+	if !attempt.IsOpen() {
+		return StudentAttempt(attempt, *test, time.Now()), ErrAttemptClosed
+	}
+	if _, err := au.closeIfExpired(ctx, attempt, test); err != nil {
+		return nil, err
+	}
+	if !attempt.IsOpen() {
+		return StudentAttempt(attempt, *test, time.Now()), ErrAttemptExpired
+	}
+
+	if err := attempt.SaveProgress(answer.ListQuestionAnswer); err != nil {
+		return nil, err
+	}
+	if err := au.storeOpenAttempt(ctx, attempt); err != nil {
+		return nil, fmt.Errorf("save attempt: %w", err)
+	}
+	return StudentAttempt(attempt, *test, time.Now()), nil
+}
+
+// Submit grades the attempt on the server and closes it.
+// Submissions after the deadline are flagged as late when the test allows it;
+// otherwise the attempt is closed with the autosaved answers instead, and
+// ErrAttemptExpired is returned along with the graded attempt.
+// The attempt and result only carry the totals until the answers are released, see StudentAttempt.
+func (au *AnswerUseCase) Submit(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, GradeResult, error) {
+	attempt, test, err := au.loadAttempt(ctx, answer)
+	if err != nil {
+		return nil, GradeResult{}, err
+	}
+	if !attempt.IsOpen() {
+		return StudentAttempt(attempt, *test, time.Now()), GradeResult{}, ErrAttemptClosed
+	}
+	result, err := au.closeIfExpired(ctx, attempt, test)
+	if err != nil {
+		return nil, GradeResult{}, err
+	}
+	if !attempt.IsOpen() {
+		now := time.Now()
+		return StudentAttempt(attempt, *test, now), StudentResult(result, *test, now), ErrAttemptExpired
+	}
+
+	attempt.AcceptAnswers(answer.ListQuestionAnswer)
+	submitted, err := entity.SubmitAnswer(*attempt)
+	if err != nil {
+		return nil, GradeResult{}, err
+	}
+	submitted.Late = submitted.IsLate(*test, submitted.EndTime)
+
+	result, err = au.GradeAnswer(ctx, submitted, test.QuestionIDs)
+	if err != nil {
+		return nil, GradeResult{}, err
+	}
+
+	if err := au.storeOpenAttempt(ctx, submitted); err != nil {
+		return nil, GradeResult{}, fmt.Errorf("store answer: %w", err)
+	}
+	now := time.Now()
+	return StudentAttempt(submitted, *test, now), StudentResult(result, *test, now), nil
+}
+
+// CloseExpiredAttempts auto-submits every open attempt whose time ran out,
+// grading whatever was autosaved. It returns the number of attempts closed.
+func (au *AnswerUseCase) CloseExpiredAttempts(ctx context.Context) (int, error) {
+	now := time.Now()
+	attempts, err := au.repo.GetAllAnswer(ctx, bson.M{
+		"status":   entity.AttemptInProgress,
+		"deadline": bson.M{"$lt": now},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("load open attempts: %w", err)
+	}
+
+	classes := make(map[primitive.ObjectID]*entity.Class)
+	closed := 0
+	for i := range attempts {
+		attempt := &attempts[i]
+		class, ok := classes[attempt.ClassID]
+		if !ok {
+			class, err = au.classRepo.GetClassByID(ctx, attempt.ClassID)
+			if err != nil {
+				log.Printf("expiry sweeper: load class %s: %v", attempt.ClassID.Hex(), err)
+				continue
+			}
+			classes[attempt.ClassID] = class
+		}
+		// Attempts of students removed since they started are closed too
+		test, err := accommodatedTest(class, attempt.TestId, attempt.Email)
+		if err != nil {
+			log.Printf("expiry sweeper: load test %s: %v", attempt.TestId.Hex(), err)
+			continue
+		}
+
+		if !attempt.HasExpired(*test, now) {
+			continue
+		}
+		if _, err := au.closeIfExpired(ctx, attempt, test); err != nil {
+			// ErrAttemptClosed: submitted in the meantime
+			if !errors.Is(err, ErrAttemptClosed) {
+				log.Printf("expiry sweeper: close attempt %s: %v", attempt.ID.Hex(), err)
+			}
+			continue
+		}
+		closed++
+	}
+	return closed, nil
+}
+
+// RunExpirySweeper calls CloseExpiredAttempts every interval until ctx is done.
+func (au *AnswerUseCase) RunExpirySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			closed, err := au.CloseExpiredAttempts(ctx)
+			if err != nil {
+				log.Printf("expiry sweeper: %v", err)
+			} else if closed > 0 {
+				log.Printf("expiry sweeper: closed %d attempts", closed)
+			}
+		}
+	}
+}
+
+// GetAttemptHistory returns every attempt of the student at the test and the score that counts.
+func (au *AnswerUseCase) GetAttemptHistory(ctx context.Context, classID, testID primitive.ObjectID, emailID string) (*AttemptHistory, error) {
+	attempts, err := au.listAttempts(ctx, testID, emailID)
+	if err != nil {
+		return nil, err
+	}
+
+	var email string
+	if len(attempts) > 0 {
+		email = attempts[len(attempts)-1].Email
+	}
+	test, err := au.studentTest(ctx, classID, testID, email)
+	if err != nil {
+		return nil, err
+	}
+	if len(attempts) > 0 {
+		if _, err := au.closeIfExpired(ctx, &attempts[len(attempts)-1], test); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := test.EffectiveRetakePolicy()
+	now := time.Now()
+	views := make([]entity.TestAnswer, len(attempts))
+	for i := range attempts {
+		views[i] = *StudentAttempt(&attempts[i], *test, now)
+	}
+	history := &AttemptHistory{
+		Attempts:     views,
+		ScoreMode:    policy.ScoreMode,
+		FinalScore:   policy.FinalScore(attempts),
+		AttemptsLeft: -1,
+	}
+	if policy.MaxAttempts > 0 {
+		history.AttemptsLeft = max(policy.MaxAttempts-len(attempts), 0)
+	}
+	if len(attempts) > 0 {
+		history.NextAttemptAt = nextAttemptAt(policy, attempts[len(attempts)-1])
+	}
+	return history, nil
+}
+
+// GetResult returns the latest closed attempt of the student for the test, see StudentAttempt.
+func (au *AnswerUseCase) GetResult(ctx context.Context, classID, testID primitive.ObjectID, emailID string) (*entity.TestAnswer, error) {
+	attempt, test, err := au.loadAttempt(ctx, entity.TestAnswer{ClassID: classID, TestId: testID, EmailID: emailID})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := au.closeIfExpired(ctx, attempt, test); err != nil {
+		return nil, err
+	}
+	if !attempt.IsOpen() {
+		return StudentAttempt(attempt, *test, time.Now()), nil
+	}
+
+	// A retake is in progress: show the previous closed attempt
+	attempts, err := au.listAttempts(ctx, testID, emailID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if !attempts[i].IsOpen() {
+			return StudentAttempt(&attempts[i], *test, time.Now()), nil
+		}
+	}
+	return nil, errors.New("attempt is still in progress")
+}
+
+// GradeAnswer scores the answer against the stored questions of the test and fills in TotalScore.
+// Attempts with drawn questions are graded against the drawn questions instead, and
+// every question is graded in the version pinned when the attempt started.
+func (au *AnswerUseCase) GradeAnswer(ctx context.Context, answer *entity.TestAnswer, questionIDs []primitive.ObjectID) (GradeResult, error) {
+	questionIDs = answer.QuestionIDs(questionIDs)
+	questions, err := au.questionRepo.GetQuestionsByIDs(ctx, questionIDs)
+	if err != nil {
+		return GradeResult{}, fmt.Errorf("load questions: %w", err)
+	}
+	questions, err = au.pinQuestions(ctx, *answer, questionIDs, questions)
+	if err != nil {
+		return GradeResult{}, err
+	}
+	applyDrawnScores(questions, answer.DrawnQuestions)
+	result := au.grader.Grade(answer, questions)
+	scoreSections(answer, result)
+	return result, nil
+}
+
+// closeIfExpired expires and grades an open attempt whose time ran out.
+// Sections whose time ran out are closed too, expiring the attempt after the last one.
+func (au *AnswerUseCase) closeIfExpired(ctx context.Context, attempt *entity.TestAnswer, test *entity.Test) (GradeResult, error) {
+	if !attempt.IsOpen() {
+		return GradeResult{}, nil
+	}
+	if now := time.Now(); !attempt.HasExpired(*test, now) {
+		return au.closeExpiredSections(ctx, attempt, test, now)
+	}
+
+	attempt.Expire(attempt.ComputeDeadline(*test))
+	result, err := au.GradeAnswer(ctx, attempt, test.QuestionIDs)
+	if err != nil {
+		return GradeResult{}, err
+	}
+	if err := au.storeOpenAttempt(ctx, attempt); err != nil {
+		return GradeResult{}, fmt.Errorf("close attempt: %w", err)
+	}
+	return result, nil
+}
+
+// storeOpenAttempt writes the attempt unless it was closed since it was loaded, see
+// AnswerRepository.UpdateOpenAnswer. It returns ErrAttemptClosed when it was.
+func (au *AnswerUseCase) storeOpenAttempt(ctx context.Context, attempt *entity.TestAnswer) error {
+	stored, err := au.repo.UpdateOpenAnswer(ctx, *attempt)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return ErrAttemptClosed
+	}
+	return nil
+}
+
+// loadAttempt fetches the stored attempt and its test for the answer's student, who must still be
+// an active student of the class. The attempt is looked up by answer.ID when given, otherwise the latest attempt is used.
+func (au *AnswerUseCase) loadAttempt(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, *entity.Test, error) {
+	attempt, err := au.findAttempt(ctx, answer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The class the attempt was started in decides the window and accommodation.
+	// Only attempts stored before they recorded it fall back to the request.
+	classID := attempt.ClassID
+	if classID.IsZero() {
+		classID = answer.ClassID
+	}
+	test, err := au.studentTest(ctx, classID, attempt.TestId, attempt.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The end of the window is the attempt's deadline, see closeIfExpired
+	if startAt, ok := test.StartAt(); ok && time.Now().Before(startAt) {
+		return nil, nil, ErrTestNotOpen
+	}
+	return attempt, test, nil
+}
+
+// studentTest loads the test of the class with the student's accommodation applied,
+// so deadlines and windows are computed for that student.
+func (au *AnswerUseCase) studentTest(ctx context.Context, classID, testID primitive.ObjectID, email string) (*entity.Test, error) {
+	class, err := au.classRepo.GetClassByID(ctx, classID)
+	if err != nil {
+		return nil, fmt.Errorf("load test: %w", err)
+	}
+	return testForStudent(class, testID, email)
+}
+
+// testForStudent returns the test of the class for one of its active students, see accommodatedTest.
+func testForStudent(class *entity.Class, testID primitive.ObjectID, email string) (*entity.Test, error) {
+	if err := requireStudent(class, email); err != nil {
+		return nil, err
+	}
+	return accommodatedTest(class, testID, email)
+}
+
+// accommodatedTest returns the test of the class with the accommodation of the email applied.
+func accommodatedTest(class *entity.Class, testID primitive.ObjectID, email string) (*entity.Test, error) {
+	for _, test := range class.Test {
+		if test.ID == testID {
+			adjusted := test.WithAccommodation(class.AccommodationFor(email, testID))
+			// Answers wait for the students with extra time, not only for this one
+			adjusted.ReleaseAt, _ = class.AnswersReleaseAt(test)
+			return &adjusted, nil
+		}
+	}
+	return nil, errors.New("load test: no matching test found")
+}
+
+func (au *AnswerUseCase) findAttempt(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error) {
+	if !answer.ID.IsZero() {
+		attempt, err := au.repo.GetAnswer(ctx, bson.M{"_id": answer.ID, "email_id": answer.EmailID})
+		if err != nil {
+			return nil, ErrAttemptNotFound
+		}
+		return &attempt, nil
+	}
+
+	attempts, err := au.listAttempts(ctx, answer.TestId, answer.EmailID)
+	if err != nil {
+		return nil, err
+	}
+	if len(attempts) == 0 {
+		return nil, ErrAttemptNotFound
+	}
+	return &attempts[len(attempts)-1], nil
 }
 
-func (au *AnswerUseCase) GetAnswer(ctx context.Context, filter primitive.M) (entity.TestAnswer, error) {
-	return au.repo.GetAnswer(ctx, filter)
+// listAttempts returns the student's attempts at the test ordered by attempt number.
+func (au *AnswerUseCase) listAttempts(ctx context.Context, testID primitive.ObjectID, emailID string) ([]entity.TestAnswer, error) {
+	attempts, err := au.repo.GetAllAnswer(ctx, bson.M{"test_id": testID, "email_id": emailID})
+	if err != nil {
+		return nil, fmt.Errorf("load attempts: %w", err)
+	}
+	sort.SliceStable(attempts, func(i, j int) bool {
+		return attemptNumber(attempts[i]) < attemptNumber(attempts[j])
+	})
+	return attempts, nil
 }
 
+// attemptNumber treats answers stored before attempts were numbered as the first attempt.
+func attemptNumber(attempt entity.TestAnswer) int {
+	if attempt.AttemptNumber == 0 {
+		return 1
+	}
+	return attempt.AttemptNumber
+}
+
+// nextAttemptAt returns when the cooldown after the given closed attempt ends.
+func nextAttemptAt(policy entity.RetakePolicy, last entity.TestAnswer) time.Time {
+	if policy.CooldownMinutes <= 0 || last.IsOpen() {
+		return time.Time{}
+	}
+	return last.EndTime.Add(time.Duration(policy.CooldownMinutes) * time.Minute)
+}
+
+// GetAnswer returns an attempt of the student at the test, see StudentAttempt.
+func (au *AnswerUseCase) GetAnswer(ctx context.Context, testID primitive.ObjectID, emailID string) (*entity.TestAnswer, error) {
+	answer, err := au.repo.GetAnswer(ctx, bson.M{"test_id": testID, "email_id": emailID})
+	if err != nil {
+		return nil, err
+	}
+	return &au.studentAttempts(ctx, []entity.TestAnswer{answer})[0], nil
+}
+
+// GetAllAnswerByEmail returns every attempt of the student, see StudentAttempt.
 func (au *AnswerUseCase) GetAllAnswerByEmail(ctx context.Context, email string) ([]entity.TestAnswer, error) {
-	filter := bson.M{"email": email}
-	return au.repo.GetAllAnswer(ctx, filter)
+	answers, err := au.repo.GetAllAnswer(ctx, bson.M{"email": email})
+	if err != nil {
+		return nil, err
+	}
+	return au.studentAttempts(ctx, answers), nil
+}
+
+// studentAttempts applies StudentAttempt to attempts of any tests. Attempts whose test cannot
+// be loaded, e.g. stored before attempts recorded their class, only keep the totals.
+func (au *AnswerUseCase) studentAttempts(ctx context.Context, attempts []entity.TestAnswer) []entity.TestAnswer {
+	classes := make(map[primitive.ObjectID]*entity.Class)
+	now := time.Now()
+	views := make([]entity.TestAnswer, len(attempts))
+	for i := range attempts {
+		attempt := &attempts[i]
+		class, ok := classes[attempt.ClassID]
+		if !ok && !attempt.ClassID.IsZero() {
+			class, _ = au.classRepo.GetClassByID(ctx, attempt.ClassID)
+			classes[attempt.ClassID] = class
+		}
+		test := entity.Test{ReviewPolicy: entity.ReviewNever}
+		if class != nil {
+			if loaded, err := accommodatedTest(class, attempt.TestId, attempt.Email); err == nil {
+				test = *loaded
+			}
+		}
+		views[i] = *StudentAttempt(attempt, test, now)
+	}
+	return views
 }
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MatchBlankAnswer reports whether the given answer is accepted for the blank.
+func MatchBlankAnswer(blank entity.FillInTheBlank, given string) bool {
+	var matching entity.AnswerMatching
+	if blank.Matching != nil {
+		matching = *blank.Matching
+	}
+
+	for _, accepted := range blank.AcceptedValues() {
+		var ok bool
+		switch matching.Mode {
+		case entity.MatchModeRegex:
+			ok = matchRegex(matching, accepted, given)
+		case entity.MatchModeNumeric:
+			ok = matchNumeric(matching, accepted, given)
+		default:
+			ok = matchText(matching, accepted, given)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchText(matching entity.AnswerMatching, accepted, given string) bool {
+	accepted = normalizeAnswer(matching, accepted)
+	given = normalizeAnswer(matching, given)
+	if accepted == given {
+		return true
+	}
+	return matching.MaxEditDistance > 0 && editDistance(accepted, given) <= matching.MaxEditDistance
+}
+
+// matchRegex matches the whole (normalized) answer against the pattern.
+// Invalid patterns never match.
+func matchRegex(matching entity.AnswerMatching, pattern, given string) bool {
+	prefix := ""
+	if matching.CaseInsensitive {
+		prefix = "(?i)"
+	}
+	re, err := regexp.Compile(prefix + "^(?:" + pattern + ")$")
+	if err != nil {
+		return false
+	}
+	// Case is handled by the (?i) flag, only whitespace and diacritics are normalized here
+	matching.CaseInsensitive = false
+	return re.MatchString(normalizeAnswer(matching, given))
+}
+
+func matchNumeric(matching entity.AnswerMatching, accepted, given string) bool {
+	want, err := parseNumber(accepted)
+	if err != nil {
+		return false
+	}
+	got, err := parseNumber(given)
+	if err != nil {
+		return false
+	}
+	return math.Abs(want-got) <= math.Abs(matching.Tolerance)
+}
+
+// numericAnswerPattern splits "12,5 cm" into the number and the unit.
+var numericAnswerPattern = regexp.MustCompile(`^\s*([-+]?(?:\d+(?:[.,]\d*)?|[.,]\d+)(?:[eE][-+]?\d+)?)\s*(.*?)\s*$`)
+
+// MatchNumericAnswer reports whether the given "value unit" answer is within the tolerance
+// of the expected value, after converting the unit to the base unit.
+func MatchNumericAnswer(expected entity.NumericAnswer, given string) bool {
+	parts := numericAnswerPattern.FindStringSubmatch(given)
+	if parts == nil {
+		return false
+	}
+	value, err := parseNumber(parts[1])
+	if err != nil {
+		return false
+	}
+
+	switch unit := parts[2]; {
+	case unit == "":
+		if expected.RequireUnit {
+			return false
+		}
+	case unit == expected.Unit:
+	default:
+		factor := 0.0
+		for _, accepted := range expected.Units {
+			if accepted.Name == unit {
+				factor = accepted.Factor
+				break
+			}
+		}
+		if factor == 0 {
+			return false
+		}
+		value *= factor
+	}
+	// The epsilon absorbs rounding from the unit conversion
+	return math.Abs(expected.Value-value) <= math.Abs(expected.Tolerance)+1e-9
+}
+
+// parseNumber accepts both "3.5" and the Vietnamese "3,5".
+func parseNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, ",") && !strings.Contains(value, ".") {
+		value = strings.ReplaceAll(value, ",", ".")
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// normalizeAnswer applies the normalization steps enabled in matching.
+func normalizeAnswer(matching entity.AnswerMatching, value string) string {
+	if matching.NormalizeSpace {
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	if matching.FoldDiacritics {
+		value = foldDiacritics(value)
+	}
+	if matching.CaseInsensitive {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+// foldDiacritics removes tone marks and accents, e.g. "Tiếng Việt" -> "Tieng Viet".
+func foldDiacritics(value string) string {
+	// đ/Đ are separate letters, not a base letter with a combining mark
+	value = strings.NewReplacer("đ", "d", "Đ", "D").Replace(value)
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, value)
+	if err != nil {
+		return value
+	}
+	return folded
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+func TestMatchBlankAnswer(t *testing.T) {
+	lenient := &entity.AnswerMatching{CaseInsensitive: true, NormalizeSpace: true, FoldDiacritics: true}
+
+	tests := []struct {
+		name  string
+		blank entity.FillInTheBlank
+		given string
+		want  bool
+	}{
+		{"strict exact", entity.FillInTheBlank{CorrectAnswer: "Hà Nội"}, "Hà Nội", true},
+		{"strict case", entity.FillInTheBlank{CorrectAnswer: "Hà Nội"}, "hà nội", false},
+		{"strict space", entity.FillInTheBlank{CorrectAnswer: "Hà Nội"}, " Hà Nội", false},
+		{"no accepted value", entity.FillInTheBlank{}, "", false},
+		{"alternative answer", entity.FillInTheBlank{CorrectAnswer: "HCMC", AcceptedAnswers: []string{"", "Sài Gòn"}}, "Sài Gòn", true},
+		{"empty alternative is skipped", entity.FillInTheBlank{CorrectAnswer: "HCMC", AcceptedAnswers: []string{""}}, "", false},
+
+		{"lenient case and space", entity.FillInTheBlank{CorrectAnswer: "Hà Nội", Matching: lenient}, "  hà   NỘI ", true},
+		{"lenient diacritics", entity.FillInTheBlank{CorrectAnswer: "Hà Nội", Matching: lenient}, "ha noi", true},
+		{"lenient đ", entity.FillInTheBlank{CorrectAnswer: "Đà Nẵng", Matching: lenient}, "da nang", true},
+		{"lenient wrong word", entity.FillInTheBlank{CorrectAnswer: "Hà Nội", Matching: lenient}, "Huế", false},
+
+		{"one typo allowed", entity.FillInTheBlank{CorrectAnswer: "photosynthesis", Matching: &entity.AnswerMatching{MaxEditDistance: 1}}, "photosynthesys", true},
+		{"two typos, one allowed", entity.FillInTheBlank{CorrectAnswer: "photosynthesis", Matching: &entity.AnswerMatching{MaxEditDistance: 1}}, "fotosynthesys", false},
+		{"typos counted in runes", entity.FillInTheBlank{CorrectAnswer: "Nội", Matching: &entity.AnswerMatching{MaxEditDistance: 1}}, "Noi", true},
+
+		{"regex", entity.FillInTheBlank{CorrectAnswer: `colou?r`, Matching: &entity.AnswerMatching{Mode: entity.MatchModeRegex}}, "color", true},
+		{"regex matches the whole answer", entity.FillInTheBlank{CorrectAnswer: `colou?r`, Matching: &entity.AnswerMatching{Mode: entity.MatchModeRegex}}, "colors", false},
+		{"regex alternation is anchored", entity.FillInTheBlank{CorrectAnswer: `a|b`, Matching: &entity.AnswerMatching{Mode: entity.MatchModeRegex}}, "ab", false},
+		{"regex case insensitive", entity.FillInTheBlank{CorrectAnswer: `colou?r`, Matching: &entity.AnswerMatching{Mode: entity.MatchModeRegex, CaseInsensitive: true}}, "COLOUR", true},
+		{"invalid regex", entity.FillInTheBlank{CorrectAnswer: `(`, Matching: &entity.AnswerMatching{Mode: entity.MatchModeRegex}}, "(", false},
+
+		{"numeric exact", entity.FillInTheBlank{CorrectAnswer: "3.5", Matching: &entity.AnswerMatching{Mode: entity.MatchModeNumeric}}, "3.50", true},
+		{"numeric decimal comma", entity.FillInTheBlank{CorrectAnswer: "3.5", Matching: &entity.AnswerMatching{Mode: entity.MatchModeNumeric}}, "3,5", true},
+		{"numeric within tolerance", entity.FillInTheBlank{CorrectAnswer: "3.14", Matching: &entity.AnswerMatching{Mode: entity.MatchModeNumeric, Tolerance: 0.01}}, "3.1416", true},
+		{"numeric outside tolerance", entity.FillInTheBlank{CorrectAnswer: "3.14", Matching: &entity.AnswerMatching{Mode: entity.MatchModeNumeric, Tolerance: 0.01}}, "3.2", false},
+		{"numeric not a number", entity.FillInTheBlank{CorrectAnswer: "3", Matching: &entity.AnswerMatching{Mode: entity.MatchModeNumeric}}, "three", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchBlankAnswer(tt.blank, tt.given); got != tt.want {
+				t.Errorf("MatchBlankAnswer(%q) = %v, want %v", tt.given, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"Việt", "Viet", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// requireHiddenGrades fails unless the attempt only carries the responses and the totals.
+func requireHiddenGrades(t *testing.T, attempt *entity.TestAnswer) {
+	t.Helper()
+	if attempt == nil {
+		t.Fatal("no attempt returned")
+	}
+	for _, qa := range attempt.ListQuestionAnswer {
+		if qa.Score != 0 || qa.Comment != "" || qa.GradedBy != "" || qa.GradeStatus != "" || !qa.GradedAt.IsZero() {
+			t.Errorf("question %s leaks its grade: %+v", qa.QuestionID.Hex(), qa)
+		}
+		if len(qa.Options) == 0 {
+			t.Errorf("question %s lost the student's response", qa.QuestionID.Hex())
+		}
+	}
+	if len(attempt.GradeLog) > 0 {
+		t.Errorf("grade log leaked: %+v", attempt.GradeLog)
+	}
+}
+
+func TestStudentAttemptsHideGrades(t *testing.T) {
+	ctx := context.Background()
+	sectioned := func(env *answerTestEnv) {
+		env.test.Sections = []entity.TestSection{{ID: env.test.ID, QuestionIDs: env.test.QuestionIDs}}
+	}
+	request := func(env *answerTestEnv) entity.TestAnswer {
+		return entity.TestAnswer{ClassID: env.class.ID, TestId: env.test.ID, EmailID: testStudentID, Email: testStudentEmail}
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(env *answerTestEnv)
+		call    func(env *answerTestEnv) (*entity.TestAnswer, error)
+		wantErr error
+	}{
+		{
+			name: "start resumes the open attempt",
+			setup: func(env *answerTestEnv) {
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptInProgress, time.Now()))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.StartAttempt(ctx, env.class.ID, env.test.ID, testStudentID, testStudentEmail)
+			},
+		},
+		{
+			name: "start with no attempts left",
+			setup: func(env *answerTestEnv) {
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.StartAttempt(ctx, env.class.ID, env.test.ID, testStudentID, testStudentEmail)
+			},
+			wantErr: ErrNoAttemptsLeft,
+		},
+		{
+			name: "start during the cooldown",
+			setup: func(env *answerTestEnv) {
+				env.test.RetakePolicy = &entity.RetakePolicy{CooldownMinutes: 60}
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-15*time.Minute)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.StartAttempt(ctx, env.class.ID, env.test.ID, testStudentID, testStudentEmail)
+			},
+			wantErr: ErrAttemptCooldown,
+		},
+		{
+			name: "start loses the race to a submitted attempt",
+			setup: func(env *answerTestEnv) {
+				env.answers.beforeCreate = func(entity.TestAnswer) error {
+					env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour)))
+					return repository.ErrDuplicateKey
+				}
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.StartAttempt(ctx, env.class.ID, env.test.ID, testStudentID, testStudentEmail)
+			},
+			wantErr: ErrNoAttemptsLeft,
+		},
+		{
+			name: "save a closed attempt",
+			setup: func(env *answerTestEnv) {
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.SaveProgress(ctx, request(env))
+			},
+			wantErr: ErrAttemptClosed,
+		},
+		{
+			name: "save an expired attempt",
+			setup: func(env *answerTestEnv) {
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptInProgress, time.Now().Add(-time.Hour)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.SaveProgress(ctx, request(env))
+			},
+			wantErr: ErrAttemptExpired,
+		},
+		{
+			name: "submit a closed attempt",
+			setup: func(env *answerTestEnv) {
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				attempt, _, err := env.uc.Submit(ctx, request(env))
+				return attempt, err
+			},
+			wantErr: ErrAttemptClosed,
+		},
+		{
+			name: "submit a section of a closed attempt",
+			setup: func(env *answerTestEnv) {
+				sectioned(env)
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.SubmitSection(ctx, request(env), env.test.ID)
+			},
+			wantErr: ErrAttemptClosed,
+		},
+		{
+			name: "submit a section of an expired attempt",
+			setup: func(env *answerTestEnv) {
+				sectioned(env)
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptInProgress, time.Now().Add(-time.Hour)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.NextQuestion(ctx, request(env), env.test.ID)
+			},
+			wantErr: ErrAttemptExpired,
+		},
+		{
+			name: "get an answer",
+			setup: func(env *answerTestEnv) {
+				env.answers.answers = append(env.answers.answers, env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour)))
+			},
+			call: func(env *answerTestEnv) (*entity.TestAnswer, error) {
+				return env.uc.GetAnswer(ctx, env.test.ID, testStudentID)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newAnswerTestEnv()
+			tt.setup(env)
+			attempt, err := tt.call(env)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			requireHiddenGrades(t, attempt)
+		})
+	}
+}
+
+func TestGetAllAnswerByEmailHidesUnreleasedGrades(t *testing.T) {
+	ctx := context.Background()
+	env := newAnswerTestEnv()
+	practice := *env.test
+	practice.ID = primitive.NewObjectID()
+	practice.ReviewPolicy = entity.ReviewImmediately
+	env.class.Test = append(env.class.Test, practice)
+
+	unreleased := env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour))
+	legacy := env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour))
+	legacy.ClassID = primitive.NilObjectID // Stored before attempts recorded their class
+	released := env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour))
+	released.TestId = practice.ID
+	env.answers.answers = append(env.answers.answers, unreleased, legacy, released)
+
+	attempts, err := env.uc.GetAllAnswerByEmail(ctx, testStudentEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(attempts))
+	}
+	requireHiddenGrades(t, &attempts[0])
+	requireHiddenGrades(t, &attempts[1])
+	if got := attempts[2].ListQuestionAnswer[0]; got.Score != 1 || got.Comment == "" {
+		t.Errorf("released attempt lost its grade: %+v", got)
+	}
+	for _, attempt := range attempts {
+		if attempt.TotalScore != 1 {
+			t.Errorf("attempt %s: total = %v, want 1", attempt.ID.Hex(), attempt.TotalScore)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"slices"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrPermissionDenied  = errors.New("you do not have permission to do this")
+	ErrInvalidPermission = errors.New("permission cannot be granted on this resource")
+	ErrInvalidResource   = errors.New("resource type must be class or test")
+	ErrInvalidEmail      = errors.New("invalid email")
+)
+
+// AuthorizationService decides what a user may do with classes, tests and questions.
+// Owners and the roles of the class roster come first, then the grants of the user.
+type AuthorizationService struct {
+	classRepo    repository.ClassRepository
+	testRepo     repository.TestRepository
+	questionRepo repository.QuestionRepository
+	grantRepo    repository.GrantRepository
+}
+
+func NewAuthorizationService(cr repository.ClassRepository, tr repository.TestRepository, qr repository.QuestionRepository, gr repository.GrantRepository) *AuthorizationService {
+	return &AuthorizationService{
+		classRepo:    cr,
+		testRepo:     tr,
+		questionRepo: qr,
+		grantRepo:    gr,
+	}
+}
+
+// ClassPermissions returns the permissions of the user in the class: those of their role plus their grant.
+func (s *AuthorizationService) ClassPermissions(ctx context.Context, class *entity.Class, emailID, email string) ([]string, error) {
+	perms := slices.Clone(entity.RolePermissions(class.RoleOf(emailID, email)))
+	return s.withGrant(ctx, perms, entity.ResourceClass, class.ID, email)
+}
+
+// TestPermissions returns the permissions of the user on a test: all of them for its author, otherwise their grant.
+func (s *AuthorizationService) TestPermissions(ctx context.Context, test *entity.Test, emailID, email string) ([]string, error) {
+	if emailID != "" && emailID == test.EmailID {
+		return []string{entity.PermView, entity.PermEdit, entity.PermGrade, entity.PermOwn}, nil
+	}
+	return s.withGrant(ctx, nil, entity.ResourceTest, test.ID, email)
+}
+
+// withGrant adds the permissions granted to the email on the resource.
+func (s *AuthorizationService) withGrant(ctx context.Context, perms []string, resourceType string, resourceID primitive.ObjectID, email string) ([]string, error) {
+	email = entity.NormalizeEmail(email)
+	if email == "" {
+		return perms, nil
+	}
+	grant, err := s.grantRepo.GetGrant(ctx, resourceType, resourceID, email)
+	if err != nil {
+		return nil, err
+	}
+	if grant != nil {
+		for _, perm := range grant.Permissions {
+			if !slices.Contains(perms, perm) {
+				perms = append(perms, perm)
+			}
+		}
+	}
+	return perms, nil
+}
+
+// AuthorizeClass loads the class and checks the user holds perm in it.
+func (s *AuthorizationService) AuthorizeClass(ctx context.Context, classID primitive.ObjectID, emailID, email, perm string) (*entity.Class, error) {
+	class, err := s.classRepo.GetClassByID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.ClassPermissions(ctx, class, emailID, email)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(perms, perm) {
+		if perm == entity.PermOwn {
+			return nil, ErrNotClassOwner
+		}
+		return nil, ErrPermissionDenied
+	}
+	return class, nil
+}
+
+// AuthorizeClassMember loads the class for its active students and for users who may view it.
+// Everyone else gets ErrNotClassMember.
+func (s *AuthorizationService) AuthorizeClassMember(ctx context.Context, classID primitive.ObjectID, emailID, email string) (*entity.Class, error) {
+	class, err := s.classRepo.GetClassByID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if requireStudent(class, email) == nil {
+		return class, nil
+	}
+	perms, err := s.ClassPermissions(ctx, class, emailID, email)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(perms, entity.PermView) {
+		return nil, ErrNotClassMember
+	}
+	return class, nil
+}
+
+// AuthorizeClassTest checks perm on a test of the class, held in the class or granted on the test.
+func (s *AuthorizationService) AuthorizeClassTest(ctx context.Context, classID, testID primitive.ObjectID, emailID, email, perm string) (*entity.Class, error) {
+	class, err := s.classRepo.GetClassByID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.ClassPermissions(ctx, class, emailID, email)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(perms, perm) {
+		return class, nil
+	}
+	if perm != entity.PermOwn && slices.ContainsFunc(class.Test, func(t entity.Test) bool { return t.ID == testID }) {
+		if perms, err = s.withGrant(ctx, nil, entity.ResourceTest, testID, email); err != nil {
+			return nil, err
+		}
+		if slices.Contains(perms, perm) {
+			return class, nil
+		}
+	}
+	return nil, ErrPermissionDenied
+}
+
+// AuthorizeTest loads a test of the bank and checks the user holds perm on it.
+// Users who may not even view the test get ErrTestNotFound.
+func (s *AuthorizationService) AuthorizeTest(ctx context.Context, testID primitive.ObjectID, emailID, email, perm string) (*entity.Test, error) {
+	test, err := s.testRepo.GetTestByID(ctx, testID)
+	if err != nil {
+		return nil, err
+	}
+	if test == nil {
+		return nil, ErrTestNotFound
+	}
+	perms, err := s.TestPermissions(ctx, test, emailID, email)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !slices.Contains(perms, entity.PermView):
+		return nil, ErrTestNotFound
+	case !slices.Contains(perms, perm):
+		return nil, ErrPermissionDenied
+	}
+	return test, nil
+}
+
+// AuthorizeQuestion loads a question and checks the user may act on it with perm. The author
+// may do anything; other users need perm on testID, a test that uses the question.
+// Users who may not even view the question get ErrQuestionNotFound.
+func (s *AuthorizationService) AuthorizeQuestion(ctx context.Context, questionID, testID primitive.ObjectID, emailID, email, perm string) (*entity.Question, error) {
+	if questionID.IsZero() {
+		return nil, ErrQuestionNotFound
+	}
+	questions, err := s.questionRepo.GetQuestionsByIDs(ctx, []primitive.ObjectID{questionID})
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, ErrQuestionNotFound
+	}
+	question := &questions[0]
+	if emailID != "" && question.Metadata.Author == emailID {
+		return question, nil
+	}
+	if testID.IsZero() {
+		return nil, ErrQuestionNotFound
+	}
+
+	test, err := s.AuthorizeTest(ctx, testID, emailID, email, entity.PermView)
+	if errors.Is(err, ErrTestNotFound) {
+		return nil, ErrQuestionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(test.QuestionIDs, questionID) {
+		return nil, ErrQuestionNotFound
+	}
+	if perm == entity.PermOwn {
+		return nil, ErrPermissionDenied
+	}
+	if _, err := s.AuthorizeTest(ctx, testID, emailID, email, perm); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// Permissions returns what the user may do with a class or a test of the bank, e.g. to show
+// or hide actions. Users who may not view a test get ErrTestNotFound.
+func (s *AuthorizationService) Permissions(ctx context.Context, emailID, email, resourceType string, resourceID primitive.ObjectID) ([]string, error) {
+	var perms []string
+	switch resourceType {
+	case entity.ResourceClass:
+		class, err := s.classRepo.GetClassByID(ctx, resourceID)
+		if err != nil {
+			return nil, err
+		}
+		if perms, err = s.ClassPermissions(ctx, class, emailID, email); err != nil {
+			return nil, err
+		}
+	case entity.ResourceTest:
+		test, err := s.AuthorizeTest(ctx, resourceID, emailID, email, entity.PermView)
+		if err != nil {
+			return nil, err
+		}
+		if perms, err = s.TestPermissions(ctx, test, emailID, email); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrInvalidResource
+	}
+	if perms == nil {
+		perms = []string{}
+	}
+	return perms, nil
+}
+
+// ListGrants lists the grants on a class or test for its owner.
+func (s *AuthorizationService) ListGrants(ctx context.Context, emailID, email, resourceType string, resourceID primitive.ObjectID) ([]entity.Grant, error) {
+	if err := s.authorizeOwner(ctx, emailID, email, resourceType, resourceID); err != nil {
+		return nil, err
+	}
+	return s.grantRepo.GetGrantsByResource(ctx, resourceType, resourceID)
+}
+
+// GrantPermissions gives grant.Email the permissions of the grant on its resource, replacing
+// those granted before. Every grant includes view. Only the owner of the resource may share it.
+func (s *AuthorizationService) GrantPermissions(ctx context.Context, emailID, email string, grant entity.Grant) (*entity.Grant, error) {
+	if err := s.authorizeOwner(ctx, emailID, email, grant.ResourceType, grant.ResourceID); err != nil {
+		return nil, err
+	}
+	target := entity.NormalizeEmail(grant.Email)
+	if _, err := mail.ParseAddress(target); err != nil {
+		return nil, ErrInvalidEmail
+	}
+	perms := []string{entity.PermView}
+	for _, perm := range grant.Permissions {
+		if !entity.IsGrantable(grant.ResourceType, perm) {
+			return nil, ErrInvalidPermission
+		}
+		if !slices.Contains(perms, perm) {
+			perms = append(perms, perm)
+		}
+	}
+
+	stored, err := s.grantRepo.GetGrant(ctx, grant.ResourceType, grant.ResourceID, target)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if stored == nil {
+		stored = &entity.Grant{ResourceType: grant.ResourceType, ResourceID: grant.ResourceID, Email: target, CreatedAt: now}
+	}
+	stored.Permissions = perms
+	stored.GrantedBy = entity.NormalizeEmail(email)
+	stored.UpdatedAt = now
+	if err := s.grantRepo.SaveGrant(ctx, stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// RevokePermissions removes the grant of target on the resource. Only the owner may revoke it.
+func (s *AuthorizationService) RevokePermissions(ctx context.Context, emailID, email, resourceType string, resourceID primitive.ObjectID, target string) error {
+	if err := s.authorizeOwner(ctx, emailID, email, resourceType, resourceID); err != nil {
+		return err
+	}
+	return s.grantRepo.DeleteGrant(ctx, resourceType, resourceID, entity.NormalizeEmail(target))
+}
+
+// SharedWith lists the grants given to the email, e.g. to list the tests shared with a teacher.
+func (s *AuthorizationService) SharedWith(ctx context.Context, email string) ([]entity.Grant, error) {
+	return s.grantRepo.GetGrantsByEmail(ctx, entity.NormalizeEmail(email))
+}
+
+// ForgetResource deletes the grants on a class or test that was deleted.
+func (s *AuthorizationService) ForgetResource(ctx context.Context, resourceType string, resourceID primitive.ObjectID) error {
+	grants, err := s.grantRepo.GetGrantsByResource(ctx, resourceType, resourceID)
+	if err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		if err := s.grantRepo.DeleteGrant(ctx, resourceType, resourceID, grant.Email); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizeOwner checks the user owns the class or the test.
+func (s *AuthorizationService) authorizeOwner(ctx context.Context, emailID, email, resourceType string, resourceID primitive.ObjectID) error {
+	var err error
+	switch resourceType {
+	case entity.ResourceClass:
+		_, err = s.AuthorizeClass(ctx, resourceID, emailID, email, entity.PermOwn)
+	case entity.ResourceTest:
+		_, err = s.AuthorizeTest(ctx, resourceID, emailID, email, entity.PermOwn)
+	default:
+		err = ErrInvalidResource
+	}
+	return err
+}
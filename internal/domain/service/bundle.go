@@ -0,0 +1,531 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Formats accepted by the exports.
+const (
+	ExportFormatJSON = "json" // Bundle re-importable with ImportBundle
+	ExportFormatQTI  = "qti"  // IMS QTI 2.1 content package
+)
+
+// BundleFormat and BundleVersion identify the JSON bundle. BundleVersion is bumped
+// when the layout changes, older bundles must keep importing.
+const (
+	BundleFormat  = "quiz-app.bundle"
+	BundleVersion = 1
+)
+
+// Paths inside the exported zip packages.
+const (
+	bundleManifest = "bundle.json"
+	bundleMediaDir = "media/"
+)
+
+// maxBundleMedia caps the size of one media file read into a package or from a bundle.
+const maxBundleMedia = 20 << 20
+
+var (
+	ErrUnknownExportFormat = errors.New("unknown export format")
+	ErrTestNotFound        = errors.New("test not found")
+	ErrInvalidBundle       = errors.New("invalid bundle")
+)
+
+// Bundle is the manifest of a JSON export. The questions keep their original media
+// references, Media tells where the packaged copy of each one is.
+type Bundle struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Test       *entity.Test      `json:"test,omitempty"`
+	Questions  []entity.Question `json:"questions"`
+	Media      []BundleMedia     `json:"media,omitempty"`
+}
+
+// BundleMedia is a file of the author's file store packaged with the questions.
+type BundleMedia struct {
+	Ref  string `json:"ref"`  // The reference used in the questions
+	Path string `json:"path"` // Path of the file inside the zip
+}
+
+// BundleImportResult reports a bundle import. Test is the new copy of the bundled test, if any.
+type BundleImportResult struct {
+	*ImportResult
+	Test  *entity.Test `json:"test,omitempty"`
+	Media []string     `json:"media"` // Files added to the importer's file store
+}
+
+// BundleUseCase exports questions and tests as portable packages and imports bundles back.
+type BundleUseCase struct {
+	Questions *QuestionUseCase
+	TestRepo  repository.TestRepository
+	FileRepo  repository.FileRepository
+	Storage   repository.FileStorage
+	Authz     *AuthorizationService
+}
+
+func NewBundleUseCase(questions *QuestionUseCase, tr repository.TestRepository, fr repository.FileRepository, storage repository.FileStorage, authz *AuthorizationService) *BundleUseCase {
+	return &BundleUseCase{
+		Questions: questions,
+		TestRepo:  tr,
+		FileRepo:  fr,
+		Storage:   storage,
+		Authz:     authz,
+	}
+}
+
+// exportContent is what goes into a package: the questions, the optional test and the
+// media found in the author's file store.
+type exportContent struct {
+	test      *entity.Test
+	questions []entity.Question
+	media     []BundleMedia
+	files     map[string][]byte // Package path -> content
+}
+
+// ExportQuestions packages the author's questions, or all of them when ids is empty.
+// email locates the author's media in the file store.
+func (uc *BundleUseCase) ExportQuestions(ctx context.Context, author, email, format string, ids []primitive.ObjectID) ([]byte, error) {
+	if err := checkExportFormat(format); err != nil {
+		return nil, err
+	}
+	var questions []entity.Question
+	var err error
+	if len(ids) == 0 {
+		questions, err = uc.allQuestions(ctx, author)
+	} else {
+		questions, err = uc.ownQuestions(ctx, author, ids)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, ErrQuestionNotFound
+	}
+	return uc.export(ctx, email, format, &exportContent{questions: questions})
+}
+
+// ExportTest packages a test the user may view with the questions of its author, in the test's order.
+// The media come from the file store of the test's author.
+func (uc *BundleUseCase) ExportTest(ctx context.Context, emailID, email, format string, testID primitive.ObjectID) ([]byte, error) {
+	if err := checkExportFormat(format); err != nil {
+		return nil, err
+	}
+	test, err := uc.Authz.AuthorizeTest(ctx, testID, emailID, email, entity.PermView)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := uc.ownQuestions(ctx, test.EmailID, test.QuestionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Answers and class state stay with the original test
+	exported := *test
+	exported.AnswerUser = nil
+	exported.AnswersReleased = false
+	return uc.export(ctx, test.EmailName, format, &exportContent{test: &exported, questions: questions})
+}
+
+func checkExportFormat(format string) error {
+	switch format {
+	case ExportFormatJSON, ExportFormatQTI:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownExportFormat, format)
+}
+
+func (uc *BundleUseCase) export(ctx context.Context, email, format string, content *exportContent) ([]byte, error) {
+	if err := uc.collectMedia(ctx, email, content); err != nil {
+		return nil, err
+	}
+	if format == ExportFormatQTI {
+		return writeQTIPackage(content)
+	}
+	return writeBundle(content)
+}
+
+// ownQuestions loads the questions of ids that belong to author, in the order of ids.
+func (uc *BundleUseCase) ownQuestions(ctx context.Context, author string, ids []primitive.ObjectID) ([]entity.Question, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	loaded, err := uc.Questions.QuestionRepo.GetQuestionsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]entity.Question, len(loaded))
+	for _, question := range loaded {
+		if question.Metadata.Author == author {
+			byID[question.ID] = question
+		}
+	}
+	questions := make([]entity.Question, 0, len(byID))
+	for _, id := range ids {
+		if question, ok := byID[id]; ok {
+			questions = append(questions, question)
+			delete(byID, id)
+		}
+	}
+	return questions, nil
+}
+
+// allQuestions walks the author's whole bank, oldest first.
+func (uc *BundleUseCase) allQuestions(ctx context.Context, author string) ([]entity.Question, error) {
+	query := entity.QuestionQuery{Author: author, SortBy: entity.QuestionSortCreated, Limit: maxQuestionPageSize}
+	var questions []entity.Question
+	for {
+		page, err := uc.Questions.QuestionRepo.SearchQuestions(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, page.Questions...)
+		if page.NextCursor == "" {
+			return questions, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// collectMedia copies the media of the questions found in the author's file store into the package.
+// References that are not in the store, e.g. links to other sites, are left as they are.
+func (uc *BundleUseCase) collectMedia(ctx context.Context, email string, content *exportContent) error {
+	content.files = make(map[string][]byte)
+	seen := make(map[string]bool)
+	for i := range content.questions {
+		for _, ref := range questionMediaRefs(&content.questions[i]) {
+			if *ref == "" || seen[*ref] {
+				continue
+			}
+			seen[*ref] = true
+
+			name := mediaFilename(*ref)
+			if name == "" {
+				continue
+			}
+			packagePath := bundleMediaDir + name
+			if _, ok := content.files[packagePath]; !ok {
+				data, err := uc.readStoredFile(ctx, email, name)
+				if err != nil {
+					return err
+				}
+				if data == nil {
+					continue
+				}
+				content.files[packagePath] = data
+			}
+			content.media = append(content.media, BundleMedia{Ref: *ref, Path: packagePath})
+		}
+	}
+	return nil
+}
+
+// readStoredFile returns nil when the file is not in the author's store. Other storage
+// errors fail the export rather than leave the media out.
+func (uc *BundleUseCase) readStoredFile(ctx context.Context, email, name string) ([]byte, error) {
+	body, err := uc.Storage.GetFile(ctx, email, name)
+	if errors.Is(err, repository.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read media %q: %w", name, err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxBundleMedia+1))
+	if err != nil {
+		return nil, fmt.Errorf("read media %q: %w", name, err)
+	}
+	if len(data) > maxBundleMedia {
+		return nil, fmt.Errorf("media %q is larger than %d bytes", name, maxBundleMedia)
+	}
+	return data, nil
+}
+
+// questionMediaRefs returns pointers to the media references of the question, so they can be rewritten.
+func questionMediaRefs(q *entity.Question) []*string {
+	refs := []*string{&q.QuestionContent.ImageURL, &q.QuestionContent.VideoURL, &q.QuestionContent.AudioURL}
+	for i := range q.Options {
+		refs = append(refs, &q.Options[i].ImageURL)
+	}
+	return refs
+}
+
+// mediaFilename returns the filename of a media reference: the reference itself for a bare
+// filename, or the last segment of a URL's path.
+func mediaFilename(ref string) string {
+	if u, err := url.Parse(ref); err == nil {
+		ref = u.Path
+	}
+	name := path.Base(ref)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+func writeBundle(content *exportContent) ([]byte, error) {
+	bundle := Bundle{
+		Format:     BundleFormat,
+		Version:    BundleVersion,
+		ExportedAt: time.Now(),
+		Test:       content.test,
+		Questions:  content.questions,
+		Media:      content.media,
+	}
+	manifest, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode bundle: %w", err)
+	}
+	files := map[string][]byte{bundleManifest: manifest}
+	for name, data := range content.files {
+		files[name] = data
+	}
+	return writeZip(files, []string{bundleManifest})
+}
+
+// writeZip writes the files into a zip, first in the given order and then the rest sorted by path.
+func writeZip(files map[string][]byte, first []string) ([]byte, error) {
+	order := append([]string(nil), first...)
+	rest := make([]string, 0, len(files))
+	for name := range files {
+		if !slices.Contains(first, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	order = append(order, rest...)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range order {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("write %s: %w", name, err)
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("write zip: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportBundle imports a JSON bundle into the account of author: the questions get new IDs,
+// the media is added to the importer's file store and the test, if any, is created again
+// with the new questions. With dryRun the questions and the test are only validated.
+func (uc *BundleUseCase) ImportBundle(ctx context.Context, author, email string, data []byte, dryRun bool) (*BundleImportResult, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifest, err := readZipFile(files[bundleManifest])
+	if err != nil {
+		return nil, err
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(manifest, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if bundle.Format != BundleFormat {
+		return nil, fmt.Errorf("%w: not a %s file", ErrInvalidBundle, BundleFormat)
+	}
+	if bundle.Version < 1 || bundle.Version > BundleVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBundle, bundle.Version)
+	}
+
+	// New IDs are assigned here to rebuild the test's question list
+	newIDs := make(map[primitive.ObjectID]primitive.ObjectID, len(bundle.Questions))
+	parsed := make([]parsedQuestion, len(bundle.Questions))
+	for i, question := range bundle.Questions {
+		newID := primitive.NewObjectID()
+		if !question.ID.IsZero() {
+			newIDs[question.ID] = newID
+		}
+		question.ID = newID
+		question.Version = 0
+		parsed[i] = parsedQuestion{Line: i + 1, Question: question}
+	}
+
+	// Everything is validated before the media is stored, so a failed import leaves no files behind
+	checked, err := uc.Questions.importParsed(ctx, author, parsed, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	result := &BundleImportResult{ImportResult: checked, Media: []string{}}
+	var test *entity.Test
+	if bundle.Test != nil {
+		if test, err = importedTest(*bundle.Test, newIDs, checked.Questions, author, email); err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	// Only the media of the questions that will be imported is stored
+	used := make(map[string]bool)
+	for i := range checked.Questions {
+		for _, ref := range questionMediaRefs(&checked.Questions[i]) {
+			used[*ref] = true
+		}
+	}
+	media := make([]BundleMedia, 0, len(bundle.Media))
+	for _, m := range bundle.Media {
+		if used[m.Ref] {
+			media = append(media, m)
+		}
+	}
+	refs, err := uc.importMedia(ctx, author, email, media, files)
+	if err != nil {
+		return nil, err
+	}
+	for i := range parsed {
+		for _, ref := range questionMediaRefs(&parsed[i].Question) {
+			if name, ok := refs[*ref]; ok {
+				*ref = name
+			}
+		}
+	}
+	for _, name := range refs {
+		if !slices.Contains(result.Media, name) {
+			result.Media = append(result.Media, name)
+		}
+	}
+	sort.Strings(result.Media)
+
+	result.ImportResult, err = uc.Questions.importParsed(ctx, author, parsed, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if test != nil {
+		if err := uc.TestRepo.InsertTest(ctx, test); err != nil {
+			return nil, err
+		}
+		result.Test = test
+	}
+	return result, nil
+}
+
+// importedTest rebuilds the bundled test for the importer, keeping only the questions that
+// pass validation under their new IDs.
+func importedTest(test entity.Test, newIDs map[primitive.ObjectID]primitive.ObjectID, questions []entity.Question, author, email string) (*entity.Test, error) {
+	imported := make(map[primitive.ObjectID]bool, len(questions))
+	for _, question := range questions {
+		imported[question.ID] = true
+	}
+	test.ID = primitive.NewObjectID()
+	test.TemplateID = primitive.NilObjectID
+	test.MapQuestionIDs(func(id primitive.ObjectID) (primitive.ObjectID, bool) {
+		newID, ok := newIDs[id]
+		return newID, ok && imported[newID]
+	})
+	test.EmailID = author
+	test.EmailName = email
+	test.AnswerUser = nil
+	test.AnswersReleased = false
+	test.CreatedAt = time.Now()
+	test.UpdatedAt = test.CreatedAt
+	if err := test.ValidateDrawRules(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if err := test.PrepareSections(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	return &test, nil
+}
+
+// importMedia stores the bundled media in the importer's file store and returns the new
+// reference of each original one. A name already used in the store gets a suffix.
+func (uc *BundleUseCase) importMedia(ctx context.Context, author, email string, media []BundleMedia, files map[string]*zip.File) (map[string]string, error) {
+	refs := make(map[string]string, len(media))
+	stored := make(map[string]string) // Package path -> stored name
+	for _, m := range media {
+		if name, ok := stored[m.Path]; ok {
+			refs[m.Ref] = name
+			continue
+		}
+		data, err := readZipFile(files[m.Path])
+		if err != nil {
+			return nil, err
+		}
+		name, err := uc.freeFilename(ctx, email, path.Base(m.Path))
+		if err != nil {
+			return nil, err
+		}
+		contentType := mime.TypeByExtension(path.Ext(name))
+		file := entity.NewFile(contentType, int64(len(data)), email, name)
+		file.Metadata.EmailID = author
+		file.UploadDate = time.Now()
+		if _, err := uc.Storage.CreateFile(ctx, file, bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		if file.ID, err = uc.FileRepo.CreateFile(ctx, file); err != nil {
+			return nil, err
+		}
+		stored[m.Path] = name
+		refs[m.Ref] = name
+	}
+	return refs, nil
+}
+
+// freeFilename returns name, or name with a short suffix when the user already has a file with that name.
+func (uc *BundleUseCase) freeFilename(ctx context.Context, email, name string) (string, error) {
+	existing, err := uc.FileRepo.FindByName(ctx, &entity.File{Metadata: entity.FileMetadata{Email: email}, Filename: name})
+	if err != nil {
+		return "", err
+	}
+	if found, _ := existing.([]any); len(found) == 0 {
+		return name, nil
+	}
+	ext := path.Ext(name)
+	suffix := primitive.NewObjectID().Hex()
+	return strings.TrimSuffix(name, ext) + "-" + suffix[len(suffix)-6:] + ext, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("%w: missing file", ErrInvalidBundle)
+	}
+	if f.UncompressedSize64 > maxBundleMedia {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidBundle, f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxBundleMedia+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if len(data) > maxBundleMedia {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidBundle, f.Name)
+	}
+	return data, nil
+}
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	entity "quiz-app/internal/domain/entities"
 	"quiz-app/internal/domain/repository"
@@ -10,13 +13,21 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrNotClassOwner  = errors.New("only the class owner can do this")
+	ErrNotClassMember = errors.New("student is not a member of the class")
+	ErrMemberBanned   = errors.New("you are banned from this class")
+)
+
 type ClassUseCase struct {
 	repoClass repository.ClassRepository
+	authz     *AuthorizationService
 }
 
-func NewClassUseCase(repoClass repository.ClassRepository, repoTest repository.TestRepository) *ClassUseCase {
+func NewClassUseCase(repoClass repository.ClassRepository, repoTest repository.TestRepository, authz *AuthorizationService) *ClassUseCase {
 	return &ClassUseCase{
 		repoClass: repoClass,
+		authz:     authz,
 	}
 }
 
@@ -34,25 +45,164 @@ func (uc *ClassUseCase) GetAllClassByEmail(ctx context.Context, email string) ([
 	return uc.repoClass.GetAllClassByEmail(ctx, email)
 }
 
-func (uc *ClassUseCase) UpdateClass(ctx context.Context, class *entity.Class) (any, error) {
+// UpdateClass saves the class for users with the edit permission. The owner and the roster are
+// kept as stored, they change through the roster methods only.
+func (uc *ClassUseCase) UpdateClass(ctx context.Context, emailID, email string, class *entity.Class) (any, error) {
+	stored, err := uc.authz.AuthorizeClass(ctx, class.ID, emailID, email, entity.PermEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	class.EmailID = stored.EmailID
+	class.AuthorMail = stored.AuthorMail
+	class.CreatedAt = stored.CreatedAt
+	class.Members = stored.Members
+	class.StudentAccept = stored.StudentAccept
+	class.StudentsWait = stored.StudentsWait
+	class.UpdatedAt = time.Now()
 	return uc.repoClass.UpdateClass(ctx, class)
 }
 
+// DeleteClass deletes the class and the grants on it. Only the owner may delete it.
 func (uc *ClassUseCase) DeleteClass(ctx context.Context, emailID string, id primitive.ObjectID) error {
-	return uc.repoClass.DeleteClass(ctx, emailID, id)
+	if _, err := uc.authz.AuthorizeClass(ctx, id, emailID, "", entity.PermOwn); err != nil {
+		return err
+	}
+	if err := uc.repoClass.DeleteClass(ctx, id); err != nil {
+		return err
+	}
+	return uc.authz.ForgetResource(ctx, entity.ResourceClass, id)
 }
 
 func (uc *ClassUseCase) GetAllClass(ctx context.Context, authorEmail string) ([]any, error) {
 	return uc.repoClass.GetClassByAuthorEmail(ctx, authorEmail)
 }
 
-func (uc *ClassUseCase) JoinClass(ctx context.Context, classID primitive.ObjectID, testIDs []primitive.ObjectID, emailAuthor, studentEmail string) error {
+// JoinClass adds the student to the class, right away when the class is public and
+// otherwise waiting for approval. Members keep their entry and banned students are refused.
+func (uc *ClassUseCase) JoinClass(ctx context.Context, classID primitive.ObjectID, studentEmail string) (*entity.ClassMember, error) {
+	class, err := uc.repoClass.GetClassByID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if member, ok := class.Member(studentEmail); ok {
+		if member.Status == entity.MemberBanned {
+			return nil, ErrMemberBanned
+		}
+		return &member, nil
+	}
 
-	return uc.repoClass.JoinClass(ctx, classID, studentEmail)
+	member := entity.ClassMember{
+		Email:     entity.NormalizeEmail(studentEmail),
+		Role:      entity.RoleStudent,
+		Status:    entity.MemberPending,
+		UpdatedAt: time.Now(),
+	}
+	if class.IsPublic {
+		member.Status = entity.MemberActive
+	}
+	if err := uc.repoClass.SaveMember(ctx, classID, member); err != nil {
+		return nil, err
+	}
+	return &member, nil
 }
-func (uc *ClassUseCase) GetAllTestOfClass(ctx context.Context, email string, id primitive.ObjectID) ([]any, error) {
+
+// GetAllTestOfClass lists the tests of the class for its active students and users who may view it.
+func (uc *ClassUseCase) GetAllTestOfClass(ctx context.Context, emailID, email string, id primitive.ObjectID) ([]any, error) {
+	if _, err := uc.authz.AuthorizeClassMember(ctx, id, emailID, email); err != nil {
+		return nil, err
+	}
 	return uc.repoClass.GetAllTestOfClass(ctx, email, id)
 }
+
+// GetQuestionOfTest returns the questions and info of a test of the class, for its active students only.
 func (uc *ClassUseCase) GetQuestionOfTest(ctx context.Context, classId, testId primitive.ObjectID, email string) ([]primitive.ObjectID, primitive.M, error) {
+	if _, err := uc.StudentClass(ctx, classId, email); err != nil {
+		return nil, nil, err
+	}
 	return uc.repoClass.GetQuestionOfTest(ctx, classId, testId, email)
 }
+
+// StudentClass loads the class for one of its active students. Pending, banned and removed
+// students, and the teachers of the class, get ErrNotClassMember.
+func (uc *ClassUseCase) StudentClass(ctx context.Context, classID primitive.ObjectID, email string) (*entity.Class, error) {
+	class, err := uc.repoClass.GetClassByID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStudent(class, email); err != nil {
+		return nil, err
+	}
+	return class, nil
+}
+
+// requireStudent checks that the email is an active student of the class.
+func requireStudent(class *entity.Class, email string) error {
+	if class.RoleOf("", email) != entity.RoleStudent {
+		return ErrNotClassMember
+	}
+	return nil
+}
+
+func (uc *ClassUseCase) GetTestOfClass(ctx context.Context, classID, testID primitive.ObjectID) (*entity.Test, error) {
+	return uc.repoClass.GetTestOfClass(ctx, classID, testID)
+}
+
+// GetAccommodation returns the user's accommodation for the test, or nil when there is none.
+// Only members of the class may ask.
+func (uc *ClassUseCase) GetAccommodation(ctx context.Context, classID, testID primitive.ObjectID, emailID, email string) (*entity.Accommodation, error) {
+	class, err := uc.authz.AuthorizeClassMember(ctx, classID, emailID, email)
+	if err != nil {
+		return nil, err
+	}
+	return class.AccommodationFor(email, testID), nil
+}
+
+// SetAccommodation stores an accommodation for a student of the class. It needs the edit permission.
+func (uc *ClassUseCase) SetAccommodation(ctx context.Context, emailID, email string, classID primitive.ObjectID, acc entity.Accommodation) (*entity.Accommodation, error) {
+	if err := acc.Validate(); err != nil {
+		return nil, err
+	}
+	class, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermEdit)
+	if err != nil {
+		return nil, err
+	}
+	if class.RoleOf("", acc.Email) != entity.RoleStudent {
+		return nil, ErrNotClassMember
+	}
+	if !acc.TestID.IsZero() && !slices.ContainsFunc(class.Test, func(t entity.Test) bool { return t.ID == acc.TestID }) {
+		return nil, errors.New("test is not part of the class")
+	}
+
+	acc.UpdatedAt = time.Now()
+	if err := uc.repoClass.SetAccommodation(ctx, classID, acc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// RemoveAccommodation deletes a student's accommodation. It needs the edit permission.
+func (uc *ClassUseCase) RemoveAccommodation(ctx context.Context, emailID, email string, classID primitive.ObjectID, studentEmail string, testID primitive.ObjectID) error {
+	if _, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermEdit); err != nil {
+		return err
+	}
+	return uc.repoClass.RemoveAccommodation(ctx, classID, studentEmail, testID)
+}
+
+// SetBlindGrading hides (or shows again) student emails in the grading queue of a test.
+// Only the class owner may change it, so graders cannot turn it off for themselves.
+func (uc *ClassUseCase) SetBlindGrading(ctx context.Context, emailID, email string, classID, testID primitive.ObjectID, blind bool) error {
+	if _, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermOwn); err != nil {
+		return err
+	}
+	return uc.repoClass.SetBlindGrading(ctx, classID, testID, blind)
+}
+
+// ReleaseAnswers opens (or closes again) the review of a test that uses the manual review policy.
+// It needs the grade permission on the class or the test.
+func (uc *ClassUseCase) ReleaseAnswers(ctx context.Context, emailID, email string, classID, testID primitive.ObjectID, released bool) error {
+	if _, err := uc.authz.AuthorizeClassTest(ctx, classID, testID, emailID, email, entity.PermGrade); err != nil {
+		return err
+	}
+	return uc.repoClass.SetAnswersReleased(ctx, classID, testID, released)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+func TestClassTestsNeedMembership(t *testing.T) {
+	ctx := context.Background()
+	env := newAnswerTestEnv()
+	env.class.Members = append(env.class.Members,
+		entity.ClassMember{Email: "pending@school.edu", Role: entity.RoleStudent, Status: entity.MemberPending},
+		entity.ClassMember{Email: "assistant@school.edu", Role: entity.RoleAssistant, Status: entity.MemberActive},
+	)
+	env.class.Accommodations = []entity.Accommodation{{Email: testStudentEmail, TestID: env.test.ID, ExtraMinutes: 15}}
+	classes := NewClassUseCase(env.classes, nil, env.uc.authz)
+
+	tests := []struct {
+		name    string
+		emailID string
+		email   string
+		wantErr error
+	}{
+		{"active student", testStudentID, testStudentEmail, nil},
+		{"owner", testOwnerID, testOwnerEmail, nil},
+		{"assistant", "assistant-id", "assistant@school.edu", nil},
+		{"pending student", "pending-id", "pending@school.edu", ErrNotClassMember},
+		{"outsider", "outsider-id", "outsider@school.edu", ErrNotClassMember},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := classes.GetAllTestOfClass(ctx, tt.emailID, tt.email, env.class.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetAllTestOfClass() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(list) != 1 {
+				t.Errorf("GetAllTestOfClass() = %d tests, want 1", len(list))
+			}
+
+			acc, err := classes.GetAccommodation(ctx, env.class.ID, env.test.ID, tt.emailID, tt.email)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetAccommodation() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := acc != nil; got != (tt.email == testStudentEmail) {
+				t.Errorf("GetAccommodation() = %+v", acc)
+			}
+		})
+	}
+}
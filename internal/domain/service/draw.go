@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrNotEnoughQuestions = errors.New("not enough questions in the bank for the draw rules")
+
+// drawSalt keeps the draw independent from the display shuffle of the same attempt.
+const drawSalt = 0x5bd1e995
+
+// questionDraw collects the questions drawn for an attempt. A question is drawn at most once.
+type questionDraw struct {
+	r      *rand.Rand
+	picked map[primitive.ObjectID]struct{}
+	drawn  []entity.DrawnQuestion
+}
+
+func newQuestionDraw(attemptID primitive.ObjectID) *questionDraw {
+	return &questionDraw{
+		r:      pkg.NewSeededRand(pkg.ShuffleSeed(attemptID) ^ drawSalt),
+		picked: make(map[primitive.ObjectID]struct{}),
+	}
+}
+
+// drawQuestions picks the attempt's questions: the test's fixed questions plus a
+// random draw from the author's bank for every draw rule.
+func (au *AnswerUseCase) drawQuestions(ctx context.Context, attemptID primitive.ObjectID, test entity.Test) ([]entity.DrawnQuestion, error) {
+	draw := newQuestionDraw(attemptID)
+	if _, err := au.draw(ctx, draw, test.EmailID, test.QuestionIDs, test.DrawRules); err != nil {
+		return nil, err
+	}
+	return draw.drawn, nil
+}
+
+// draw adds the fixed questions and the questions drawn by rules, returning the IDs it added.
+func (au *AnswerUseCase) draw(ctx context.Context, draw *questionDraw, author string, fixed []primitive.ObjectID, rules []entity.DrawRule) ([]primitive.ObjectID, error) {
+	added := make([]primitive.ObjectID, 0, len(fixed))
+	for _, id := range fixed {
+		draw.picked[id] = struct{}{}
+		draw.drawn = append(draw.drawn, entity.DrawnQuestion{QuestionID: id})
+		added = append(added, id)
+	}
+
+	for i, rule := range rules {
+		ids, err := au.questionRepo.GetQuestionIDsByRule(ctx, author, rule)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]primitive.ObjectID, 0, len(ids))
+		for _, id := range ids {
+			if _, ok := draw.picked[id]; !ok {
+				candidates = append(candidates, id)
+			}
+		}
+		if len(candidates) < rule.Count {
+			return nil, fmt.Errorf("%w: rule %d needs %d, found %d", ErrNotEnoughQuestions, i+1, rule.Count, len(candidates))
+		}
+
+		pkg.Shuffle(draw.r, candidates)
+		for _, id := range candidates[:rule.Count] {
+			draw.picked[id] = struct{}{}
+			draw.drawn = append(draw.drawn, entity.DrawnQuestion{QuestionID: id, Score: rule.Score})
+			added = append(added, id)
+		}
+	}
+	return added, nil
+}
+
+// applyDrawnScores sets the per-rule score on drawn questions that override it.
+func applyDrawnScores(questions []entity.Question, drawn []entity.DrawnQuestion) {
+	scores := make(map[primitive.ObjectID]float32, len(drawn))
+	for _, d := range drawn {
+		if d.Score > 0 {
+			scores[d.QuestionID] = d.Score
+		}
+	}
+	for i := range questions {
+		if score, ok := scores[questions[i].ID]; ok {
+			questions[i].Score = score
+		}
+	}
+}
@@ -0,0 +1,587 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IMS QTI 2.1 content package: imsmanifest.xml, one assessmentItem file per question,
+// an assessmentTest file when a test is exported, and the media under media/.
+const (
+	qtiNamespace      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiSchemaLocation = "http://www.imsglobal.org/xsd/imsqti_v2p1 http://www.imsglobal.org/xsd/qti/qtiv2p1/imsqti_v2p1.xsd"
+	qtiMatchCorrect   = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	imsCPNamespace    = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	qtiManifestFile   = "imsmanifest.xml"
+	qtiTestFile       = "test.xml"
+
+	// Hotspot coordinates are fractions of the image, QTI wants pixels of the object:
+	// the image is declared with this size and the coordinates scaled to it.
+	qtiHotspotSize = 1000
+)
+
+type qtiItem struct {
+	XMLName        xml.Name                 `xml:"assessmentItem"`
+	Xmlns          string                   `xml:"xmlns,attr"`
+	XmlnsXsi       string                   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string                   `xml:"xsi:schemaLocation,attr"`
+	Identifier     string                   `xml:"identifier,attr"`
+	Title          string                   `xml:"title,attr"`
+	Adaptive       bool                     `xml:"adaptive,attr"`
+	TimeDependent  bool                     `xml:"timeDependent,attr"`
+	Responses      []qtiResponseDeclaration `xml:"responseDeclaration"`
+	Outcome        qtiOutcomeDeclaration    `xml:"outcomeDeclaration"`
+	Body           qtiInnerXML              `xml:"itemBody"`
+	Processing     *qtiResponseProcessing   `xml:"responseProcessing"`
+}
+
+type qtiInnerXML struct {
+	Content string `xml:",innerxml"`
+}
+
+type qtiResponseDeclaration struct {
+	Identifier  string      `xml:"identifier,attr"`
+	Cardinality string      `xml:"cardinality,attr"`
+	BaseType    string      `xml:"baseType,attr"`
+	Correct     *qtiValues  `xml:"correctResponse"`
+	Mapping     *qtiMapping `xml:"mapping"`
+}
+
+type qtiValues struct {
+	Values []string `xml:"value"`
+}
+
+type qtiMapping struct {
+	DefaultValue float32       `xml:"defaultValue,attr"`
+	Entries      []qtiMapEntry `xml:"mapEntry"`
+}
+
+type qtiMapEntry struct {
+	Key           string  `xml:"mapKey,attr"`
+	Value         float32 `xml:"mappedValue,attr"`
+	CaseSensitive bool    `xml:"caseSensitive,attr"`
+}
+
+type qtiOutcomeDeclaration struct {
+	Identifier    string    `xml:"identifier,attr"`
+	Cardinality   string    `xml:"cardinality,attr"`
+	BaseType      string    `xml:"baseType,attr"`
+	NormalMaximum float32   `xml:"normalMaximum,attr,omitempty"`
+	Default       qtiValues `xml:"defaultValue"`
+}
+
+type qtiResponseProcessing struct {
+	Template string `xml:"template,attr,omitempty"`
+	Content  string `xml:",innerxml"`
+}
+
+// Manifest of the content package.
+type qtiManifest struct {
+	XMLName    xml.Name      `xml:"manifest"`
+	Xmlns      string        `xml:"xmlns,attr"`
+	Identifier string        `xml:"identifier,attr"`
+	Resources  []qtiResource `xml:"resources>resource"`
+}
+
+type qtiResource struct {
+	Identifier   string          `xml:"identifier,attr"`
+	Type         string          `xml:"type,attr"`
+	Href         string          `xml:"href,attr"`
+	Files        []qtiFile       `xml:"file"`
+	Dependencies []qtiDependency `xml:"dependency"`
+}
+
+type qtiFile struct {
+	Href string `xml:"href,attr"`
+}
+
+type qtiDependency struct {
+	IdentifierRef string `xml:"identifierref,attr"`
+}
+
+type qtiTest struct {
+	XMLName        xml.Name       `xml:"assessmentTest"`
+	Xmlns          string         `xml:"xmlns,attr"`
+	XmlnsXsi       string         `xml:"xmlns:xsi,attr"`
+	SchemaLocation string         `xml:"xsi:schemaLocation,attr"`
+	Identifier     string         `xml:"identifier,attr"`
+	Title          string         `xml:"title,attr"`
+	TimeLimits     *qtiTimeLimits `xml:"timeLimits"`
+	Parts          []qtiTestPart  `xml:"testPart"`
+}
+
+type qtiTimeLimits struct {
+	MaxTime int `xml:"maxTime,attr"` // Seconds
+}
+
+type qtiTestPart struct {
+	Identifier     string         `xml:"identifier,attr"`
+	NavigationMode string         `xml:"navigationMode,attr"`
+	SubmissionMode string         `xml:"submissionMode,attr"`
+	TimeLimits     *qtiTimeLimits `xml:"timeLimits"`
+	Section        qtiSection     `xml:"assessmentSection"`
+}
+
+type qtiSection struct {
+	Identifier string       `xml:"identifier,attr"`
+	Title      string       `xml:"title,attr"`
+	Visible    bool         `xml:"visible,attr"`
+	Rubric     *qtiRubric   `xml:"rubricBlock"`
+	ItemRefs   []qtiItemRef `xml:"assessmentItemRef"`
+}
+
+type qtiRubric struct {
+	View    string `xml:"view,attr"`
+	Content string `xml:",innerxml"`
+}
+
+type qtiItemRef struct {
+	Identifier string `xml:"identifier,attr"`
+	Href       string `xml:"href,attr"`
+}
+
+// writeQTIPackage writes the content as a QTI 2.1 zip package.
+func writeQTIPackage(content *exportContent) ([]byte, error) {
+	mediaPaths := make(map[string]string, len(content.media))
+	for _, m := range content.media {
+		mediaPaths[m.Ref] = m.Path
+	}
+
+	files := make(map[string][]byte, len(content.questions)+len(content.files)+2)
+	manifest := qtiManifest{Xmlns: imsCPNamespace, Identifier: "MANIFEST-" + primitive.NewObjectID().Hex()}
+	var itemRefs []qtiItemRef
+	var dependencies []qtiDependency
+	for i, question := range content.questions {
+		identifier := qtiID("Q", question.ID, i)
+		href := identifier + ".xml"
+		item, media := qtiAssessmentItem(identifier, question, mediaPaths)
+		data, err := marshalQTI(item)
+		if err != nil {
+			return nil, err
+		}
+		files[href] = data
+
+		resource := qtiResource{Identifier: identifier, Type: "imsqti_item_xmlv2p1", Href: href, Files: []qtiFile{{Href: href}}}
+		for _, m := range media {
+			resource.Files = append(resource.Files, qtiFile{Href: m})
+		}
+		manifest.Resources = append(manifest.Resources, resource)
+		itemRefs = append(itemRefs, qtiItemRef{Identifier: identifier, Href: href})
+		dependencies = append(dependencies, qtiDependency{IdentifierRef: identifier})
+	}
+
+	first := []string{qtiManifestFile}
+	if content.test != nil {
+		test := qtiAssessmentTest(content.test, itemRefs)
+		data, err := marshalQTI(test)
+		if err != nil {
+			return nil, err
+		}
+		files[qtiTestFile] = data
+		manifest.Resources = append([]qtiResource{{
+			Identifier:   test.Identifier,
+			Type:         "imsqti_test_xmlv2p1",
+			Href:         qtiTestFile,
+			Files:        []qtiFile{{Href: qtiTestFile}},
+			Dependencies: dependencies,
+		}}, manifest.Resources...)
+		first = append(first, qtiTestFile)
+	}
+
+	data, err := marshalQTI(manifest)
+	if err != nil {
+		return nil, err
+	}
+	files[qtiManifestFile] = data
+	for name, media := range content.files {
+		files[name] = media
+	}
+	return writeZip(files, first)
+}
+
+func marshalQTI(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode qti: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// qtiAssessmentTest builds the test with one testPart per section, carrying the section's
+// navigation mode, time limit and instructions. Unlock scores have no QTI equivalent and are left out.
+func qtiAssessmentTest(test *entity.Test, itemRefs []qtiItemRef) qtiTest {
+	qt := qtiTest{
+		Xmlns:          qtiNamespace,
+		XmlnsXsi:       "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: qtiSchemaLocation,
+		Identifier:     qtiID("T", test.ID, 0),
+		Title:          test.TestName,
+	}
+	if test.DurationMinutes > 0 {
+		qt.TimeLimits = &qtiTimeLimits{MaxTime: test.DurationMinutes * 60}
+	}
+	if len(test.Sections) == 0 {
+		qt.Parts = []qtiTestPart{{
+			Identifier:     "PART1",
+			NavigationMode: "nonlinear",
+			SubmissionMode: "simultaneous",
+			Section:        qtiSection{Identifier: "SECTION1", Title: test.TestName, Visible: true, ItemRefs: itemRefs},
+		}}
+		return qt
+	}
+
+	refs := make(map[string]qtiItemRef, len(itemRefs))
+	for _, ref := range itemRefs {
+		refs[ref.Identifier] = ref
+	}
+	for i, section := range test.Sections {
+		part := qtiTestPart{
+			Identifier:     "PART" + strconv.Itoa(i+1),
+			NavigationMode: "nonlinear",
+			SubmissionMode: "simultaneous",
+			Section:        qtiSection{Identifier: qtiID("S", section.ID, i), Title: section.Title, Visible: true},
+		}
+		if section.EffectiveNavigation() == entity.NavigationLinear {
+			part.NavigationMode = "linear"
+			part.SubmissionMode = "individual"
+		}
+		if section.DurationMinutes > 0 {
+			part.TimeLimits = &qtiTimeLimits{MaxTime: section.DurationMinutes * 60}
+		}
+		if section.Instructions != "" {
+			part.Section.Rubric = &qtiRubric{View: "candidate", Content: "<p>" + qtiEscape(section.Instructions) + "</p>"}
+		}
+		for j, id := range section.QuestionIDs {
+			if ref, ok := refs[qtiID("Q", id, j)]; ok {
+				part.Section.ItemRefs = append(part.Section.ItemRefs, ref)
+			}
+		}
+		qt.Parts = append(qt.Parts, part)
+	}
+	return qt
+}
+
+// qtiAssessmentItem converts a question. It also returns the package paths of the media it uses.
+func qtiAssessmentItem(identifier string, question entity.Question, mediaPaths map[string]string) (qtiItem, []string) {
+	b := &qtiBody{mediaPaths: mediaPaths}
+	item := qtiItem{
+		Xmlns:          qtiNamespace,
+		XmlnsXsi:       "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: qtiSchemaLocation,
+		Identifier:     identifier,
+		Title:          qtiTitle(question.QuestionContent.Text),
+		Outcome: qtiOutcomeDeclaration{
+			Identifier:    "SCORE",
+			Cardinality:   "single",
+			BaseType:      "float",
+			NormalMaximum: question.Score,
+			Default:       qtiValues{Values: []string{"0"}},
+		},
+	}
+
+	typeName := question.Type
+	if qt, ok := LookupQuestionType(question.Type); ok {
+		typeName = qt.Name
+	}
+	// Fill-in-the-blank text goes around the blanks, the others have the text as a prompt
+	if typeName != entity.QuestionTypeFillInTheBlank {
+		b.paragraph(question.QuestionContent.Text)
+	}
+	if question.QuestionContent.ImageURL != "" && typeName != entity.QuestionTypeHotspot {
+		b.raw("<p>" + b.img(question.QuestionContent.ImageURL) + "</p>")
+	}
+	b.mediaObject(question.QuestionContent.AudioURL, "audio/mpeg")
+	b.mediaObject(question.QuestionContent.VideoURL, "video/mp4")
+
+	switch typeName {
+	case entity.QuestionTypeSingleChoice, entity.QuestionTypeMultipleChoice:
+		cardinality, maxChoices := "single", 1
+		if typeName == entity.QuestionTypeMultipleChoice {
+			cardinality, maxChoices = "multiple", 0
+		}
+		var correct []string
+		b.open("choiceInteraction", "responseIdentifier", "RESPONSE", "shuffle", "true", "maxChoices", strconv.Itoa(maxChoices))
+		for i, option := range question.Options {
+			id := qtiID("C", option.ID, i)
+			text := qtiEscape(option.Text)
+			if option.ImageURL != "" {
+				text += b.img(option.ImageURL)
+			}
+			b.element("simpleChoice", text, "identifier", id)
+			if option.IsCorrect {
+				correct = append(correct, id)
+			}
+		}
+		b.close("choiceInteraction")
+		item.Responses = []qtiResponseDeclaration{{Identifier: "RESPONSE", Cardinality: cardinality, BaseType: "identifier", Correct: &qtiValues{correct}}}
+		item.Processing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+
+	case entity.QuestionTypeTrueFalse:
+		b.open("choiceInteraction", "responseIdentifier", "RESPONSE", "shuffle", "false", "maxChoices", "1")
+		b.element("simpleChoice", "True", "identifier", "TRUE")
+		b.element("simpleChoice", "False", "identifier", "FALSE")
+		b.close("choiceInteraction")
+		response := qtiResponseDeclaration{Identifier: "RESPONSE", Cardinality: "single", BaseType: "identifier"}
+		if question.TrueFalse != nil {
+			value := "FALSE"
+			if *question.TrueFalse {
+				value = "TRUE"
+			}
+			response.Correct = &qtiValues{[]string{value}}
+		}
+		item.Responses = []qtiResponseDeclaration{response}
+		item.Processing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+
+	case entity.QuestionTypeOrder:
+		b.open("orderInteraction", "responseIdentifier", "RESPONSE", "shuffle", "true")
+		positions := make([]int, len(question.OrderItems))
+		for i, orderItem := range question.OrderItems {
+			b.element("simpleChoice", qtiEscape(orderItem.Text), "identifier", qtiID("C", orderItem.ID, i))
+			positions[i] = i
+		}
+		b.close("orderInteraction")
+		sort.SliceStable(positions, func(i, j int) bool {
+			return question.OrderItems[positions[i]].Order < question.OrderItems[positions[j]].Order
+		})
+		correct := make([]string, len(positions))
+		for i, position := range positions {
+			correct[i] = qtiID("C", question.OrderItems[position].ID, position)
+		}
+		item.Responses = []qtiResponseDeclaration{{Identifier: "RESPONSE", Cardinality: "ordered", BaseType: "identifier", Correct: &qtiValues{correct}}}
+		item.Processing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+
+	case entity.QuestionTypeMatchChoice:
+		var correct []string
+		itemIDs := make(map[string]string, len(question.MatchItems))
+		b.open("matchInteraction", "responseIdentifier", "RESPONSE", "shuffle", "true", "maxAssociations", strconv.Itoa(len(question.MatchItems)))
+		b.open("simpleMatchSet")
+		for i, matchItem := range question.MatchItems {
+			id := qtiID("I", matchItem.ID, i)
+			itemIDs[matchItem.ID.Hex()] = id
+			b.element("simpleAssociableChoice", qtiEscape(matchItem.Text), "identifier", id, "matchMax", "1")
+		}
+		b.close("simpleMatchSet")
+		b.open("simpleMatchSet")
+		for i, option := range question.MatchOptions {
+			id := qtiID("O", option.ID, i)
+			b.element("simpleAssociableChoice", qtiEscape(option.Text), "identifier", id, "matchMax", "1")
+			if itemID, ok := itemIDs[option.MatchId]; ok {
+				correct = append(correct, itemID+" "+id)
+			}
+		}
+		b.close("simpleMatchSet")
+		b.close("matchInteraction")
+		item.Responses = []qtiResponseDeclaration{{Identifier: "RESPONSE", Cardinality: "multiple", BaseType: "directedPair", Correct: &qtiValues{correct}}}
+		item.Processing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+
+	case entity.QuestionTypeMatrix:
+		if question.Matrix == nil {
+			break
+		}
+		var correct []string
+		columnIDs := make(map[primitive.ObjectID]string, len(question.Matrix.Columns))
+		b.open("matchInteraction", "responseIdentifier", "RESPONSE", "shuffle", "false", "maxAssociations", strconv.Itoa(len(question.Matrix.Rows)))
+		b.open("simpleMatchSet")
+		for i, row := range question.Matrix.Rows {
+			b.element("simpleAssociableChoice", qtiEscape(row.Text), "identifier", qtiID("R", row.ID, i), "matchMax", "1")
+		}
+		b.close("simpleMatchSet")
+		b.open("simpleMatchSet")
+		for i, column := range question.Matrix.Columns {
+			id := qtiID("K", column.ID, i)
+			columnIDs[column.ID] = id
+			b.element("simpleAssociableChoice", qtiEscape(column.Text), "identifier", id, "matchMax", strconv.Itoa(len(question.Matrix.Rows)))
+		}
+		b.close("simpleMatchSet")
+		b.close("matchInteraction")
+		for i, row := range question.Matrix.Rows {
+			if columnID, ok := columnIDs[row.CorrectColumn]; ok && !row.CorrectColumn.IsZero() {
+				correct = append(correct, qtiID("R", row.ID, i)+" "+columnID)
+			}
+		}
+		response := qtiResponseDeclaration{Identifier: "RESPONSE", Cardinality: "multiple", BaseType: "directedPair"}
+		// Likert scales have no correct answer
+		if len(correct) > 0 {
+			response.Correct = &qtiValues{correct}
+			item.Processing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+		}
+		item.Responses = []qtiResponseDeclaration{response}
+
+	case entity.QuestionTypeHotspot:
+		var correct []string
+		b.open("hotspotInteraction", "responseIdentifier", "RESPONSE", "maxChoices", "0")
+		b.raw(fmt.Sprintf(`<object data="%s" type="%s" width="%d" height="%d"/>`,
+			qtiEscape(b.media(question.QuestionContent.ImageURL)), qtiMediaType(question.QuestionContent.ImageURL, "image/png"), qtiHotspotSize, qtiHotspotSize))
+		for i, hotspot := range question.Hotspots {
+			id := qtiID("H", hotspot.ID, i)
+			shape, coords := qtiHotspotCoords(hotspot)
+			b.element("hotspotChoice", "", "identifier", id, "shape", shape, "coords", coords)
+			if hotspot.IsCorrect {
+				correct = append(correct, id)
+			}
+		}
+		b.close("hotspotInteraction")
+		item.Responses = []qtiResponseDeclaration{{Identifier: "RESPONSE", Cardinality: "multiple", BaseType: "identifier", Correct: &qtiValues{correct}}}
+		item.Processing = &qtiResponseProcessing{Template: qtiMatchCorrect}
+
+	case entity.QuestionTypeFillInTheBlank:
+		// One textEntryInteraction per blank, every accepted answer maps to one point
+		var sum strings.Builder
+		b.raw("<p>")
+		for i, blank := range question.FillInTheBlanks {
+			id := "RESPONSE_" + strconv.Itoa(i+1)
+			b.raw(qtiEscape(blank.TextBefore) + `<textEntryInteraction responseIdentifier="` + id + `"/>` + qtiEscape(blank.TextAfter))
+			response := qtiResponseDeclaration{Identifier: id, Cardinality: "single", BaseType: "string", Mapping: &qtiMapping{}}
+			caseSensitive := blank.Matching == nil || !blank.Matching.CaseInsensitive
+			for _, value := range blank.AcceptedValues() {
+				if response.Correct == nil {
+					response.Correct = &qtiValues{[]string{value}}
+				}
+				response.Mapping.Entries = append(response.Mapping.Entries, qtiMapEntry{Key: value, Value: 1, CaseSensitive: caseSensitive})
+			}
+			item.Responses = append(item.Responses, response)
+			sum.WriteString(`<mapResponse identifier="` + id + `"/>`)
+		}
+		b.raw("</p>")
+		if len(question.FillInTheBlanks) > 0 {
+			item.Processing = &qtiResponseProcessing{Content: `<setOutcomeValue identifier="SCORE"><sum>` + sum.String() + `</sum></setOutcomeValue>`}
+		}
+
+	case entity.QuestionTypeNumeric:
+		b.raw(`<p><textEntryInteraction responseIdentifier="RESPONSE"/></p>`)
+		response := qtiResponseDeclaration{Identifier: "RESPONSE", Cardinality: "single", BaseType: "float"}
+		if question.Numeric != nil {
+			value := strconv.FormatFloat(question.Numeric.Value, 'f', -1, 64)
+			tolerance := strconv.FormatFloat(question.Numeric.Tolerance, 'f', -1, 64)
+			response.Correct = &qtiValues{[]string{value}}
+			item.Processing = &qtiResponseProcessing{Content: `<responseCondition><responseIf>` +
+				`<equal toleranceMode="absolute" tolerance="` + tolerance + " " + tolerance + `"><variable identifier="RESPONSE"/><correct identifier="RESPONSE"/></equal>` +
+				`<setOutcomeValue identifier="SCORE"><baseValue baseType="float">1</baseValue></setOutcomeValue>` +
+				`</responseIf></responseCondition>`}
+		}
+		item.Responses = []qtiResponseDeclaration{response}
+
+	default:
+		// Essay, short answer and unknown types are scored by a person
+		b.raw(`<extendedTextInteraction responseIdentifier="RESPONSE"/>`)
+		item.Responses = []qtiResponseDeclaration{{Identifier: "RESPONSE", Cardinality: "single", BaseType: "string"}}
+	}
+
+	item.Body = qtiInnerXML{Content: b.String()}
+	return item, b.used
+}
+
+// qtiBody builds the XML of an itemBody.
+type qtiBody struct {
+	strings.Builder
+	mediaPaths map[string]string
+	used       []string // Package paths of the media referenced so far
+}
+
+func (b *qtiBody) raw(s string) {
+	b.WriteString(s)
+}
+
+func (b *qtiBody) paragraph(text string) {
+	if text != "" {
+		b.raw("<p>" + qtiEscape(text) + "</p>")
+	}
+}
+
+func (b *qtiBody) open(name string, attrs ...string) {
+	b.raw("<" + name + qtiAttrs(attrs) + ">")
+}
+
+func (b *qtiBody) close(name string) {
+	b.raw("</" + name + ">")
+}
+
+// element writes an element with content, content must already be escaped.
+func (b *qtiBody) element(name, content string, attrs ...string) {
+	if content == "" {
+		b.raw("<" + name + qtiAttrs(attrs) + "/>")
+		return
+	}
+	b.raw("<" + name + qtiAttrs(attrs) + ">" + content + "</" + name + ">")
+}
+
+func (b *qtiBody) img(ref string) string {
+	return `<img src="` + qtiEscape(b.media(ref)) + `" alt=""/>`
+}
+
+func (b *qtiBody) mediaObject(ref, defaultType string) {
+	if ref != "" {
+		b.raw(`<p><object data="` + qtiEscape(b.media(ref)) + `" type="` + qtiMediaType(ref, defaultType) + `"/></p>`)
+	}
+}
+
+// media returns the path of the packaged copy of ref, or ref itself when it was not packaged.
+func (b *qtiBody) media(ref string) string {
+	if p, ok := b.mediaPaths[ref]; ok {
+		if !slices.Contains(b.used, p) {
+			b.used = append(b.used, p)
+		}
+		return p
+	}
+	return ref
+}
+
+func qtiAttrs(attrs []string) string {
+	var s strings.Builder
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.WriteString(" " + attrs[i] + `="` + qtiEscape(attrs[i+1]) + `"`)
+	}
+	return s.String()
+}
+
+func qtiEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// qtiID builds an identifier from the ObjectID, falling back to the position for parts without one.
+// QTI identifiers cannot start with a digit, hence the prefix.
+func qtiID(prefix string, id primitive.ObjectID, index int) string {
+	if id.IsZero() {
+		return prefix + strconv.Itoa(index+1)
+	}
+	return prefix + id.Hex()
+}
+
+// qtiTitle shortens the question text to a one line title.
+func qtiTitle(text string) string {
+	title := strings.Join(strings.Fields(text), " ")
+	if runes := []rune(title); len(runes) > 80 {
+		title = string(runes[:77]) + "..."
+	}
+	return title
+}
+
+func qtiMediaType(ref, defaultType string) string {
+	if t, _, err := mime.ParseMediaType(mime.TypeByExtension(path.Ext(mediaFilename(ref)))); err == nil {
+		return t
+	}
+	return defaultType
+}
+
+// qtiHotspotCoords converts a hotspot to QTI shape coordinates on the qtiHotspotSize image.
+func qtiHotspotCoords(h entity.Hotspot) (string, string) {
+	scale := func(v float64) string {
+		return strconv.Itoa(int(v*qtiHotspotSize + 0.5))
+	}
+	if h.Shape == entity.HotspotCircle {
+		return "circle", scale(h.X) + "," + scale(h.Y) + "," + scale(h.Radius)
+	}
+	return "rect", scale(h.X) + "," + scale(h.Y) + "," + scale(h.X+h.Width) + "," + scale(h.Y+h.Height)
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// In-memory repositories for the use case tests. Methods a test does not need are left to
+// the embedded interface and panic when called.
+
+type fakeAnswerRepo struct {
+	repository.AnswerRepository
+	answers []entity.TestAnswer
+	// beforeCreate runs before CreateAnswer stores the answer; an error aborts the insert.
+	beforeCreate func(entity.TestAnswer) error
+	// beforeGrade runs before UpdateGradedAnswer compares the grade versions.
+	beforeGrade func()
+}
+
+func (r *fakeAnswerRepo) CreateAnswer(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error) {
+	if r.beforeCreate != nil {
+		if err := r.beforeCreate(answer); err != nil {
+			return nil, err
+		}
+	}
+	r.answers = append(r.answers, answer)
+	return &answer, nil
+}
+
+func (r *fakeAnswerRepo) UpdateAnswer(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error) {
+	for i := range r.answers {
+		if r.answers[i].ID == answer.ID {
+			r.answers[i] = answer
+			return &answer, nil
+		}
+	}
+	return nil, errors.New("answer not found")
+}
+
+func (r *fakeAnswerRepo) UpdateOpenAnswer(ctx context.Context, answer entity.TestAnswer) (bool, error) {
+	for i := range r.answers {
+		if r.answers[i].ID == answer.ID {
+			if !r.answers[i].IsOpen() {
+				return false, nil
+			}
+			r.answers[i] = answer
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAnswerRepo) UpdateGradedAnswer(ctx context.Context, answer entity.TestAnswer) (bool, error) {
+	if r.beforeGrade != nil {
+		r.beforeGrade()
+	}
+	for i := range r.answers {
+		if r.answers[i].ID == answer.ID {
+			if r.answers[i].GradeVersion != answer.GradeVersion {
+				return false, nil
+			}
+			answer.GradeVersion++
+			r.answers[i] = answer
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAnswerRepo) GetAnswer(ctx context.Context, filter bson.M) (entity.TestAnswer, error) {
+	for _, answer := range r.answers {
+		if answerMatches(answer, filter) {
+			return answer, nil
+		}
+	}
+	return entity.TestAnswer{}, errors.New("answer not found")
+}
+
+func (r *fakeAnswerRepo) GetAllAnswer(ctx context.Context, filter bson.M) ([]entity.TestAnswer, error) {
+	var answers []entity.TestAnswer
+	for _, answer := range r.answers {
+		if answerMatches(answer, filter) {
+			answers = append(answers, answer)
+		}
+	}
+	return answers, nil
+}
+
+// answerMatches supports the equality filters used by the use cases.
+func answerMatches(answer entity.TestAnswer, filter bson.M) bool {
+	for key, value := range filter {
+		var field any
+		switch key {
+		case "_id":
+			field = answer.ID
+		case "class_id":
+			field = answer.ClassID
+		case "grading_pending":
+			field = answer.GradingPending
+		case "test_id":
+			field = answer.TestId
+		case "email_id":
+			field = answer.EmailID
+		case "email":
+			field = answer.Email
+		case "status":
+			field = answer.Status
+		default:
+			panic(fmt.Sprintf("fakeAnswerRepo: unsupported filter %q", key))
+		}
+		if field != value {
+			return false
+		}
+	}
+	return true
+}
+
+type fakeClassRepo struct {
+	repository.ClassRepository
+	classes map[primitive.ObjectID]*entity.Class
+	saveErr error // Returned by SaveMember when set
+}
+
+func newFakeClassRepo(classes ...*entity.Class) *fakeClassRepo {
+	r := &fakeClassRepo{classes: make(map[primitive.ObjectID]*entity.Class)}
+	for _, class := range classes {
+		r.classes[class.ID] = class
+	}
+	return r
+}
+
+func (r *fakeClassRepo) GetClassByID(ctx context.Context, id primitive.ObjectID) (*entity.Class, error) {
+	class, ok := r.classes[id]
+	if !ok {
+		return nil, errors.New("no class found with the given ID")
+	}
+	copied := *class
+	return &copied, nil
+}
+
+func (r *fakeClassRepo) SaveMember(ctx context.Context, classID primitive.ObjectID, member entity.ClassMember) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	class, ok := r.classes[classID]
+	if !ok {
+		return errors.New("no class found with the given ID")
+	}
+	class.Members = slices.DeleteFunc(slices.Clone(class.Members), func(m entity.ClassMember) bool {
+		return entity.NormalizeEmail(m.Email) == entity.NormalizeEmail(member.Email)
+	})
+	class.Members = append(class.Members, member)
+	return nil
+}
+
+func (r *fakeClassRepo) SetBlindGrading(ctx context.Context, classID, testID primitive.ObjectID, blind bool) error {
+	class, ok := r.classes[classID]
+	if !ok {
+		return errors.New("no class found with the given ID")
+	}
+	for i := range class.Test {
+		if class.Test[i].ID == testID {
+			class.Test[i].BlindGrading = blind
+			return nil
+		}
+	}
+	return errors.New("no matching test found")
+}
+
+func (r *fakeClassRepo) GetAllTestOfClass(ctx context.Context, email string, id primitive.ObjectID) ([]any, error) {
+	var tests []any
+	for _, test := range r.classes[id].Test {
+		tests = append(tests, test)
+	}
+	return tests, nil
+}
+
+// fakeGrantRepo holds no grants.
+type fakeGrantRepo struct {
+	repository.GrantRepository
+}
+
+func (fakeGrantRepo) GetGrant(ctx context.Context, resourceType string, resourceID primitive.ObjectID, email string) (*entity.Grant, error) {
+	return nil, nil
+}
+
+type fakeJoinCodeRepo struct {
+	repository.JoinCodeRepository
+	codes []*entity.JoinCode
+	uses  []entity.JoinCodeUse
+}
+
+func (r *fakeJoinCodeRepo) GetJoinCode(ctx context.Context, code string) (*entity.JoinCode, error) {
+	for _, c := range r.codes {
+		if c.Code == code {
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeJoinCodeRepo) UseJoinCode(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	for _, c := range r.codes {
+		if c.ID == id {
+			if c.Status(at) != entity.JoinCodeActive {
+				return false, nil
+			}
+			c.Uses++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeJoinCodeRepo) ReleaseJoinCodeUse(ctx context.Context, id primitive.ObjectID) error {
+	for _, c := range r.codes {
+		if c.ID == id && c.Uses > 0 {
+			c.Uses--
+		}
+	}
+	return nil
+}
+
+func (r *fakeJoinCodeRepo) AddJoinCodeUse(ctx context.Context, use entity.JoinCodeUse) error {
+	r.uses = append(r.uses, use)
+	return nil
+}
+
+type fakeQuestionRepo struct {
+	repository.QuestionRepository
+	questions map[primitive.ObjectID]entity.Question
+}
+
+func newFakeQuestionRepo(questions ...entity.Question) *fakeQuestionRepo {
+	r := &fakeQuestionRepo{questions: make(map[primitive.ObjectID]entity.Question)}
+	for _, question := range questions {
+		r.questions[question.ID] = question
+	}
+	return r
+}
+
+func (r *fakeQuestionRepo) GetQuestionsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]entity.Question, error) {
+	var questions []entity.Question
+	for _, id := range ids {
+		if question, ok := r.questions[id]; ok {
+			questions = append(questions, question)
+		}
+	}
+	return questions, nil
+}
+
+func (r *fakeQuestionRepo) UpdateQuestion(ctx context.Context, question *entity.Question) (any, error) {
+	r.questions[question.ID] = *question
+	return question, nil
+}
+
+func (r *fakeQuestionRepo) DeleteQuestion(ctx context.Context, question *entity.Question) error {
+	delete(r.questions, question.ID)
+	return nil
+}
+
+type fakeVersionRepo struct {
+	repository.QuestionVersionRepository
+	versions []entity.QuestionVersion
+}
+
+func (r *fakeVersionRepo) CreateVersion(ctx context.Context, version entity.QuestionVersion) error {
+	for _, v := range r.versions {
+		if v.QuestionID == version.QuestionID && v.Version == version.Version {
+			return repository.ErrDuplicateKey
+		}
+	}
+	r.versions = append(r.versions, version)
+	return nil
+}
+
+func (r *fakeVersionRepo) GetVersions(ctx context.Context, refs []entity.QuestionVersionRef) ([]entity.QuestionVersion, error) {
+	var versions []entity.QuestionVersion
+	for _, v := range r.versions {
+		if slices.Contains(refs, entity.QuestionVersionRef{QuestionID: v.QuestionID, Version: v.Version}) {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// answerTestEnv is a class with one graded test of one single choice question,
+// taken by one active student. The answers of the test are not released yet.
+type answerTestEnv struct {
+	uc        *AnswerUseCase
+	answers   *fakeAnswerRepo
+	classes   *fakeClassRepo
+	questions *fakeQuestionRepo
+	versions  *fakeVersionRepo
+
+	class    *entity.Class
+	test     *entity.Test
+	question entity.Question
+}
+
+const (
+	testStudentID    = "student-id"
+	testStudentEmail = "student@school.edu"
+	testOwnerID      = "owner-id"
+	testOwnerEmail   = "owner@school.edu"
+)
+
+func newAnswerTestEnv() *answerTestEnv {
+	now := time.Now()
+	question := entity.Question{
+		ID:    primitive.NewObjectID(),
+		Type:  entity.QuestionTypeSingleChoice,
+		Score: 1,
+		Options: []entity.Option{
+			{ID: primitive.NewObjectID(), Text: "right", IsCorrect: true},
+			{ID: primitive.NewObjectID(), Text: "wrong"},
+		},
+		Metadata: entity.Metadata{Author: testOwnerID},
+	}
+	test := entity.Test{
+		ID:              primitive.NewObjectID(),
+		QuestionIDs:     []primitive.ObjectID{question.ID},
+		IsTest:          true,
+		StartTime:       now.Add(-3 * time.Hour).Format(time.RFC3339),
+		EndTime:         now.Add(3 * time.Hour).Format(time.RFC3339),
+		DurationMinutes: 30,
+		ReviewPolicy:    entity.ReviewAfterEnd,
+		EmailID:         testOwnerID,
+	}
+	class := &entity.Class{
+		ID:         primitive.NewObjectID(),
+		EmailID:    testOwnerID,
+		AuthorMail: testOwnerEmail,
+		Test:       []entity.Test{test},
+		Members: []entity.ClassMember{
+			{Email: testStudentEmail, Role: entity.RoleStudent, Status: entity.MemberActive},
+		},
+	}
+
+	env := &answerTestEnv{
+		answers:   &fakeAnswerRepo{},
+		classes:   newFakeClassRepo(class),
+		questions: newFakeQuestionRepo(question),
+		versions:  &fakeVersionRepo{},
+		class:     class,
+		test:      &class.Test[0],
+		question:  question,
+	}
+	authz := NewAuthorizationService(env.classes, nil, env.questions, fakeGrantRepo{})
+	env.uc = NewAnswerUseCase(env.answers, env.questions, env.versions, env.classes, authz)
+	return env
+}
+
+// attempt returns an attempt of the student that answered the question right and was graded.
+func (env *answerTestEnv) attempt(number int, status string, startedAt time.Time) entity.TestAnswer {
+	attempt := entity.TestAnswer{
+		ID:            primitive.NewObjectID(),
+		TestId:        env.test.ID,
+		ClassID:       env.class.ID,
+		EmailID:       testStudentID,
+		Email:         testStudentEmail,
+		AttemptNumber: number,
+		Status:        status,
+		StartTime:     startedAt,
+		ListQuestionAnswer: []entity.QuestionAnswer{{
+			QuestionID:  env.question.ID,
+			Options:     []entity.OptionAnswer{{ID: env.question.Options[0].ID}},
+			Score:       1,
+			GradeStatus: entity.GradeGraded,
+			Comment:     "well done",
+			GradedBy:    testOwnerEmail,
+			GradedAt:    startedAt,
+		}},
+		TotalScore:       1,
+		MaxScore:         1,
+		QuestionVersions: []entity.QuestionVersionRef{{QuestionID: env.question.ID, Version: 1}},
+		GradeLog: []entity.GradeChange{
+			{QuestionID: env.question.ID, Score: 1, Comment: "well done", GradedBy: testOwnerEmail, GradedAt: startedAt},
+		},
+	}
+	if status != entity.AttemptInProgress {
+		attempt.EndTime = startedAt.Add(10 * time.Minute)
+	}
+	attempt.Deadline = attempt.ComputeDeadline(*env.test)
+	return attempt
+}
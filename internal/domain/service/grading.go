@@ -0,0 +1,171 @@
+package service
+
+import (
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuestionGrade is the server-side result for a single question.
+type QuestionGrade struct {
+	QuestionID primitive.ObjectID `json:"question_id"`
+	Type       string             `json:"type"`
+	Score      float32            `json:"score"`
+	MaxScore   float32            `json:"max_score"`
+	Answered   bool               `json:"answered"`
+	Pending    bool               `json:"pending,omitempty"` // Waiting for a teacher to grade it
+}
+
+// GradeResult holds the per-question grades and the totals of a TestAnswer.
+type GradeResult struct {
+	Questions  []QuestionGrade `json:"questions"`
+	TotalScore float32         `json:"total_score"`
+	MaxScore   float32         `json:"max_score"`
+	Pending    int             `json:"pending"` // Responses waiting for manual grading
+}
+
+// GradingService scores a TestAnswer against the stored questions of a test.
+type GradingService struct{}
+
+func NewGradingService() *GradingService {
+	return &GradingService{}
+}
+
+// Grade scores every question of the test. Unanswered questions count for zero,
+// answers to questions that are not part of the test are ignored.
+// Manually graded questions keep the teacher's score, or are queued as pending.
+// The per-question scores and the totals are written back onto the answer.
+func (gs *GradingService) Grade(answer *entity.TestAnswer, questions []entity.Question) GradeResult {
+	answersByQuestion := make(map[primitive.ObjectID]int, len(answer.ListQuestionAnswer))
+	for i, qa := range answer.ListQuestionAnswer {
+		if qa.GradeStatus != entity.GradeGraded {
+			answer.ListQuestionAnswer[i].Score = 0
+		}
+		answersByQuestion[qa.QuestionID] = i
+	}
+
+	result := GradeResult{Questions: make([]QuestionGrade, 0, len(questions))}
+	for _, question := range questions {
+		qt, known := LookupQuestionType(question.Type)
+		if known {
+			question.Type = qt.Name
+		}
+		grade := QuestionGrade{
+			QuestionID: question.ID,
+			Type:       question.Type,
+			MaxScore:   question.Score,
+		}
+
+		if idx, ok := answersByQuestion[question.ID]; ok {
+			qa := &answer.ListQuestionAnswer[idx]
+			grade.Answered = true
+			qa.Type = question.Type
+			if known && qt.ManualGrading {
+				if qa.GradeStatus != entity.GradeGraded {
+					qa.GradeStatus = entity.GradePending
+				}
+				grade.Pending = qa.GradeStatus == entity.GradePending
+				grade.Score = qa.Score
+			} else {
+				grade.Score = gs.GradeQuestion(question, *qa)
+				qa.Score = grade.Score
+			}
+		}
+
+		result.Questions = append(result.Questions, grade)
+		result.TotalScore += grade.Score
+		result.MaxScore += grade.MaxScore
+		if grade.Pending {
+			result.Pending++
+		}
+	}
+
+	answer.TotalScore = result.TotalScore
+	answer.MaxScore = result.MaxScore
+	answer.GradingPending = result.Pending > 0
+	return result
+}
+
+// GradeQuestion returns the points earned by a single response under the question's scoring policy.
+func (gs *GradingService) GradeQuestion(question entity.Question, response entity.QuestionAnswer) float32 {
+	qt, ok := LookupQuestionType(question.Type)
+	if !ok || qt.Grade == nil {
+		return 0
+	}
+	parts := qt.Grade(question, response)
+	return applyScoringPolicy(question.EffectiveScoringPolicy(qt.DefaultScoring), question.Score, parts)
+}
+
+// Part states produced by the per-type graders.
+const (
+	partRight = iota
+	partWrong
+	partBlank
+)
+
+// gradedPart is one scorable unit of a response: an option, a blank, a position or a pair.
+// Penalty-only parts (e.g. a wrongly ticked option) do not count towards the total,
+// they only take points away.
+type gradedPart struct {
+	state       int
+	weight      float32
+	penaltyOnly bool
+}
+
+// applyScoringPolicy turns the graded parts into points.
+func applyScoringPolicy(policy entity.ScoringPolicy, score float32, parts []gradedPart) float32 {
+	var right, wrong, extra, total, rightWeight, extraWeight, totalWeight float32
+	allRight := true
+	for _, part := range parts {
+		if part.state != partRight {
+			allRight = false
+		}
+		if part.state == partWrong {
+			wrong++
+		}
+		if part.penaltyOnly {
+			extra++
+			extraWeight += part.weight
+			continue
+		}
+		total++
+		totalWeight += part.weight
+		if part.state == partRight {
+			right++
+			rightWeight += part.weight
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	switch policy.Mode {
+	case entity.ScoringProportional:
+		// Ticking every option must not earn the full score
+		return score * max(right-extra, 0) / total
+	case entity.ScoringRightMinusWrong:
+		earned := score * (right - policy.Penalty*wrong) / total
+		if earned < 0 && !policy.AllowNegative {
+			return 0
+		}
+		return earned
+	case entity.ScoringWeighted:
+		if totalWeight == 0 {
+			return 0
+		}
+		return score * max(rightWeight-extraWeight, 0) / totalWeight
+	default:
+		if allRight {
+			return score
+		}
+		return 0
+	}
+}
+
+// partWeight defaults unset weights to 1.
+func partWeight(weight float32) float32 {
+	if weight <= 0 {
+		return 1
+	}
+	return weight
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+func TestApplyScoringPolicy(t *testing.T) {
+	right := gradedPart{state: partRight, weight: 1}
+	wrong := gradedPart{state: partWrong, weight: 1}
+	blank := gradedPart{state: partBlank, weight: 1}
+	// A wrongly ticked option: lowers the score but is not part of the total
+	extra := gradedPart{state: partWrong, weight: 1, penaltyOnly: true}
+
+	tests := []struct {
+		name   string
+		policy entity.ScoringPolicy
+		parts  []gradedPart
+		want   float32
+	}{
+		{"no parts", entity.ScoringPolicy{}, nil, 0},
+		{"all or nothing, all right", entity.ScoringPolicy{}, []gradedPart{right, right}, 4},
+		{"all or nothing, one blank", entity.ScoringPolicy{Mode: entity.ScoringAllOrNothing}, []gradedPart{right, blank}, 0},
+		{"all or nothing, extra option", entity.ScoringPolicy{}, []gradedPart{right, right, extra}, 0},
+		{"proportional", entity.ScoringPolicy{Mode: entity.ScoringProportional}, []gradedPart{right, wrong, blank, right}, 2},
+		{"proportional, penalty-only parts", entity.ScoringPolicy{Mode: entity.ScoringProportional}, []gradedPart{right, right, extra}, 2},
+		{"proportional, every option ticked", entity.ScoringPolicy{Mode: entity.ScoringProportional}, []gradedPart{right, right, extra, extra}, 0},
+		{"proportional, floored at zero", entity.ScoringPolicy{Mode: entity.ScoringProportional}, []gradedPart{right, blank, extra, extra}, 0},
+		{"right minus wrong", entity.ScoringPolicy{Mode: entity.ScoringRightMinusWrong, Penalty: 1}, []gradedPart{right, right, right, wrong}, 2},
+		{"right minus wrong, half penalty", entity.ScoringPolicy{Mode: entity.ScoringRightMinusWrong, Penalty: 0.5}, []gradedPart{right, wrong}, 1},
+		{"right minus wrong, blanks cost nothing", entity.ScoringPolicy{Mode: entity.ScoringRightMinusWrong, Penalty: 1}, []gradedPart{right, blank}, 2},
+		{"right minus wrong, penalty-only parts", entity.ScoringPolicy{Mode: entity.ScoringRightMinusWrong, Penalty: 1}, []gradedPart{right, right, extra}, 2},
+		{"right minus wrong, floored at zero", entity.ScoringPolicy{Mode: entity.ScoringRightMinusWrong, Penalty: 1}, []gradedPart{wrong, wrong}, 0},
+		{"right minus wrong, negative allowed", entity.ScoringPolicy{Mode: entity.ScoringRightMinusWrong, Penalty: 1, AllowNegative: true}, []gradedPart{wrong, blank}, -2},
+		{"weighted", entity.ScoringPolicy{Mode: entity.ScoringWeighted}, []gradedPart{{state: partRight, weight: 3}, {state: partWrong, weight: 1}}, 3},
+		{"weighted, penalty-only parts", entity.ScoringPolicy{Mode: entity.ScoringWeighted}, []gradedPart{{state: partRight, weight: 3}, {state: partRight, weight: 1}, extra}, 3},
+		{"weighted, every option ticked", entity.ScoringPolicy{Mode: entity.ScoringWeighted}, []gradedPart{{state: partRight, weight: 1}, {state: partRight, weight: 1}, extra, extra}, 0},
+		{"weighted, no weight", entity.ScoringPolicy{Mode: entity.ScoringWeighted}, []gradedPart{{state: partRight}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyScoringPolicy(tt.policy, 4, tt.parts)
+			if math.Abs(float64(got-tt.want)) > 1e-6 {
+				t.Errorf("applyScoringPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeMultipleChoiceCountsWrongTicks(t *testing.T) {
+	options := []entity.Option{
+		{ID: testObjectID(1), IsCorrect: true},
+		{ID: testObjectID(2), IsCorrect: true},
+		{ID: testObjectID(3)},
+		{ID: testObjectID(4)},
+	}
+	ticked := func(ids ...int) entity.QuestionAnswer {
+		var response entity.QuestionAnswer
+		for _, id := range ids {
+			response.Options = append(response.Options, entity.OptionAnswer{ID: testObjectID(id)})
+		}
+		return response
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		response entity.QuestionAnswer
+		want     float32
+	}{
+		{"proportional, right options", entity.ScoringProportional, ticked(1, 2), 4},
+		{"proportional, one right one wrong", entity.ScoringProportional, ticked(1, 3), 0},
+		{"proportional, two right one wrong", entity.ScoringProportional, ticked(1, 2, 3), 2},
+		{"proportional, every option", entity.ScoringProportional, ticked(1, 2, 3, 4), 0},
+		{"weighted, every option", entity.ScoringWeighted, ticked(1, 2, 3, 4), 0},
+	}
+	gs := NewGradingService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question := entity.Question{
+				Type:          entity.QuestionTypeMultipleChoice,
+				Score:         4,
+				Options:       options,
+				ScoringPolicy: &entity.ScoringPolicy{Mode: tt.mode},
+			}
+			if got := gs.GradeQuestion(question, tt.response); math.Abs(float64(got-tt.want)) > 1e-6 {
+				t.Errorf("GradeQuestion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
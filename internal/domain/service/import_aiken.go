@@ -0,0 +1,95 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+var (
+	aikenOption = regexp.MustCompile(`^([A-Za-z])[.)]\s+(.+)$`)
+	aikenAnswer = regexp.MustCompile(`^ANSWER:\s*([A-Za-z])\s*$`)
+)
+
+// Aiken import: single choice questions only.
+//
+//	What is 2 + 2?
+//	A. 3
+//	B. 4
+//	ANSWER: B
+//
+// The ANSWER line ends a question; blank lines between questions are optional.
+func parseAikenQuestions(r io.Reader) ([]parsedQuestion, []ImportError) {
+	var questions []parsedQuestion
+	var importErrors []ImportError
+
+	var text []string
+	var letters, options []string
+	start := 0
+	reset := func() {
+		text, letters, options, start = nil, nil, nil, 0
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if trimmed == "" {
+			continue
+		}
+		if start == 0 {
+			start = line
+		}
+
+		if m := aikenAnswer.FindStringSubmatch(trimmed); m != nil {
+			question, err := aikenQuestion(text, letters, options, strings.ToUpper(m[1]))
+			if err != nil {
+				importErrors = append(importErrors, ImportError{Line: start, Message: err.Error()})
+			} else {
+				questions = append(questions, parsedQuestion{Line: start, Question: question})
+			}
+			reset()
+			continue
+		}
+		// Text lines come before the first option; a question can span several lines
+		if m := aikenOption.FindStringSubmatch(trimmed); m != nil && len(text) > 0 {
+			letters = append(letters, strings.ToUpper(m[1]))
+			options = append(options, strings.TrimSpace(m[2]))
+			continue
+		}
+		if len(options) > 0 {
+			importErrors = append(importErrors, ImportError{Line: line, Message: "expected an option or the ANSWER line"})
+			continue
+		}
+		text = append(text, trimmed)
+	}
+	if start != 0 {
+		importErrors = append(importErrors, ImportError{Line: start, Message: "missing ANSWER line"})
+	}
+	if err := scanner.Err(); err != nil {
+		importErrors = append(importErrors, ImportError{Message: err.Error()})
+	}
+	return questions, importErrors
+}
+
+func aikenQuestion(text, letters, options []string, answer string) (entity.Question, error) {
+	if len(text) == 0 {
+		return entity.Question{}, fmt.Errorf("missing question text")
+	}
+	correct := make([]bool, len(options))
+	found := false
+	for i, letter := range letters {
+		if letter == answer {
+			correct[i] = true
+			found = true
+		}
+	}
+	if !found {
+		return entity.Question{}, fmt.Errorf("answer %s is not one of the options", answer)
+	}
+	return choiceQuestion(strings.Join(text, "\n"), options, correct), nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+// CSV import: one question per row after a header row. Columns, in any order:
+//
+//	type        question type name or alias (required)
+//	question    question text (required)
+//	options     "|"-separated: choices, order items in the correct order, or "item=match" pairs
+//	answer      choice numbers or letters ("1|3", "A"), true/false, a number, or the
+//	            accepted answers of each blank ("Paris|paris;Rome" for two "___" blanks)
+//	score, difficulty, tolerance, unit
+//	tags        "|"-separated
+func parseCSVQuestions(r io.Reader) ([]parsedQuestion, []ImportError) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, []ImportError{{Line: 1, Message: "missing header row"}}
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"type", "question"} {
+		if _, ok := columns[required]; !ok {
+			return nil, []ImportError{{Line: 1, Field: required, Message: "missing column"}}
+		}
+	}
+
+	var questions []parsedQuestion
+	var importErrors []ImportError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				importErrors = append(importErrors, ImportError{Message: err.Error()})
+				break
+			}
+			importErrors = append(importErrors, ImportError{Line: parseErr.Line, Message: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		question, field, err := csvQuestion(get)
+		if err != nil {
+			importErrors = append(importErrors, ImportError{Line: line, Field: field, Message: err.Error()})
+			continue
+		}
+		questions = append(questions, parsedQuestion{Line: line, Question: question})
+	}
+	return questions, importErrors
+}
+
+// csvQuestion builds the question of one row. On error it also returns the column at fault.
+func csvQuestion(get func(string) string) (entity.Question, string, error) {
+	qt, ok := LookupQuestionType(get("type"))
+	if !ok {
+		return entity.Question{}, "type", fmt.Errorf("unknown question type %q", get("type"))
+	}
+	text := get("question")
+	options := splitList(get("options"), "|")
+	answer := get("answer")
+
+	var question entity.Question
+	switch qt.Name {
+	case entity.QuestionTypeSingleChoice, entity.QuestionTypeMultipleChoice:
+		correct := make([]bool, len(options))
+		for _, ref := range splitList(answer, "|") {
+			i, err := choiceIndex(ref, len(options))
+			if err != nil {
+				return entity.Question{}, "answer", err
+			}
+			correct[i] = true
+		}
+		question = choiceQuestion(text, options, correct)
+		// The declared type wins, so a multiple choice question with one correct option stays one
+		question.Type = qt.Name
+	case entity.QuestionTypeTrueFalse:
+		value, err := strconv.ParseBool(strings.ToLower(answer))
+		if err != nil {
+			return entity.Question{}, "answer", fmt.Errorf("expected true or false, got %q", answer)
+		}
+		question = entity.Question{TrueFalse: &value}
+	case entity.QuestionTypeNumeric:
+		value, err := parseNumber(answer)
+		if err != nil {
+			return entity.Question{}, "answer", fmt.Errorf("expected a number, got %q", answer)
+		}
+		numeric := &entity.NumericAnswer{Value: value, Unit: get("unit")}
+		if tolerance := get("tolerance"); tolerance != "" {
+			if numeric.Tolerance, err = parseNumber(tolerance); err != nil {
+				return entity.Question{}, "tolerance", fmt.Errorf("expected a number, got %q", tolerance)
+			}
+		}
+		question = entity.Question{Numeric: numeric}
+	case entity.QuestionTypeFillInTheBlank:
+		var accepted [][]string
+		for _, group := range strings.Split(answer, ";") {
+			accepted = append(accepted, splitList(group, "|"))
+		}
+		var err error
+		if question, err = blankQuestion(text, accepted); err != nil {
+			return entity.Question{}, "answer", err
+		}
+	case entity.QuestionTypeOrder:
+		question = orderQuestion(text, options)
+	case entity.QuestionTypeMatchChoice:
+		var pairs [][2]string
+		for _, option := range options {
+			item, match, ok := strings.Cut(option, "=")
+			if !ok {
+				return entity.Question{}, "options", fmt.Errorf("expected item=match, got %q", option)
+			}
+			pairs = append(pairs, [2]string{strings.TrimSpace(item), strings.TrimSpace(match)})
+		}
+		question = matchQuestion(text, pairs)
+	case entity.QuestionTypeEssay, entity.QuestionTypeShortAnswer:
+	default:
+		return entity.Question{}, "type", fmt.Errorf("%s questions cannot be imported from CSV", qt.Name)
+	}
+
+	question.Type = qt.Name
+	question.QuestionContent.Text = text
+	question.Tags = splitList(get("tags"), "|")
+	question.Difficulty = strings.ToLower(get("difficulty"))
+	if score := get("score"); score != "" {
+		value, err := parseNumber(score)
+		if err != nil {
+			return entity.Question{}, "score", fmt.Errorf("expected a number, got %q", score)
+		}
+		question.Score = float32(value)
+	}
+	return question, "", nil
+}
+
+// choiceIndex reads a 1-based option number or an option letter.
+func choiceIndex(ref string, count int) (int, error) {
+	i, err := strconv.Atoi(ref)
+	if err != nil {
+		if len(ref) != 1 {
+			return 0, fmt.Errorf("invalid option %q", ref)
+		}
+		i = int(strings.ToUpper(ref)[0]-'A') + 1
+	}
+	if i < 1 || i > count {
+		return 0, fmt.Errorf("option %q does not exist", ref)
+	}
+	return i - 1, nil
+}
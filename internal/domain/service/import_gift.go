@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+// GIFT import, the Moodle subset most banks use:
+//
+//	::Title:: Question {=right ~wrong ~%50%half}   single or multiple choice
+//	Question {T}                                   true/false
+//	Question {=answer =other}                      fill in the blank (short answer)
+//	Question {=item -> match =item -> match}       matching
+//	Question {#3.14:0.01}  or  {#1..5}             numeric
+//	Question {}                                    essay
+//
+// Questions are separated by blank lines, "//" lines are comments, feedback after "#" is
+// dropped and "$CATEGORY: a/b" tags the following questions with "b".
+func parseGIFTQuestions(r io.Reader) ([]parsedQuestion, []ImportError) {
+	var questions []parsedQuestion
+	var importErrors []ImportError
+	var block []string
+	blockLine, category := 0, ""
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(block, "\n"))
+		block = block[:0]
+		if text == "" {
+			return
+		}
+		question, err := giftQuestion(text)
+		if err != nil {
+			importErrors = append(importErrors, ImportError{Line: blockLine, Message: err.Error()})
+			return
+		}
+		if category != "" {
+			question.Tags = append(question.Tags, category)
+		}
+		questions = append(questions, parsedQuestion{Line: blockLine, Question: question})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Text()
+		trimmed := strings.TrimSpace(raw)
+		switch {
+		case strings.HasPrefix(trimmed, "//"):
+			continue
+		case strings.HasPrefix(trimmed, "$CATEGORY:"):
+			flush()
+			path := strings.TrimSpace(strings.TrimPrefix(trimmed, "$CATEGORY:"))
+			category = path[strings.LastIndex(path, "/")+1:]
+			continue
+		case trimmed == "":
+			// A blank line inside an open answer block does not end the question
+			if giftBraceDepth(strings.Join(block, "\n")) == 0 {
+				flush()
+			}
+			continue
+		}
+		if len(block) == 0 {
+			blockLine = line
+		}
+		block = append(block, raw)
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		importErrors = append(importErrors, ImportError{Message: err.Error()})
+	}
+	return questions, importErrors
+}
+
+// giftQuestion parses one question block.
+func giftQuestion(text string) (entity.Question, error) {
+	// Drop the ::title::
+	if strings.HasPrefix(text, "::") {
+		if end := strings.Index(text[2:], "::"); end >= 0 {
+			text = strings.TrimSpace(text[end+4:])
+		}
+	}
+	// Drop the [html]/[markdown] format marker
+	if strings.HasPrefix(text, "[") {
+		if end := strings.Index(text, "]"); end > 0 && !strings.Contains(text[:end], " ") {
+			text = text[end+1:]
+		}
+	}
+
+	open := giftIndex(text, '{')
+	if open < 0 {
+		return entity.Question{}, errors.New("missing answer block {...}")
+	}
+	end := giftIndex(text[open:], '}')
+	if end < 0 {
+		return entity.Question{}, errors.New("answer block is not closed")
+	}
+	before := strings.TrimSpace(giftUnescape(text[:open]))
+	after := strings.TrimSpace(giftUnescape(text[open+end+1:]))
+	body := strings.TrimSpace(text[open+1 : open+end])
+	full := strings.TrimSpace(before + " " + after)
+
+	switch upper := strings.ToUpper(strings.TrimSpace(giftStripFeedback(body))); {
+	case body == "":
+		return entity.Question{Type: entity.QuestionTypeEssay, QuestionContent: entity.QuestionContent{Text: full}}, nil
+	case upper == "T" || upper == "TRUE" || upper == "F" || upper == "FALSE":
+		value := upper[0] == 'T'
+		return entity.Question{Type: entity.QuestionTypeTrueFalse, QuestionContent: entity.QuestionContent{Text: full}, TrueFalse: &value}, nil
+	case strings.HasPrefix(body, "#"):
+		numeric, err := giftNumeric(body[1:])
+		if err != nil {
+			return entity.Question{}, err
+		}
+		return entity.Question{Type: entity.QuestionTypeNumeric, QuestionContent: entity.QuestionContent{Text: full}, Numeric: numeric}, nil
+	}
+
+	answers := giftAnswers(body)
+	if len(answers) == 0 {
+		return entity.Question{}, errors.New("answer block has no answers")
+	}
+
+	if strings.Contains(body, "->") {
+		var pairs [][2]string
+		for _, answer := range answers {
+			item, match, ok := strings.Cut(answer.text, "->")
+			if !ok || answer.mark != '=' {
+				return entity.Question{}, fmt.Errorf("expected =item -> match, got %q", answer.text)
+			}
+			pairs = append(pairs, [2]string{strings.TrimSpace(item), strings.TrimSpace(match)})
+		}
+		return matchQuestion(full, pairs), nil
+	}
+
+	shortAnswer := true
+	for _, answer := range answers {
+		if answer.mark != '=' || answer.weight != nil {
+			shortAnswer = false
+		}
+	}
+	if shortAnswer {
+		accepted := make([]string, len(answers))
+		for i, answer := range answers {
+			accepted[i] = answer.text
+		}
+		return blankQuestion(strings.TrimSpace(before+" "+blankPattern+" "+after), [][]string{accepted})
+	}
+
+	options := make([]string, len(answers))
+	correct := make([]bool, len(answers))
+	for i, answer := range answers {
+		options[i] = answer.text
+		correct[i] = answer.mark == '=' || (answer.weight != nil && *answer.weight > 0)
+	}
+	question := choiceQuestion(full, options, correct)
+	for i, answer := range answers {
+		if answer.weight != nil && *answer.weight > 0 {
+			question.Options[i].Weight = float32(*answer.weight / 100)
+		}
+	}
+	return question, nil
+}
+
+type giftAnswer struct {
+	mark   byte     // '=' or '~'
+	weight *float64 // %n% percentage, when given
+	text   string
+}
+
+// giftAnswers splits an answer block on its unescaped = and ~ marks.
+func giftAnswers(body string) []giftAnswer {
+	var answers []giftAnswer
+	start := -1
+	for i := 0; i <= len(body); i++ {
+		atMark := i < len(body) && (body[i] == '=' || body[i] == '~') && (i == 0 || body[i-1] != '\\')
+		// "->" in a matching pair is not a mark
+		if atMark && body[i] == '=' && i+1 < len(body) && body[i+1] == '>' {
+			atMark = false
+		}
+		if !atMark && i < len(body) {
+			continue
+		}
+		if start >= 0 {
+			answers = append(answers, newGiftAnswer(body[start], body[start+1:i]))
+		}
+		start = i
+	}
+	return answers
+}
+
+func newGiftAnswer(mark byte, raw string) giftAnswer {
+	answer := giftAnswer{mark: mark}
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "%") {
+		if end := strings.Index(raw[1:], "%"); end >= 0 {
+			if weight, err := strconv.ParseFloat(raw[1:end+1], 64); err == nil {
+				answer.weight = &weight
+			}
+			raw = raw[end+2:]
+		}
+	}
+	answer.text = strings.TrimSpace(giftUnescape(giftStripFeedback(raw)))
+	return answer
+}
+
+// giftNumeric reads "value:tolerance", "min..max" or "value".
+func giftNumeric(body string) (*entity.NumericAnswer, error) {
+	body = strings.TrimSpace(body)
+	// Only the first of several answers ("=1822:0 =%50%1822:2") is kept
+	if answers := giftAnswers(body); strings.HasPrefix(body, "=") && len(answers) > 0 {
+		body = answers[0].text
+	} else {
+		body = strings.TrimSpace(giftStripFeedback(body))
+	}
+
+	if low, high, ok := strings.Cut(body, ".."); ok {
+		min, err1 := parseNumber(low)
+		max, err2 := parseNumber(high)
+		if err1 != nil || err2 != nil || min > max {
+			return nil, fmt.Errorf("invalid numeric range %q", body)
+		}
+		return &entity.NumericAnswer{Value: (min + max) / 2, Tolerance: (max - min) / 2}, nil
+	}
+	value, tolerance, _ := strings.Cut(body, ":")
+	numeric := &entity.NumericAnswer{}
+	var err error
+	if numeric.Value, err = parseNumber(value); err != nil {
+		return nil, fmt.Errorf("invalid numeric answer %q", body)
+	}
+	if tolerance != "" {
+		if numeric.Tolerance, err = parseNumber(tolerance); err != nil {
+			return nil, fmt.Errorf("invalid numeric tolerance %q", tolerance)
+		}
+	}
+	return numeric, nil
+}
+
+// giftStripFeedback drops the feedback after the first unescaped '#'.
+func giftStripFeedback(s string) string {
+	if i := giftIndex(s, '#'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// giftIndex returns the index of the first unescaped c in s, or -1.
+func giftIndex(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// giftBraceDepth reports how many answer blocks are still open at the end of s.
+func giftBraceDepth(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+var giftUnescaper = strings.NewReplacer(`\~`, "~", `\=`, "=", `\#`, "#", `\{`, "{", `\}`, "}", `\:`, ":", `\n`, "\n", `\\`, `\`)
+
+func giftUnescape(s string) string {
+	return giftUnescaper.Replace(s)
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvalidInvitationToken  = errors.New("invalid invitation link")
+	ErrInvitationExpired       = errors.New("invitation has expired")
+	ErrInvitationRevoked       = errors.New("invitation was revoked")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to another email")
+	ErrInvalidInvitation       = errors.New("invalid invitation")
+	ErrInvitationUsed          = errors.New("invitation link was already used")
+)
+
+// Limits of an invitation request.
+const (
+	DefaultInvitationDays = 7
+	MaxInvitationDays     = 30
+	MaxInvitationEmails   = 200
+)
+
+// InvitationOptions are the settings of new invitations. Zero values use the defaults.
+type InvitationOptions struct {
+	ExpiresInDays int
+	Message       string // Added to the mail under the link
+}
+
+// InviteResult reports the invitations sent for a list of emails.
+type InviteResult struct {
+	Invitations []entity.Invitation `json:"invitations"`
+	Skipped     []RosterSkip        `json:"skipped,omitempty"`
+}
+
+// InvitationUseCase invites students to a class by email. The mail holds a link signed
+// with secret, opened by the student while logged in with the invited email.
+type InvitationUseCase struct {
+	invitations repository.InvitationRepository
+	classRepo   repository.ClassRepository
+	mailer      repository.Mailer
+	authz       *AuthorizationService
+	secret      []byte
+	acceptURL   string
+}
+
+func NewInvitationUseCase(invitations repository.InvitationRepository, classRepo repository.ClassRepository, mailer repository.Mailer, authz *AuthorizationService, secret []byte, acceptURL string) *InvitationUseCase {
+	return &InvitationUseCase{
+		invitations: invitations,
+		classRepo:   classRepo,
+		mailer:      mailer,
+		authz:       authz,
+		secret:      secret,
+		acceptURL:   acceptURL,
+	}
+}
+
+// Invite sends an invitation to every email that is not a member of the class yet.
+// Emails with an open invitation get it again with a new expiry. It needs the manage_roster permission.
+func (uc *InvitationUseCase) Invite(ctx context.Context, emailID, email string, classID primitive.ObjectID, emails []string, opts InvitationOptions) (*InviteResult, error) {
+	class, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermManageRoster)
+	if err != nil {
+		return nil, err
+	}
+	if len(emails) == 0 || len(emails) > MaxInvitationEmails {
+		return nil, fmt.Errorf("%w: send between 1 and %d emails", ErrInvalidInvitation, MaxInvitationEmails)
+	}
+	days, err := invitationDays(opts.ExpiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.invitations.GetInvitationsByClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[string]entity.Invitation)
+	for _, invitation := range existing {
+		// Newest first, so the latest open invitation of each email is kept
+		if _, ok := open[invitation.Email]; !ok && invitation.IsOpen() {
+			open[invitation.Email] = invitation
+		}
+	}
+
+	now := time.Now()
+	result := &InviteResult{Invitations: []entity.Invitation{}}
+	seen := make(map[string]bool, len(emails))
+	for _, raw := range emails {
+		target := entity.NormalizeEmail(raw)
+		if target == "" || seen[target] {
+			continue
+		}
+		seen[target] = true
+		if _, err := mail.ParseAddress(target); err != nil {
+			result.Skipped = append(result.Skipped, RosterSkip{Email: raw, Reason: "invalid email"})
+			continue
+		}
+		if member, ok := class.Member(target); ok && member.Status != entity.MemberPending {
+			result.Skipped = append(result.Skipped, RosterSkip{Email: target, Reason: "already on the roster as " + member.Status + " " + member.Role})
+			continue
+		}
+
+		invitation, ok := open[target]
+		if !ok {
+			invitation = entity.Invitation{
+				ClassID:   classID,
+				Email:     target,
+				InvitedBy: entity.NormalizeEmail(email),
+				Status:    entity.InvitationPending,
+				CreatedAt: now,
+				ExpiresAt: now.AddDate(0, 0, days),
+			}
+			// Saved first, the link needs the ID
+			if err := uc.invitations.SaveInvitation(ctx, &invitation); err != nil {
+				return nil, err
+			}
+		} else {
+			invitation.ExpiresAt = now.AddDate(0, 0, days)
+		}
+		if err := uc.send(ctx, class, &invitation, opts.Message); err != nil {
+			return nil, err
+		}
+		result.Invitations = append(result.Invitations, invitation)
+	}
+	return result, nil
+}
+
+// ListInvitations lists the invitations of the class, newest first, with their current state.
+func (uc *InvitationUseCase) ListInvitations(ctx context.Context, emailID, email string, classID primitive.ObjectID) ([]entity.Invitation, error) {
+	if _, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermManageRoster); err != nil {
+		return nil, err
+	}
+	invitations, err := uc.invitations.GetInvitationsByClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range invitations {
+		invitations[i].Status = invitations[i].State(now)
+	}
+	return invitations, nil
+}
+
+// ResendInvitation sends the mail of an open or expired invitation again with a new expiry.
+func (uc *InvitationUseCase) ResendInvitation(ctx context.Context, emailID, email string, id primitive.ObjectID, expiresInDays int) (*entity.Invitation, error) {
+	days, err := invitationDays(expiresInDays)
+	if err != nil {
+		return nil, err
+	}
+	invitation, class, err := uc.manage(ctx, emailID, email, id)
+	if err != nil {
+		return nil, err
+	}
+	if !invitation.IsOpen() {
+		return nil, fmt.Errorf("%w: the invitation is %s", ErrInvalidInvitation, invitation.Status)
+	}
+	invitation.ExpiresAt = time.Now().AddDate(0, 0, days)
+	if err := uc.send(ctx, class, invitation, ""); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// RevokeInvitation disables the link of an open invitation.
+func (uc *InvitationUseCase) RevokeInvitation(ctx context.Context, emailID, email string, id primitive.ObjectID) (*entity.Invitation, error) {
+	invitation, _, err := uc.manage(ctx, emailID, email, id)
+	if err != nil {
+		return nil, err
+	}
+	if !invitation.IsOpen() {
+		return invitation, nil
+	}
+	invitation.Status = entity.InvitationRevoked
+	if err := uc.invitations.SaveInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation adds the caller to the class as an active student. The caller must be
+// logged in with the invited email. Accepting twice returns the member again, as long as
+// they are still active: a used link does not bring back a removed student.
+func (uc *InvitationUseCase) AcceptInvitation(ctx context.Context, token, email string) (*entity.ClassMember, error) {
+	id, err := uc.verifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := uc.invitations.GetInvitation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, ErrInvitationNotFound
+	}
+	if invitation.Email != entity.NormalizeEmail(email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	now := time.Now()
+	switch invitation.State(now) {
+	case entity.InvitationRevoked:
+		return nil, ErrInvitationRevoked
+	case entity.InvitationExpired:
+		return nil, ErrInvitationExpired
+	}
+
+	class, err := uc.classRepo.GetClassByID(ctx, invitation.ClassID)
+	if err != nil {
+		return nil, err
+	}
+	member, found := class.Member(invitation.Email)
+	if invitation.Status == entity.InvitationAccepted {
+		if found && member.Status == entity.MemberActive {
+			return &member, nil
+		}
+		return nil, ErrInvitationUsed
+	}
+	switch {
+	case found && member.Status == entity.MemberBanned:
+		return nil, ErrMemberBanned
+	case found && member.Status == entity.MemberActive:
+		// Already in the class, e.g. added by a teacher since the invitation was sent
+	default:
+		if !found {
+			member = entity.ClassMember{Email: invitation.Email, Role: entity.RoleStudent}
+		}
+		member.Status = entity.MemberActive
+		member.AddedBy = invitation.InvitedBy
+		member.UpdatedAt = now
+		if err := uc.classRepo.SaveMember(ctx, class.ID, member); err != nil {
+			return nil, err
+		}
+	}
+
+	invitation.Status = entity.InvitationAccepted
+	invitation.AcceptedAt = now
+	if err := uc.invitations.SaveInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// manage loads an invitation and its class for a user who may manage the roster.
+func (uc *InvitationUseCase) manage(ctx context.Context, emailID, email string, id primitive.ObjectID) (*entity.Invitation, *entity.Class, error) {
+	invitation, err := uc.invitations.GetInvitation(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if invitation == nil {
+		return nil, nil, ErrInvitationNotFound
+	}
+	class, err := uc.authz.AuthorizeClass(ctx, invitation.ClassID, emailID, email, entity.PermManageRoster)
+	if err != nil {
+		return nil, nil, err
+	}
+	return invitation, class, nil
+}
+
+// send mails the invitation and saves it with the outcome. A failed mail only marks the
+// invitation as failed so the other emails of the request still go out.
+func (uc *InvitationUseCase) send(ctx context.Context, class *entity.Class, invitation *entity.Invitation, message string) error {
+	body := fmt.Sprintf("%s invited you to join the class %q.\n\nOpen this link to accept the invitation:\n%s\n\nThe link works until %s.\n",
+		invitation.InvitedBy, class.ClassName, uc.acceptLink(invitation.ID), invitation.ExpiresAt.Format("2006-01-02 15:04 MST"))
+	if message = strings.TrimSpace(message); message != "" {
+		body += "\n" + message + "\n"
+	}
+	err := uc.mailer.Send(ctx, entity.Mail{
+		To:      invitation.Email,
+		Subject: "Invitation to join " + class.ClassName,
+		Body:    body,
+	})
+
+	invitation.SendCount++
+	if err != nil {
+		invitation.Status = entity.InvitationFailed
+		invitation.SendError = err.Error()
+	} else {
+		invitation.Status = entity.InvitationSent
+		invitation.SendError = ""
+		invitation.SentAt = time.Now()
+	}
+	return uc.invitations.SaveInvitation(ctx, invitation)
+}
+
+// invitationDays checks the validity of new links, in days. 0 means DefaultInvitationDays.
+func invitationDays(days int) (int, error) {
+	if days == 0 {
+		return DefaultInvitationDays, nil
+	}
+	if days < 0 || days > MaxInvitationDays {
+		return 0, fmt.Errorf("%w: expiry must be between 1 and %d days", ErrInvalidInvitation, MaxInvitationDays)
+	}
+	return days, nil
+}
+
+func (uc *InvitationUseCase) acceptLink(id primitive.ObjectID) string {
+	return uc.acceptURL + "?token=" + url.QueryEscape(uc.signToken(id))
+}
+
+// signToken returns "<invitation id>.<signature>". The expiry and state are kept with the
+// invitation, so revoking it disables the link.
+func (uc *InvitationUseCase) signToken(id primitive.ObjectID) string {
+	return id.Hex() + "." + base64.RawURLEncoding.EncodeToString(uc.signature(id.Hex()))
+}
+
+func (uc *InvitationUseCase) verifyToken(token string) (primitive.ObjectID, error) {
+	hexID, sig, ok := strings.Cut(token, ".")
+	if !ok || len(uc.secret) == 0 { // Anyone can sign with an empty key
+		return primitive.NilObjectID, ErrInvalidInvitationToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, uc.signature(hexID)) {
+		return primitive.NilObjectID, ErrInvalidInvitationToken
+	}
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidInvitationToken
+	}
+	return id, nil
+}
+
+func (uc *InvitationUseCase) signature(hexID string) []byte {
+	mac := hmac.New(sha256.New, uc.secret)
+	mac.Write([]byte("class-invitation:" + hexID))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVerifyToken(t *testing.T) {
+	uc := &InvitationUseCase{secret: []byte("test-secret")}
+	other := &InvitationUseCase{secret: []byte("another-secret")}
+	id, _ := primitive.ObjectIDFromHex("64f0a1b2c3d4e5f60718293a")
+	token := uc.signToken(id)
+	hexID, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", token, true},
+		{"signed with another secret", other.signToken(id), false},
+		{"signature of another invitation", testObjectID(8).Hex() + "." + sig, false},
+		{"no signature", hexID, false},
+		{"empty signature", hexID + ".", false},
+		{"empty", "", false},
+		{"signature not base64", hexID + ".!!!", false},
+		{"padded signature", hexID + "." + base64.URLEncoding.EncodeToString(uc.signature(hexID)), false},
+		{"uppercase id", strings.ToUpper(hexID) + "." + sig, false},
+		{"signed id that is not an object ID", "abc." + base64.RawURLEncoding.EncodeToString(uc.signature("abc")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uc.verifyToken(tt.token)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidInvitationToken) {
+					t.Errorf("verifyToken() error = %v, want ErrInvalidInvitationToken", err)
+				}
+				if got != primitive.NilObjectID {
+					t.Errorf("verifyToken() = %s, want the nil ID", got.Hex())
+				}
+				return
+			}
+			if err != nil || got != id {
+				t.Errorf("verifyToken() = %s, %v, want %s", got.Hex(), err, id.Hex())
+			}
+		})
+	}
+
+	unsigned := &InvitationUseCase{}
+	if _, err := unsigned.verifyToken(unsigned.signToken(id)); !errors.Is(err, ErrInvalidInvitationToken) {
+		t.Errorf("verifyToken() with no secret: error = %v, want ErrInvalidInvitationToken", err)
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrJoinCodeNotFound       = errors.New("join code not found")
+	ErrJoinCodeExpired        = errors.New("join code has expired")
+	ErrJoinCodeRevoked        = errors.New("join code was revoked")
+	ErrJoinCodeUsedUp         = errors.New("join code has no uses left")
+	ErrDomainNotAllowed       = errors.New("your email domain cannot use this join code")
+	ErrInvalidJoinCodeOptions = errors.New("invalid join code options")
+)
+
+// Codes avoid letters and digits that are easily confused, such as O and 0 or I and 1.
+const (
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 10
+)
+
+// JoinCodeOptions are the limits of a new join code. Zero values mean no limit.
+type JoinCodeOptions struct {
+	ExpiresInMinutes int
+	MaxUses          int
+	AllowedDomains   []string
+}
+
+// JoinCodeStatus is a join code with its state at the time it was listed.
+type JoinCodeStatus struct {
+	entity.JoinCode
+	Status string `json:"status"`
+}
+
+// JoinCodeUseCase creates join codes for the teachers of a class and lets students join with them.
+// Every use of a code, refused or not, goes to the audit log of the class.
+type JoinCodeUseCase struct {
+	codes     repository.JoinCodeRepository
+	classRepo repository.ClassRepository
+	classes   *ClassUseCase
+	authz     *AuthorizationService
+}
+
+func NewJoinCodeUseCase(codes repository.JoinCodeRepository, classRepo repository.ClassRepository, classes *ClassUseCase, authz *AuthorizationService) *JoinCodeUseCase {
+	return &JoinCodeUseCase{
+		codes:     codes,
+		classRepo: classRepo,
+		classes:   classes,
+		authz:     authz,
+	}
+}
+
+// CreateJoinCode creates a code for the class. It needs the manage_roster permission.
+func (uc *JoinCodeUseCase) CreateJoinCode(ctx context.Context, emailID, email string, classID primitive.ObjectID, opts JoinCodeOptions) (*entity.JoinCode, error) {
+	if _, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermManageRoster); err != nil {
+		return nil, err
+	}
+	if opts.ExpiresInMinutes < 0 || opts.MaxUses < 0 {
+		return nil, fmt.Errorf("%w: expiry and max uses cannot be negative", ErrInvalidJoinCodeOptions)
+	}
+	domains := make([]string, 0, len(opts.AllowedDomains))
+	for _, raw := range opts.AllowedDomains {
+		domain := entity.NormalizeDomain(raw)
+		if domain == "" {
+			continue
+		}
+		if !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@ ") {
+			return nil, fmt.Errorf("%w: invalid domain %q", ErrInvalidJoinCodeOptions, raw)
+		}
+		domains = append(domains, domain)
+	}
+
+	value, err := generateJoinCode()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	code := &entity.JoinCode{
+		Code:           value,
+		ClassID:        classID,
+		CreatedBy:      entity.NormalizeEmail(email),
+		CreatedAt:      now,
+		MaxUses:        opts.MaxUses,
+		AllowedDomains: domains,
+	}
+	if opts.ExpiresInMinutes > 0 {
+		code.ExpiresAt = now.Add(time.Duration(opts.ExpiresInMinutes) * time.Minute)
+	}
+	if err := uc.codes.CreateJoinCode(ctx, code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// ListJoinCodes lists the codes of the class, newest first, for users who may manage its roster.
+func (uc *JoinCodeUseCase) ListJoinCodes(ctx context.Context, emailID, email string, classID primitive.ObjectID) ([]JoinCodeStatus, error) {
+	if _, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermManageRoster); err != nil {
+		return nil, err
+	}
+	codes, err := uc.codes.GetJoinCodesByClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	statuses := make([]JoinCodeStatus, 0, len(codes))
+	for _, code := range codes {
+		statuses = append(statuses, JoinCodeStatus{JoinCode: code, Status: code.Status(now)})
+	}
+	return statuses, nil
+}
+
+// RevokeJoinCode stops a code from being used again. Students who joined with it stay in the class.
+func (uc *JoinCodeUseCase) RevokeJoinCode(ctx context.Context, emailID, email string, codeID primitive.ObjectID) (*entity.JoinCode, error) {
+	code, err := uc.codes.GetJoinCodeByID(ctx, codeID)
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, ErrJoinCodeNotFound
+	}
+	if _, err := uc.authz.AuthorizeClass(ctx, code.ClassID, emailID, email, entity.PermManageRoster); err != nil {
+		return nil, err
+	}
+	if !code.RevokedAt.IsZero() {
+		return code, nil
+	}
+	code.RevokedAt = time.Now()
+	code.RevokedBy = entity.NormalizeEmail(email)
+	if err := uc.codes.RevokeJoinCode(ctx, code.ID, code.RevokedBy, code.RevokedAt); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// JoinCodeLog returns the audit log of the class, newest first, for one code unless codeID is zero.
+func (uc *JoinCodeUseCase) JoinCodeLog(ctx context.Context, emailID, email string, classID, codeID primitive.ObjectID) ([]entity.JoinCodeUse, error) {
+	if _, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermManageRoster); err != nil {
+		return nil, err
+	}
+	return uc.codes.GetJoinCodeUses(ctx, classID, codeID)
+}
+
+// RedeemJoinCode adds the student to the class of the code, see ClassUseCase.JoinClass.
+// Only joins that add the student count as a use; members already on the roster do not.
+func (uc *JoinCodeUseCase) RedeemJoinCode(ctx context.Context, value, email string) (*entity.ClassMember, error) {
+	code, err := uc.codes.GetJoinCode(ctx, entity.NormalizeJoinCode(value))
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, ErrJoinCodeNotFound
+	}
+
+	now := time.Now()
+	switch code.Status(now) {
+	case entity.JoinCodeRevoked:
+		return nil, uc.refuse(ctx, code, email, entity.JoinCodeRevoked, ErrJoinCodeRevoked)
+	case entity.JoinCodeExpired:
+		return nil, uc.refuse(ctx, code, email, entity.JoinCodeExpired, ErrJoinCodeExpired)
+	case entity.JoinCodeUsedUp:
+		return nil, uc.refuse(ctx, code, email, entity.JoinCodeUsedUp, ErrJoinCodeUsedUp)
+	}
+	if !code.AllowsEmail(email) {
+		return nil, uc.refuse(ctx, code, email, entity.JoinDomainNotAllowed, ErrDomainNotAllowed)
+	}
+
+	class, err := uc.classRepo.GetClassByID(ctx, code.ClassID)
+	if err != nil {
+		return nil, err
+	}
+	if member, ok := class.Member(email); ok {
+		if member.Status == entity.MemberBanned {
+			return nil, uc.refuse(ctx, code, email, entity.JoinBanned, ErrMemberBanned)
+		}
+		uc.logUse(ctx, code, email, entity.JoinAlreadyMember, now)
+		return &member, nil
+	}
+
+	// The code may have been used up or revoked since it was loaded
+	counted, err := uc.codes.UseJoinCode(ctx, code.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !counted {
+		return nil, uc.refuse(ctx, code, email, entity.JoinCodeUsedUp, ErrJoinCodeUsedUp)
+	}
+	member, err := uc.classes.JoinClass(ctx, code.ClassID, email)
+	if err != nil {
+		// Không mất lượt dùng khi vào lớp thất bại
+		if releaseErr := uc.codes.ReleaseJoinCodeUse(ctx, code.ID); releaseErr != nil {
+			log.Printf("join code %s: %v", code.Code, releaseErr)
+		}
+		outcome := entity.JoinFailed
+		if errors.Is(err, ErrMemberBanned) {
+			outcome = entity.JoinBanned
+		}
+		return nil, uc.refuse(ctx, code, email, outcome, err)
+	}
+	outcome := entity.JoinJoined
+	if member.Status == entity.MemberPending {
+		outcome = entity.JoinPending
+	}
+	uc.logUse(ctx, code, email, outcome, now)
+	return member, nil
+}
+
+// refuse logs a refused use of the code and returns err.
+func (uc *JoinCodeUseCase) refuse(ctx context.Context, code *entity.JoinCode, email, outcome string, err error) error {
+	uc.logUse(ctx, code, email, outcome, time.Now())
+	return err
+}
+
+// logUse adds an entry to the audit log. A failed write does not undo the join.
+func (uc *JoinCodeUseCase) logUse(ctx context.Context, code *entity.JoinCode, email, outcome string, at time.Time) {
+	err := uc.codes.AddJoinCodeUse(ctx, entity.JoinCodeUse{
+		CodeID:  code.ID,
+		Code:    code.Code,
+		ClassID: code.ClassID,
+		Email:   entity.NormalizeEmail(email),
+		Outcome: outcome,
+		At:      at,
+	})
+	if err != nil {
+		log.Printf("join code %s: %v", code.Code, err)
+	}
+}
+
+// generateJoinCode draws a random code from joinCodeAlphabet.
+func generateJoinCode() (string, error) {
+	var b strings.Builder
+	size := big.NewInt(int64(len(joinCodeAlphabet)))
+	for range joinCodeLength {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate join code: %w", err)
+		}
+		b.WriteByte(joinCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
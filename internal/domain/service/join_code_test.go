@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRedeemJoinCodeGivesBackTheUseWhenTheJoinFails(t *testing.T) {
+	ctx := context.Background()
+	env := newAnswerTestEnv()
+	env.class.IsPublic = true
+	code := &entity.JoinCode{ID: primitive.NewObjectID(), Code: "ABCD2345", ClassID: env.class.ID, MaxUses: 1}
+	codes := &fakeJoinCodeRepo{codes: []*entity.JoinCode{code}}
+	uc := NewJoinCodeUseCase(codes, env.classes, NewClassUseCase(env.classes, nil, env.uc.authz), env.uc.authz)
+
+	env.classes.saveErr = errors.New("write failed")
+	if _, err := uc.RedeemJoinCode(ctx, code.Code, "first@school.edu"); !errors.Is(err, env.classes.saveErr) {
+		t.Fatalf("RedeemJoinCode() error = %v, want the write error", err)
+	}
+	if code.Uses != 0 {
+		t.Errorf("uses = %d after a failed join, want 0", code.Uses)
+	}
+	if len(codes.uses) != 1 || codes.uses[0].Outcome != entity.JoinFailed {
+		t.Errorf("log = %+v, want one failed use", codes.uses)
+	}
+
+	// Lượt dùng duy nhất vẫn còn cho người tiếp theo
+	env.classes.saveErr = nil
+	member, err := uc.RedeemJoinCode(ctx, code.Code, "second@school.edu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member.Status != entity.MemberActive || code.Uses != 1 {
+		t.Errorf("member %+v, uses %d; want active and 1", member, code.Uses)
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNotManuallyGraded = errors.New("question is not graded manually")
+	ErrInvalidScore      = errors.New("score must be between 0 and the question's score")
+	ErrGradeConflict     = errors.New("the attempt is being graded by someone else, try again")
+)
+
+// maxGradeRetries is how often GradeResponse applies a grade again after losing to another grader.
+const maxGradeRetries = 3
+
+// GradingQueueItem is a submitted response waiting for a teacher's grade.
+type GradingQueueItem struct {
+	AnswerID      primitive.ObjectID `json:"answer_id"`
+	QuestionID    primitive.ObjectID `json:"question_id"`
+	QuestionText  string             `json:"question_text"`
+	MaxScore      float32            `json:"max_score"`
+	Response      string             `json:"response"`
+	Email         string             `json:"email,omitempty"` // Empty when grading blind
+	AttemptNumber int                `json:"attempt_number"`
+	SubmittedAt   time.Time          `json:"submitted_at"`
+}
+
+// GradedResponse is what a grader gets back after grading a response. It never names
+// the student, so blind grading stays blind.
+type GradedResponse struct {
+	AnswerID       primitive.ObjectID `json:"answer_id"`
+	QuestionID     primitive.ObjectID `json:"question_id"`
+	Score          float32            `json:"score"`
+	Comment        string             `json:"comment,omitempty"`
+	TotalScore     float32            `json:"total_score"`
+	GradingPending bool               `json:"grading_pending"` // Other responses of the attempt still wait for a grade
+}
+
+// GradingQueue lists the ungraded responses of a test for users with the grade permission.
+// Items are ordered by answer ID. When the owner turned on blind grading for the test,
+// student emails are left out.
+func (au *AnswerUseCase) GradingQueue(ctx context.Context, teacherEmailID, teacherEmail string, classID, testID primitive.ObjectID) ([]GradingQueueItem, error) {
+	class, err := au.authz.AuthorizeClassTest(ctx, classID, testID, teacherEmailID, teacherEmail, entity.PermGrade)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(class.Test, func(t entity.Test) bool { return t.ID == testID })
+	if i < 0 {
+		return nil, ErrTestNotFound
+	}
+	blind := class.Test[i].BlindGrading
+
+	answers, err := au.repo.GetAllAnswer(ctx, bson.M{"class_id": classID, "test_id": testID, "grading_pending": true})
+	if err != nil {
+		return nil, fmt.Errorf("load answers: %w", err)
+	}
+
+	var questionIDs []primitive.ObjectID
+	seen := make(map[primitive.ObjectID]struct{})
+	for _, answer := range answers {
+		for _, qa := range answer.ListQuestionAnswer {
+			if _, ok := seen[qa.QuestionID]; !ok && qa.GradeStatus == entity.GradePending {
+				seen[qa.QuestionID] = struct{}{}
+				questionIDs = append(questionIDs, qa.QuestionID)
+			}
+		}
+	}
+	if len(questionIDs) == 0 {
+		return []GradingQueueItem{}, nil
+	}
+	questions, err := au.questionRepo.GetQuestionsByIDs(ctx, questionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("load questions: %w", err)
+	}
+	byID := questionsByID(questions)
+	snapshots, err := au.snapshotsFor(ctx, answers, questionIDs, questions)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(answers, func(i, j int) bool {
+		return answers[i].ID.Hex() < answers[j].ID.Hex()
+	})
+
+	queue := make([]GradingQueueItem, 0)
+	for _, answer := range answers {
+		for _, qa := range answer.ListQuestionAnswer {
+			if qa.GradeStatus != entity.GradePending {
+				continue
+			}
+			question, ok := pinnedQuestion(answer, qa.QuestionID, byID, snapshots)
+			if !ok {
+				continue
+			}
+			item := GradingQueueItem{
+				AnswerID:      answer.ID,
+				QuestionID:    qa.QuestionID,
+				QuestionText:  question.QuestionContent.Text,
+				MaxScore:      maxScoreFor(question, answer),
+				Response:      qa.Text,
+				AttemptNumber: attemptNumber(answer),
+				SubmittedAt:   answer.EndTime,
+			}
+			if !blind {
+				item.Email = answer.Email
+			}
+			queue = append(queue, item)
+		}
+	}
+	return queue, nil
+}
+
+// GradeResponse stores a teacher's score and comment for a manually graded response,
+// logs the change and recomputes the attempt's total score. When another grader stores
+// a grade of the same attempt meanwhile, the grade is applied again on top of theirs.
+func (au *AnswerUseCase) GradeResponse(ctx context.Context, teacherEmailID, teacherEmail string, answerID, questionID primitive.ObjectID, score float32, comment string) (*GradedResponse, error) {
+	answer, err := au.repo.GetAnswer(ctx, bson.M{"_id": answerID})
+	if err != nil {
+		return nil, ErrAttemptNotFound
+	}
+	if _, err := au.authz.AuthorizeClassTest(ctx, answer.ClassID, answer.TestId, teacherEmailID, teacherEmail, entity.PermGrade); err != nil {
+		return nil, err
+	}
+	if answer.IsOpen() {
+		return nil, errors.New("attempt is still in progress")
+	}
+	if !slices.ContainsFunc(answer.ListQuestionAnswer, func(qa entity.QuestionAnswer) bool { return qa.QuestionID == questionID }) {
+		return nil, ErrNotManuallyGraded
+	}
+
+	ids := []primitive.ObjectID{questionID}
+	questions, err := au.questionRepo.GetQuestionsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("load question: %w", err)
+	}
+	if questions, err = au.pinQuestions(ctx, answer, ids, questions); err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, ErrNotManuallyGraded
+	}
+	question := questions[0]
+	if qt, ok := LookupQuestionType(question.Type); !ok || !qt.ManualGrading {
+		return nil, ErrNotManuallyGraded
+	}
+	if score < 0 || score > maxScoreFor(question, answer) {
+		return nil, ErrInvalidScore
+	}
+
+	for try := 0; ; try++ {
+		if try > 0 {
+			if answer, err = au.repo.GetAnswer(ctx, bson.M{"_id": answerID}); err != nil {
+				return nil, ErrAttemptNotFound
+			}
+		}
+		idx := slices.IndexFunc(answer.ListQuestionAnswer, func(qa entity.QuestionAnswer) bool { return qa.QuestionID == questionID })
+		if idx < 0 {
+			return nil, ErrNotManuallyGraded
+		}
+
+		now := time.Now()
+		qa := &answer.ListQuestionAnswer[idx]
+		answer.GradeLog = append(answer.GradeLog, entity.GradeChange{
+			QuestionID:    questionID,
+			PreviousScore: qa.Score,
+			Score:         score,
+			Comment:       comment,
+			GradedBy:      teacherEmail,
+			GradedAt:      now,
+		})
+		qa.Score = score
+		qa.Comment = comment
+		qa.GradeStatus = entity.GradeGraded
+		qa.GradedBy = teacherEmail
+		qa.GradedAt = now
+		answer.RecomputeScore()
+
+		stored, err := au.repo.UpdateGradedAnswer(ctx, answer)
+		if err != nil {
+			return nil, fmt.Errorf("store grade: %w", err)
+		}
+		if stored {
+			break
+		}
+		if try == maxGradeRetries {
+			return nil, ErrGradeConflict
+		}
+	}
+	return &GradedResponse{
+		AnswerID:       answer.ID,
+		QuestionID:     questionID,
+		Score:          score,
+		Comment:        comment,
+		TotalScore:     answer.TotalScore,
+		GradingPending: answer.GradingPending,
+	}, nil
+}
+
+// maxScoreFor returns the question's score in the attempt, honoring draw rule overrides.
+func maxScoreFor(question entity.Question, answer entity.TestAnswer) float32 {
+	questions := []entity.Question{question}
+	applyDrawnScores(questions, answer.DrawnQuestions)
+	return questions[0].Score
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// essayAttempt adds an essay question to the test and returns a submitted attempt
+// of the student waiting for its two essays to be graded.
+func essayAttempt(env *answerTestEnv) (entity.TestAnswer, [2]primitive.ObjectID) {
+	var ids [2]primitive.ObjectID
+	attempt := env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour))
+	attempt.ListQuestionAnswer = nil
+	for i := range ids {
+		essay := entity.Question{ID: primitive.NewObjectID(), Type: entity.QuestionTypeEssay, Score: 5, Metadata: entity.Metadata{Author: testOwnerID}}
+		env.questions.questions[essay.ID] = essay
+		env.test.QuestionIDs = append(env.test.QuestionIDs, essay.ID)
+		attempt.ListQuestionAnswer = append(attempt.ListQuestionAnswer, entity.QuestionAnswer{QuestionID: essay.ID, Text: "essay", GradeStatus: entity.GradePending})
+		ids[i] = essay.ID
+	}
+	attempt.QuestionVersions = nil
+	attempt.GradeLog = nil
+	attempt.RecomputeScore()
+	env.answers.answers = append(env.answers.answers, attempt)
+	return attempt, ids
+}
+
+func TestGradeResponseKeepsConcurrentGrades(t *testing.T) {
+	ctx := context.Background()
+	env := newAnswerTestEnv()
+	attempt, essays := essayAttempt(env)
+
+	// Người chấm thứ hai lưu điểm ngay trước lần ghi đầu tiên
+	env.answers.beforeGrade = func() {
+		env.answers.beforeGrade = nil
+		if _, err := env.uc.GradeResponse(ctx, testOwnerID, testOwnerEmail, attempt.ID, essays[1], 4, "second"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	graded, err := env.uc.GradeResponse(ctx, testOwnerID, testOwnerEmail, attempt.ID, essays[0], 3, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graded.TotalScore != 7 || graded.GradingPending {
+		t.Errorf("graded = %+v, want a total of 7 with nothing pending", graded)
+	}
+
+	stored := env.answers.answers[0]
+	for i, want := range []float32{3, 4} {
+		if qa := stored.ListQuestionAnswer[i]; qa.Score != want || qa.GradeStatus != entity.GradeGraded {
+			t.Errorf("essay %d = %+v, want graded %v", i, qa, want)
+		}
+	}
+	if len(stored.GradeLog) != 2 || stored.TotalScore != 7 || stored.GradeVersion != 2 {
+		t.Errorf("stored log %+v, total %v, version %d; want 2 entries, 7 and 2", stored.GradeLog, stored.TotalScore, stored.GradeVersion)
+	}
+}
+
+func TestGradeResponseGivesUpAfterRetries(t *testing.T) {
+	ctx := context.Background()
+	env := newAnswerTestEnv()
+	attempt, essays := essayAttempt(env)
+	env.answers.beforeGrade = func() { env.answers.answers[0].GradeVersion++ }
+
+	_, err := env.uc.GradeResponse(ctx, testOwnerID, testOwnerEmail, attempt.ID, essays[0], 3, "")
+	if !errors.Is(err, ErrGradeConflict) {
+		t.Fatalf("error = %v, want ErrGradeConflict", err)
+	}
+}
+
+func TestGradingQueueBlindIsSetByTheOwner(t *testing.T) {
+	ctx := context.Background()
+	env := newAnswerTestEnv()
+	const coTeacherID, coTeacherEmail = "co-teacher-id", "co@school.edu"
+	env.class.Members = append(env.class.Members, entity.ClassMember{Email: coTeacherEmail, Role: entity.RoleCoTeacher, Status: entity.MemberActive})
+	essayAttempt(env)
+	classes := NewClassUseCase(env.classes, nil, env.uc.authz)
+
+	queueEmails := func() []string {
+		t.Helper()
+		queue, err := env.uc.GradingQueue(ctx, coTeacherID, coTeacherEmail, env.class.ID, env.test.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var emails []string
+		for _, item := range queue {
+			emails = append(emails, item.Email)
+		}
+		return emails
+	}
+
+	if emails := queueEmails(); len(emails) != 2 || emails[0] != testStudentEmail {
+		t.Fatalf("queue emails = %q, want the student's", emails)
+	}
+	if err := classes.SetBlindGrading(ctx, coTeacherID, coTeacherEmail, env.class.ID, env.test.ID, true); !errors.Is(err, ErrNotClassOwner) {
+		t.Fatalf("co-teacher SetBlindGrading() error = %v, want ErrNotClassOwner", err)
+	}
+	if err := classes.SetBlindGrading(ctx, testOwnerID, testOwnerEmail, env.class.ID, env.test.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if emails := queueEmails(); len(emails) != 2 || emails[0] != "" || emails[1] != "" {
+		t.Errorf("blind queue emails = %q, want none", emails)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeSingleChoice,
+		Aliases:        []string{"multiple_choice_single"},
+		DefaultScoring: entity.ScoringAllOrNothing,
+		Validate:       validateChoices(1, 1),
+		AssignIDs:      assignOptionIDs,
+		Sanitize:       sanitizeOptions,
+		AnswerKey:      correctOptionsKey,
+		Shuffle:        shuffleOptions,
+		Reorder:        reorderOptions,
+		Grade:          gradeSingleChoice,
+	})
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeMultipleChoice,
+		Aliases:        []string{"multiple_choice_multiple"},
+		DefaultScoring: entity.ScoringAllOrNothing,
+		Validate:       validateChoices(1, -1),
+		AssignIDs:      assignOptionIDs,
+		Sanitize:       sanitizeOptions,
+		AnswerKey:      correctOptionsKey,
+		Shuffle:        shuffleOptions,
+		Reorder:        reorderOptions,
+		Grade:          gradeMultipleChoice,
+	})
+}
+
+func optionID(o entity.Option) primitive.ObjectID { return o.ID }
+
+// validateChoices needs at least two options and between minCorrect and maxCorrect
+// correct ones; a negative maxCorrect means no upper bound.
+func validateChoices(minCorrect, maxCorrect int) func(entity.Question, *entity.ValidationErrors) {
+	return func(q entity.Question, errs *entity.ValidationErrors) {
+		if len(q.Options) < 2 {
+			errs.Add("options", "needs at least two options")
+		}
+		correct := 0
+		for i, option := range q.Options {
+			if option.Text == "" && option.ImageURL == "" {
+				errs.Add(fmt.Sprintf("options[%d].text", i), "option needs a text or an image")
+			}
+			if option.Weight < 0 {
+				errs.Add(fmt.Sprintf("options[%d].weight", i), "cannot be negative")
+			}
+			if option.IsCorrect {
+				correct++
+			}
+		}
+		switch {
+		case minCorrect == maxCorrect && correct != minCorrect:
+			errs.Add("options", "needs exactly %d correct option(s), has %d", minCorrect, correct)
+		case correct < minCorrect:
+			errs.Add("options", "needs at least %d correct option(s)", minCorrect)
+		case maxCorrect >= 0 && correct > maxCorrect:
+			errs.Add("options", "allows at most %d correct option(s), has %d", maxCorrect, correct)
+		}
+	}
+}
+
+func assignOptionIDs(q *entity.Question) {
+	for i := range q.Options {
+		assignID(&q.Options[i].ID)
+	}
+}
+
+func sanitizeOptions(q entity.Question, view *StudentQuestion) {
+	for _, option := range q.Options {
+		view.Options = append(view.Options, StudentChoice{ID: option.ID, Text: option.Text, ImageURL: option.ImageURL})
+	}
+}
+
+func correctOptionsKey(q entity.Question, key *AnswerKey) {
+	for _, option := range q.Options {
+		if option.IsCorrect {
+			key.Options = append(key.Options, option.ID)
+		}
+	}
+}
+
+func shuffleOptions(r *rand.Rand, q entity.Question, perm *entity.QuestionPermutation) {
+	perm.Options = collectIDs(q.Options, optionID)
+	pkg.Shuffle(r, perm.Options)
+}
+
+func reorderOptions(q *entity.Question, perm entity.QuestionPermutation) {
+	q.Options = reorderByID(q.Options, perm.Options, optionID)
+}
+
+// gradeSingleChoice has one part: the correct option.
+func gradeSingleChoice(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	part := gradedPart{state: partBlank, weight: 1}
+	for _, option := range question.Options {
+		if option.IsCorrect {
+			part.weight = partWeight(option.Weight)
+		}
+	}
+	if len(response.Options) == 0 {
+		return []gradedPart{part}
+	}
+
+	part.state = partWrong
+	if len(response.Options) == 1 {
+		for _, option := range question.Options {
+			if option.ID == response.Options[0].ID && option.IsCorrect {
+				part.state = partRight
+			}
+		}
+	}
+	return []gradedPart{part}
+}
+
+// gradeMultipleChoice has a part per correct option; every ticked incorrect option is a penalty.
+func gradeMultipleChoice(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	selected := make(map[primitive.ObjectID]struct{}, len(response.Options))
+	for _, option := range response.Options {
+		selected[option.ID] = struct{}{}
+	}
+
+	var parts []gradedPart
+	for _, option := range question.Options {
+		_, picked := selected[option.ID]
+		delete(selected, option.ID)
+		switch {
+		case option.IsCorrect && picked:
+			parts = append(parts, gradedPart{state: partRight, weight: partWeight(option.Weight)})
+		case option.IsCorrect:
+			parts = append(parts, gradedPart{state: partBlank, weight: partWeight(option.Weight)})
+		case picked:
+			parts = append(parts, gradedPart{state: partWrong, weight: partWeight(option.Weight), penaltyOnly: true})
+		}
+	}
+	// Selections that reference options outside the question are wrong too
+	for range selected {
+		parts = append(parts, gradedPart{state: partWrong, weight: 1, penaltyOnly: true})
+	}
+	return parts
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeFillInTheBlank,
+		DefaultScoring: entity.ScoringProportional,
+		Validate:       validateFillInTheBlank,
+		AssignIDs: func(q *entity.Question) {
+			for i := range q.FillInTheBlanks {
+				assignID(&q.FillInTheBlanks[i].ID)
+			}
+		},
+		Sanitize: func(q entity.Question, view *StudentQuestion) {
+			for _, blank := range q.FillInTheBlanks {
+				view.FillInTheBlanks = append(view.FillInTheBlanks, StudentBlank{ID: blank.ID, TextBefore: blank.TextBefore, TextAfter: blank.TextAfter})
+			}
+		},
+		AnswerKey: func(q entity.Question, key *AnswerKey) {
+			for _, blank := range q.FillInTheBlanks {
+				key.Blanks = append(key.Blanks, BlankKey{ID: blank.ID, Accepted: blank.AcceptedValues()})
+			}
+		},
+		Grade: gradeFillInTheBlank,
+	})
+}
+
+func validateFillInTheBlank(q entity.Question, errs *entity.ValidationErrors) {
+	if len(q.FillInTheBlanks) == 0 {
+		errs.Add("fill_in_the_blanks", "needs at least one blank")
+	}
+	for i, blank := range q.FillInTheBlanks {
+		field := fmt.Sprintf("fill_in_the_blanks[%d]", i)
+		accepted := blank.AcceptedValues()
+		if len(accepted) == 0 {
+			errs.Add(field+".correct_answer", "blank needs an accepted answer")
+		}
+		if blank.Weight < 0 {
+			errs.Add(field+".weight", "cannot be negative")
+		}
+		if blank.Matching == nil {
+			continue
+		}
+		switch blank.Matching.Mode {
+		case "", entity.MatchModeText:
+		case entity.MatchModeRegex:
+			for _, pattern := range accepted {
+				if _, err := regexp.Compile(pattern); err != nil {
+					errs.Add(field+".correct_answer", "invalid pattern %q", pattern)
+				}
+			}
+		case entity.MatchModeNumeric:
+			for _, value := range accepted {
+				if _, err := parseNumber(value); err != nil {
+					errs.Add(field+".correct_answer", "%q is not a number", value)
+				}
+			}
+		default:
+			errs.Add(field+".matching.mode", "unknown matching mode %q", blank.Matching.Mode)
+		}
+		if blank.Matching.Tolerance < 0 {
+			errs.Add(field+".matching.tolerance", "cannot be negative")
+		}
+		if blank.Matching.MaxEditDistance < 0 {
+			errs.Add(field+".matching.max_edit_distance", "cannot be negative")
+		}
+	}
+}
+
+// gradeFillInTheBlank has a part per blank, compared with the blank's matching rules.
+func gradeFillInTheBlank(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	given := make(map[primitive.ObjectID]string, len(response.FillInTheBlanks))
+	for _, blank := range response.FillInTheBlanks {
+		given[blank.ID] = blank.CorrectAnswer
+	}
+
+	parts := make([]gradedPart, 0, len(question.FillInTheBlanks))
+	for _, blank := range question.FillInTheBlanks {
+		part := gradedPart{state: partBlank, weight: partWeight(blank.Weight)}
+		if value, ok := given[blank.ID]; ok && value != "" {
+			part.state = partWrong
+			if MatchBlankAnswer(blank, value) {
+				part.state = partRight
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+// Hotspot regions are never sent to the student: they are the answer.
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeHotspot,
+		DefaultScoring: entity.ScoringAllOrNothing,
+		Validate:       validateHotspot,
+		AssignIDs: func(q *entity.Question) {
+			for i := range q.Hotspots {
+				assignID(&q.Hotspots[i].ID)
+			}
+		},
+		AnswerKey: func(q entity.Question, key *AnswerKey) {
+			for _, hotspot := range q.Hotspots {
+				if hotspot.IsCorrect {
+					key.Hotspots = append(key.Hotspots, hotspot)
+				}
+			}
+		},
+		Grade: gradeHotspot,
+	})
+}
+
+func validateHotspot(q entity.Question, errs *entity.ValidationErrors) {
+	if q.QuestionContent.ImageURL == "" {
+		errs.Add("question_content.image_url", "hotspot question needs an image")
+	}
+	correct := 0
+	for i, hotspot := range q.Hotspots {
+		field := fmt.Sprintf("hotspots[%d]", i)
+		switch {
+		case hotspot.Shape == entity.HotspotCircle && hotspot.Radius <= 0:
+			errs.Add(field+".radius", "circle hotspot needs a positive radius")
+		case hotspot.Shape == entity.HotspotRect && (hotspot.Width <= 0 || hotspot.Height <= 0):
+			errs.Add(field, "rect hotspot needs a positive width and height")
+		case hotspot.Shape != entity.HotspotCircle && hotspot.Shape != entity.HotspotRect:
+			errs.Add(field+".shape", "must be rect or circle")
+		}
+		if hotspot.IsCorrect {
+			correct++
+		}
+	}
+	if correct == 0 {
+		errs.Add("hotspots", "needs at least one correct hotspot")
+	}
+}
+
+// gradeHotspot has a part per correct hotspot; every click outside them is a penalty.
+func gradeHotspot(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	var parts []gradedPart
+	for _, hotspot := range question.Hotspots {
+		if !hotspot.IsCorrect {
+			continue
+		}
+		part := gradedPart{state: partBlank, weight: partWeight(hotspot.Weight)}
+		for _, point := range response.Points {
+			if hotspot.Contains(point) {
+				part.state = partRight
+				break
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	for _, point := range response.Points {
+		hit := false
+		for _, hotspot := range question.Hotspots {
+			if hotspot.IsCorrect && hotspot.Contains(point) {
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			parts = append(parts, gradedPart{state: partWrong, weight: 1, penaltyOnly: true})
+		}
+	}
+	return parts
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	entity "quiz-app/internal/domain/entities"
+)
+
+// Essay and short answer questions have no answer key: a teacher grades them
+// through the grading queue.
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:          entity.QuestionTypeEssay,
+		ManualGrading: true,
+	})
+	RegisterQuestionType(QuestionType{
+		Name:          entity.QuestionTypeShortAnswer,
+		ManualGrading: true,
+	})
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeMatchChoice,
+		DefaultScoring: entity.ScoringAllOrNothing,
+		Validate:       validateMatch,
+		AssignIDs: func(q *entity.Question) {
+			for i := range q.MatchItems {
+				assignID(&q.MatchItems[i].ID)
+			}
+			for i := range q.MatchOptions {
+				assignID(&q.MatchOptions[i].ID)
+			}
+		},
+		Sanitize: func(q entity.Question, view *StudentQuestion) {
+			for _, item := range q.MatchItems {
+				view.MatchItems = append(view.MatchItems, StudentChoice{ID: item.ID, Text: item.Text})
+			}
+			for _, option := range q.MatchOptions {
+				view.MatchOptions = append(view.MatchOptions, StudentChoice{ID: option.ID, Text: option.Text})
+			}
+		},
+		AnswerKey: func(q entity.Question, key *AnswerKey) {
+			for _, option := range q.MatchOptions {
+				key.Matches = append(key.Matches, MatchKey{ID: option.ID, MatchId: option.MatchId})
+			}
+		},
+		Shuffle: func(r *rand.Rand, q entity.Question, perm *entity.QuestionPermutation) {
+			perm.MatchItems = collectIDs(q.MatchItems, matchItemID)
+			perm.MatchOptions = collectIDs(q.MatchOptions, matchOptionID)
+			pkg.Shuffle(r, perm.MatchItems)
+			pkg.Shuffle(r, perm.MatchOptions)
+		},
+		Reorder: func(q *entity.Question, perm entity.QuestionPermutation) {
+			q.MatchItems = reorderByID(q.MatchItems, perm.MatchItems, matchItemID)
+			q.MatchOptions = reorderByID(q.MatchOptions, perm.MatchOptions, matchOptionID)
+		},
+		Grade: gradeMatchChoice,
+	})
+}
+
+// validateMatch needs every match option to point at one of the match items.
+func validateMatch(q entity.Question, errs *entity.ValidationErrors) {
+	if len(q.MatchItems) == 0 {
+		errs.Add("match_items", "needs at least one item")
+	}
+	if len(q.MatchOptions) == 0 {
+		errs.Add("match_options", "needs at least one option")
+	}
+	items := make(map[string]struct{}, len(q.MatchItems))
+	for i, item := range q.MatchItems {
+		if item.Text == "" {
+			errs.Add(fmt.Sprintf("match_items[%d].text", i), "is required")
+		}
+		items[item.ID.Hex()] = struct{}{}
+	}
+	for i, option := range q.MatchOptions {
+		field := fmt.Sprintf("match_options[%d]", i)
+		if option.Text == "" {
+			errs.Add(field+".text", "is required")
+		}
+		if option.Weight < 0 {
+			errs.Add(field+".weight", "cannot be negative")
+		}
+		if _, ok := items[option.MatchId]; !ok {
+			errs.Add(field+".match_id", "does not reference a match item")
+		}
+	}
+}
+
+func matchItemID(m entity.MatchItem) primitive.ObjectID     { return m.ID }
+func matchOptionID(m entity.MatchOption) primitive.ObjectID { return m.ID }
+
+// gradeMatchChoice has a part per match option.
+func gradeMatchChoice(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	pairs := make(map[primitive.ObjectID]primitive.ObjectID, len(response.Match))
+	for _, match := range response.Match {
+		pairs[match.ID] = match.MatchId
+	}
+
+	parts := make([]gradedPart, 0, len(question.MatchOptions))
+	for _, option := range question.MatchOptions {
+		part := gradedPart{state: partBlank, weight: partWeight(option.Weight)}
+		if itemID, ok := pairs[option.ID]; ok && !itemID.IsZero() {
+			part.state = partWrong
+			if itemID.Hex() == option.MatchId {
+				part.state = partRight
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeMatrix,
+		DefaultScoring: entity.ScoringProportional,
+		Validate:       validateMatrix,
+		// Columns may come with IDs so rows can reference their correct column
+		AssignIDs: func(q *entity.Question) {
+			if q.Matrix == nil {
+				return
+			}
+			for i := range q.Matrix.Rows {
+				assignID(&q.Matrix.Rows[i].ID)
+			}
+			for i := range q.Matrix.Columns {
+				assignID(&q.Matrix.Columns[i].ID)
+			}
+		},
+		Sanitize: func(q entity.Question, view *StudentQuestion) {
+			if q.Matrix == nil {
+				return
+			}
+			view.Matrix = &StudentMatrix{}
+			for _, row := range q.Matrix.Rows {
+				view.Matrix.Rows = append(view.Matrix.Rows, StudentChoice{ID: row.ID, Text: row.Text})
+			}
+			for _, column := range q.Matrix.Columns {
+				view.Matrix.Columns = append(view.Matrix.Columns, StudentChoice{ID: column.ID, Text: column.Text})
+			}
+		},
+		AnswerKey: func(q entity.Question, key *AnswerKey) {
+			if q.Matrix == nil {
+				return
+			}
+			for _, row := range q.Matrix.Rows {
+				if !row.CorrectColumn.IsZero() {
+					key.Matrix = append(key.Matrix, entity.MatrixAnswer{RowID: row.ID, ColumnID: row.CorrectColumn})
+				}
+			}
+		},
+		// Columns keep their order, they are often a scale
+		Shuffle: func(r *rand.Rand, q entity.Question, perm *entity.QuestionPermutation) {
+			if q.Matrix == nil {
+				return
+			}
+			perm.MatrixRows = collectIDs(q.Matrix.Rows, matrixRowID)
+			pkg.Shuffle(r, perm.MatrixRows)
+		},
+		Reorder: func(q *entity.Question, perm entity.QuestionPermutation) {
+			if q.Matrix == nil {
+				return
+			}
+			matrix := *q.Matrix
+			matrix.Rows = reorderByID(matrix.Rows, perm.MatrixRows, matrixRowID)
+			q.Matrix = &matrix
+		},
+		Grade: gradeMatrix,
+	})
+}
+
+func matrixRowID(m entity.MatrixRow) primitive.ObjectID { return m.ID }
+
+func validateMatrix(q entity.Question, errs *entity.ValidationErrors) {
+	if q.Matrix == nil || len(q.Matrix.Rows) == 0 || len(q.Matrix.Columns) < 2 {
+		errs.Add("matrix", "matrix question needs rows and at least two columns")
+		return
+	}
+	columns := make(map[primitive.ObjectID]struct{}, len(q.Matrix.Columns))
+	for _, column := range q.Matrix.Columns {
+		columns[column.ID] = struct{}{}
+	}
+	for i, row := range q.Matrix.Rows {
+		if row.CorrectColumn.IsZero() {
+			continue
+		}
+		if _, ok := columns[row.CorrectColumn]; !ok {
+			errs.Add(fmt.Sprintf("matrix.rows[%d].correct_column", i), "points to an unknown column")
+		}
+	}
+}
+
+// gradeMatrix has a part per row. Rows without a correct column are right once answered.
+func gradeMatrix(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	if question.Matrix == nil {
+		return nil
+	}
+	picked := make(map[primitive.ObjectID]primitive.ObjectID, len(response.Matrix))
+	for _, answer := range response.Matrix {
+		picked[answer.RowID] = answer.ColumnID
+	}
+
+	parts := make([]gradedPart, 0, len(question.Matrix.Rows))
+	for _, row := range question.Matrix.Rows {
+		part := gradedPart{state: partBlank, weight: partWeight(row.Weight)}
+		if column, ok := picked[row.ID]; ok && !column.IsZero() {
+			part.state = partWrong
+			if row.CorrectColumn.IsZero() || row.CorrectColumn == column {
+				part.state = partRight
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
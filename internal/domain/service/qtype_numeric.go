@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeNumeric,
+		DefaultScoring: entity.ScoringAllOrNothing,
+		Validate:       validateNumeric,
+		Sanitize: func(q entity.Question, view *StudentQuestion) {
+			if q.Numeric == nil {
+				return
+			}
+			if q.Numeric.Unit != "" {
+				view.Units = append(view.Units, q.Numeric.Unit)
+			}
+			for _, unit := range q.Numeric.Units {
+				view.Units = append(view.Units, unit.Name)
+			}
+		},
+		AnswerKey: func(q entity.Question, key *AnswerKey) {
+			key.Numeric = q.Numeric
+		},
+		Grade: gradeNumeric,
+	})
+}
+
+func validateNumeric(q entity.Question, errs *entity.ValidationErrors) {
+	if q.Numeric == nil {
+		errs.Add("numeric", "numeric question needs an answer")
+		return
+	}
+	if q.Numeric.Tolerance < 0 {
+		errs.Add("numeric.tolerance", "cannot be negative")
+	}
+	for i, unit := range q.Numeric.Units {
+		if unit.Name == "" || unit.Factor <= 0 {
+			errs.Add(fmt.Sprintf("numeric.units[%d]", i), "unit needs a name and a positive factor")
+		}
+	}
+	if q.Numeric.RequireUnit && q.Numeric.Unit == "" {
+		errs.Add("numeric.unit", "is required when require_unit is set")
+	}
+}
+
+// gradeNumeric has one part: the value in response.Text, converted to the base unit.
+func gradeNumeric(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	part := gradedPart{state: partBlank, weight: 1}
+	if question.Numeric == nil || strings.TrimSpace(response.Text) == "" {
+		return []gradedPart{part}
+	}
+	part.state = partWrong
+	if MatchNumericAnswer(*question.Numeric, response.Text) {
+		part.state = partRight
+	}
+	return []gradedPart{part}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeOrder,
+		Aliases:        []string{"ordering_question"},
+		DefaultScoring: entity.ScoringAllOrNothing,
+		Validate:       validateOrder,
+		AssignIDs: func(q *entity.Question) {
+			for i := range q.OrderItems {
+				assignID(&q.OrderItems[i].ID)
+			}
+		},
+		Sanitize: func(q entity.Question, view *StudentQuestion) {
+			for _, item := range q.OrderItems {
+				view.OrderItems = append(view.OrderItems, StudentChoice{ID: item.ID, Text: item.Text})
+			}
+		},
+		AnswerKey: func(q entity.Question, key *AnswerKey) {
+			for _, item := range sortedOrderItems(q.OrderItems) {
+				key.Options = append(key.Options, item.ID)
+			}
+		},
+		Shuffle: func(r *rand.Rand, q entity.Question, perm *entity.QuestionPermutation) {
+			perm.OrderItems = collectIDs(q.OrderItems, orderItemID)
+			pkg.Shuffle(r, perm.OrderItems)
+		},
+		Reorder: func(q *entity.Question, perm entity.QuestionPermutation) {
+			q.OrderItems = reorderByID(q.OrderItems, perm.OrderItems, orderItemID)
+		},
+		Grade: gradeOrderQuestion,
+	})
+}
+
+// validateOrder needs distinct positions without gaps, e.g. 1, 2, 3.
+func validateOrder(q entity.Question, errs *entity.ValidationErrors) {
+	if len(q.OrderItems) < 2 {
+		errs.Add("order_items", "needs at least two items")
+		return
+	}
+	seen := make(map[int]int, len(q.OrderItems))
+	lowest := q.OrderItems[0].Order
+	for i, item := range q.OrderItems {
+		field := fmt.Sprintf("order_items[%d]", i)
+		if item.Text == "" {
+			errs.Add(field+".text", "is required")
+		}
+		if item.Weight < 0 {
+			errs.Add(field+".weight", "cannot be negative")
+		}
+		if first, ok := seen[item.Order]; ok {
+			errs.Add(field+".order", "duplicates the order of order_items[%d]", first)
+			continue
+		}
+		seen[item.Order] = i
+		lowest = min(lowest, item.Order)
+	}
+	if len(seen) != len(q.OrderItems) {
+		return
+	}
+	if lowest != 0 && lowest != 1 {
+		errs.Add("order_items", "order must start at 0 or 1")
+		return
+	}
+	for order := lowest; order < lowest+len(q.OrderItems); order++ {
+		if _, ok := seen[order]; !ok {
+			errs.Add("order_items", "order has a gap at %d", order)
+			return
+		}
+	}
+}
+
+func orderItemID(o entity.OrderItem) primitive.ObjectID { return o.ID }
+
+// gradeOrderQuestion has a part per position of the expected order.
+// The student's order is the order of response.Options.
+func gradeOrderQuestion(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	expected := sortedOrderItems(question.OrderItems)
+	parts := make([]gradedPart, 0, len(expected))
+	for i, item := range expected {
+		part := gradedPart{state: partBlank, weight: partWeight(item.Weight)}
+		if i < len(response.Options) {
+			part.state = partWrong
+			if response.Options[i].ID == item.ID {
+				part.state = partRight
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// sortedOrderItems returns a copy of the items sorted by their expected position.
+func sortedOrderItems(items []entity.OrderItem) []entity.OrderItem {
+	sorted := make([]entity.OrderItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+	return sorted
+}
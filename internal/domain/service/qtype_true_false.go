@@ -0,0 +1,34 @@
+package service
+
+import (
+	entity "quiz-app/internal/domain/entities"
+)
+
+func init() {
+	RegisterQuestionType(QuestionType{
+		Name:           entity.QuestionTypeTrueFalse,
+		DefaultScoring: entity.ScoringAllOrNothing,
+		Validate: func(q entity.Question, errs *entity.ValidationErrors) {
+			if q.TrueFalse == nil {
+				errs.Add("true_false", "true/false question needs an answer")
+			}
+		},
+		AnswerKey: func(q entity.Question, key *AnswerKey) {
+			key.TrueFalse = q.TrueFalse
+		},
+		Grade: gradeTrueFalse,
+	})
+}
+
+// gradeTrueFalse has one part: the true/false answer.
+func gradeTrueFalse(question entity.Question, response entity.QuestionAnswer) []gradedPart {
+	part := gradedPart{state: partBlank, weight: 1}
+	if question.TrueFalse == nil || response.TrueFalse == nil {
+		return []gradedPart{part}
+	}
+	part.state = partWrong
+	if *question.TrueFalse == *response.TrueFalse {
+		part.state = partRight
+	}
+	return []gradedPart{part}
+}
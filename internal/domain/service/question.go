@@ -4,6 +4,8 @@ import (
 	"context"
 	entity "quiz-app/internal/domain/entities"
 	"quiz-app/internal/domain/repository"
+	"slices"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,23 +13,86 @@ import (
 
 type QuestionUseCase struct {
 	QuestionRepo repository.QuestionRepository
+	VersionRepo  repository.QuestionVersionRepository
 }
 
-func NewQuestionUseCase(tr repository.QuestionRepository) *QuestionUseCase {
+func NewQuestionUseCase(tr repository.QuestionRepository, vr repository.QuestionVersionRepository) *QuestionUseCase {
 	return &QuestionUseCase{
 		QuestionRepo: tr,
+		VersionRepo:  vr,
 	}
 }
 
-// CreateQuestion creates a new question
+// CreateQuestion validates and creates a new question.
+// A question that breaks the rules of its type returns entity.ValidationErrors.
 func (uc *QuestionUseCase) CreateQuestion(ctx context.Context, question *entity.Question) (any, error) {
+	if err := PrepareQuestion(question); err != nil {
+		return nil, err
+	}
+	question.Version = 1
 	newQuestion, err := uc.QuestionRepo.CreateQuestion(ctx, question)
 	if err != nil {
 		return nil, err
 	}
+	if err := uc.snapshot(ctx, *question, question.Metadata.Author, 0); err != nil {
+		return nil, err
+	}
 	return newQuestion, nil
 }
 
+// CopyQuestions copies the author's questions of ids as new questions at version 1 and
+// returns the new ID of each copied one. Questions of other authors are not copied.
+func (uc *QuestionUseCase) CopyQuestions(ctx context.Context, author string, ids []primitive.ObjectID) (map[primitive.ObjectID]primitive.ObjectID, error) {
+	copied := make(map[primitive.ObjectID]primitive.ObjectID, len(ids))
+	if len(ids) == 0 {
+		return copied, nil
+	}
+	questions, err := uc.QuestionRepo.GetQuestionsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	copies := make([]entity.Question, 0, len(questions))
+	for _, question := range questions {
+		if question.Metadata.Author != author || copied[question.ID] != primitive.NilObjectID {
+			continue
+		}
+		newID := primitive.NewObjectID()
+		copied[question.ID] = newID
+		question.ID = newID
+		question.Version = 1
+		question.Created_At = now
+		question.Updated_At = now
+		copies = append(copies, question)
+	}
+	if len(copies) == 0 {
+		return copied, nil
+	}
+	if err := uc.createQuestions(ctx, author, copies); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// createQuestions inserts new questions in one batch, each with its version 1 snapshot.
+func (uc *QuestionUseCase) createQuestions(ctx context.Context, author string, questions []entity.Question) error {
+	if err := uc.QuestionRepo.CreateQuestions(ctx, questions); err != nil {
+		return err
+	}
+	versions := make([]entity.QuestionVersion, len(questions))
+	for i, question := range questions {
+		versions[i] = entity.QuestionVersion{
+			QuestionID: question.ID,
+			Version:    question.Version,
+			Question:   question,
+			EditedBy:   author,
+			CreatedAt:  question.Created_At,
+		}
+	}
+	return uc.VersionRepo.CreateVersions(ctx, versions)
+}
+
 // GetQuestionByID retrieves a question by its ID
 func (uc *QuestionUseCase) GetAllQuestionsByUser(ctx context.Context, userID string, limit, page int) ([]any, error) {
 	return uc.QuestionRepo.GetAllQuestionsByUser(ctx, userID, limit, page)
@@ -38,9 +103,45 @@ func (uc *QuestionUseCase) GetAllTestQuestions(ctx context.Context, question_ids
 	return uc.QuestionRepo.GetAllQuestions(ctx, question_ids)
 }
 
-// UpdateQuestion updates an existing question
-func (uc *QuestionUseCase) UpdateQuestion(ctx context.Context, question *entity.Question) (any, error) {
-	return uc.QuestionRepo.UpdateQuestion(ctx, question)
+// Page sizes of SearchQuestions.
+const (
+	defaultQuestionPageSize = 50
+	maxQuestionPageSize     = 200
+)
+
+// SearchQuestions returns one page of the author's question bank.
+// An invalid cursor returns entity.ErrInvalidCursor.
+func (uc *QuestionUseCase) SearchQuestions(ctx context.Context, query entity.QuestionQuery) (entity.QuestionPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultQuestionPageSize
+	}
+	query.Limit = min(query.Limit, maxQuestionPageSize)
+	if query.SortBy == "" {
+		query.SortBy = entity.QuestionSortCreated
+		query.Desc = true
+	}
+	// Legacy type names are stored too, search them with the canonical one
+	var types []string
+	for _, name := range query.Types {
+		names := []string{name}
+		if qt, ok := LookupQuestionType(name); ok {
+			names = append([]string{qt.Name}, qt.Aliases...)
+		}
+		for _, n := range names {
+			if !slices.Contains(types, n) {
+				types = append(types, n)
+			}
+		}
+	}
+	query.Types = types
+	return uc.QuestionRepo.SearchQuestions(ctx, query)
+}
+
+// UpdateQuestion validates and replaces an existing question of Metadata.Author, see CreateQuestion.
+// The new content becomes the next version, recorded as edited by editedBy; earlier versions
+// stay available, see ListVersions. Callers check the editor's permission first.
+func (uc *QuestionUseCase) UpdateQuestion(ctx context.Context, editedBy string, question *entity.Question) (any, error) {
+	return uc.saveVersion(ctx, question, editedBy, 0)
 }
 
 // DeleteQuestion deletes a question by ID
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Formats accepted by ImportQuestions.
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatGIFT  = "gift"  // Moodle GIFT
+	ImportFormatAiken = "aiken" // Moodle Aiken, single choice only
+)
+
+// MaxImportQuestions caps the questions of one import.
+const MaxImportQuestions = 1000
+
+var ErrUnknownImportFormat = errors.New("unknown import format")
+
+// ImportError is a problem with the question starting at Line of the imported file.
+type ImportError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult reports what an import did, or would do in a dry run.
+type ImportResult struct {
+	DryRun    bool              `json:"dry_run"`
+	Parsed    int               `json:"parsed"`
+	Imported  int               `json:"imported"`
+	Errors    []ImportError     `json:"errors"`
+	Questions []entity.Question `json:"questions"` // The valid questions, with their new IDs unless DryRun
+}
+
+// parsedQuestion is a question read from an import file, before validation.
+type parsedQuestion struct {
+	Line     int
+	Question entity.Question
+}
+
+// questionParsers read the import formats. Errors are reported per question so
+// one bad question does not stop the rest of the file.
+var questionParsers = map[string]func(io.Reader) ([]parsedQuestion, []ImportError){
+	ImportFormatCSV:   parseCSVQuestions,
+	ImportFormatGIFT:  parseGIFTQuestions,
+	ImportFormatAiken: parseAikenQuestions,
+}
+
+// ImportQuestions parses a file of questions for author, validates each one and inserts
+// the valid ones in one batch. With dryRun nothing is stored.
+func (uc *QuestionUseCase) ImportQuestions(ctx context.Context, author, format string, r io.Reader, dryRun bool) (*ImportResult, error) {
+	parse, ok := questionParsers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownImportFormat, format)
+	}
+
+	parsed, parseErrors := parse(r)
+	return uc.importParsed(ctx, author, parsed, parseErrors, dryRun)
+}
+
+// importParsed validates the parsed questions and inserts the valid ones with their first version.
+// Questions get a new ID unless the caller already set one.
+func (uc *QuestionUseCase) importParsed(ctx context.Context, author string, parsed []parsedQuestion, parseErrors []ImportError, dryRun bool) (*ImportResult, error) {
+	result := &ImportResult{DryRun: dryRun, Parsed: len(parsed), Errors: parseErrors, Questions: []entity.Question{}}
+	if len(parsed) > MaxImportQuestions {
+		result.Errors = append(result.Errors, ImportError{
+			Line:    parsed[MaxImportQuestions].Line,
+			Message: fmt.Sprintf("an import holds at most %d questions", MaxImportQuestions),
+		})
+		parsed = parsed[:MaxImportQuestions]
+	}
+
+	now := time.Now()
+	for _, p := range parsed {
+		question := p.Question
+		question.Metadata.Author = author
+		if question.Score == 0 {
+			question.Score = 1
+		}
+		if err := PrepareQuestion(&question); err != nil {
+			var invalid entity.ValidationErrors
+			if !errors.As(err, &invalid) {
+				return nil, err
+			}
+			for _, fe := range invalid {
+				result.Errors = append(result.Errors, ImportError{Line: p.Line, Field: fe.Field, Message: fe.Message})
+			}
+			continue
+		}
+		if question.ID.IsZero() {
+			question.ID = primitive.NewObjectID()
+		}
+		question.Version = 1
+		question.Created_At = now
+		question.Updated_At = now
+		result.Questions = append(result.Questions, question)
+	}
+
+	if dryRun || len(result.Questions) == 0 {
+		return result, nil
+	}
+	if err := uc.createQuestions(ctx, author, result.Questions); err != nil {
+		return nil, err
+	}
+	result.Imported = len(result.Questions)
+	return result, nil
+}
+
+// choiceQuestion builds a single or multiple choice question, depending on how many options are correct.
+func choiceQuestion(text string, options []string, correct []bool) entity.Question {
+	question := entity.Question{Type: entity.QuestionTypeSingleChoice, QuestionContent: entity.QuestionContent{Text: text}}
+	count := 0
+	for i, option := range options {
+		question.Options = append(question.Options, entity.Option{Text: option, IsCorrect: correct[i]})
+		if correct[i] {
+			count++
+		}
+	}
+	if count > 1 {
+		question.Type = entity.QuestionTypeMultipleChoice
+	}
+	return question
+}
+
+// matchQuestion builds a match question from (item, option) pairs. Items get their IDs
+// here because the options reference them.
+func matchQuestion(text string, pairs [][2]string) entity.Question {
+	question := entity.Question{Type: entity.QuestionTypeMatchChoice, QuestionContent: entity.QuestionContent{Text: text}}
+	for _, pair := range pairs {
+		item := entity.MatchItem{ID: primitive.NewObjectID(), Text: pair[0]}
+		question.MatchItems = append(question.MatchItems, item)
+		question.MatchOptions = append(question.MatchOptions, entity.MatchOption{Text: pair[1], MatchId: item.ID.Hex()})
+	}
+	return question
+}
+
+// orderQuestion builds an order question from the items in their correct order.
+func orderQuestion(text string, items []string) entity.Question {
+	question := entity.Question{Type: entity.QuestionTypeOrder, QuestionContent: entity.QuestionContent{Text: text}}
+	for i, item := range items {
+		question.OrderItems = append(question.OrderItems, entity.OrderItem{Text: item, Order: i + 1})
+	}
+	return question
+}
+
+// blankPattern marks a blank inside an imported question text.
+const blankPattern = "___"
+
+// blankQuestion builds a fill-in-the-blank question. The text has one blankPattern per
+// entry of accepted, each entry lists the accepted answers of that blank.
+func blankQuestion(text string, accepted [][]string) (entity.Question, error) {
+	parts := strings.Split(text, blankPattern)
+	if len(parts)-1 != len(accepted) {
+		return entity.Question{}, fmt.Errorf("text has %d blanks but %d answers", len(parts)-1, len(accepted))
+	}
+	question := entity.Question{Type: entity.QuestionTypeFillInTheBlank, QuestionContent: entity.QuestionContent{Text: text}}
+	for i, answers := range accepted {
+		blank := entity.FillInTheBlank{TextBefore: strings.TrimLeft(parts[i], "_"), TextAfter: strings.TrimLeft(parts[i+1], "_")}
+		if i+1 < len(accepted) {
+			blank.TextAfter = ""
+		}
+		if len(answers) > 0 {
+			blank.CorrectAnswer = answers[0]
+			blank.AcceptedAnswers = answers[1:]
+		}
+		question.FillInTheBlanks = append(question.FillInTheBlanks, blank)
+	}
+	return question, nil
+}
+
+// splitList splits s on sep and drops the empty items.
+func splitList(s, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
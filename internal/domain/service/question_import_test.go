@@ -0,0 +1,258 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+// describeImported renders the parts of a question that the parsers fill, without the IDs.
+// Correct options are marked with "*", blanks are written as [answer|alternative].
+func describeImported(q entity.Question) string {
+	parts := []string{q.Type, q.QuestionContent.Text}
+	var details []string
+	for _, option := range q.Options {
+		detail := option.Text
+		if option.IsCorrect {
+			detail = "*" + detail
+		}
+		if option.Weight != 0 {
+			detail += fmt.Sprintf("(%g)", option.Weight)
+		}
+		details = append(details, detail)
+	}
+	if q.TrueFalse != nil {
+		details = append(details, fmt.Sprint(*q.TrueFalse))
+	}
+	if q.Numeric != nil {
+		details = append(details, strings.TrimSpace(fmt.Sprintf("%g±%g %s", q.Numeric.Value, q.Numeric.Tolerance, q.Numeric.Unit)))
+	}
+	if len(q.FillInTheBlanks) > 0 {
+		var text string
+		for _, blank := range q.FillInTheBlanks {
+			text += blank.TextBefore + "[" + strings.Join(blank.AcceptedValues(), "|") + "]" + blank.TextAfter
+		}
+		details = append(details, text)
+	}
+	for _, item := range q.OrderItems {
+		details = append(details, fmt.Sprintf("%d.%s", item.Order, item.Text))
+	}
+	for _, item := range q.MatchItems {
+		for _, option := range q.MatchOptions {
+			if option.MatchId == item.ID.Hex() {
+				details = append(details, item.Text+"->"+option.Text)
+			}
+		}
+	}
+	if len(details) > 0 {
+		parts = append(parts, strings.Join(details, ", "))
+	}
+	if len(q.Tags) > 0 {
+		parts = append(parts, "tags "+strings.Join(q.Tags, "|"))
+	}
+	if q.Score != 0 || q.Difficulty != "" {
+		parts = append(parts, fmt.Sprintf("score %g %s", q.Score, q.Difficulty))
+	}
+	return strings.Join(parts, " / ")
+}
+
+type importCase struct {
+	name   string
+	input  string
+	want   []string // "line: description" of each question
+	errors []string // "line field" of each error
+}
+
+func runImportCases(t *testing.T, parse func(io.Reader) ([]parsedQuestion, []ImportError), tests []importCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions, importErrors := parse(strings.NewReader(tt.input))
+
+			var got []string
+			for _, p := range questions {
+				got = append(got, fmt.Sprintf("%d: %s", p.Line, describeImported(p.Question)))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("questions:\n got %q\nwant %q", got, tt.want)
+			}
+
+			var gotErrors []string
+			for _, e := range importErrors {
+				gotErrors = append(gotErrors, strings.TrimSpace(fmt.Sprintf("%d %s", e.Line, e.Field)))
+			}
+			if !slices.Equal(gotErrors, tt.errors) {
+				t.Errorf("errors:\n got %q\nwant %q (%v)", gotErrors, tt.errors, importErrors)
+			}
+		})
+	}
+}
+
+func TestParseGIFTQuestions(t *testing.T) {
+	runImportCases(t, parseGIFTQuestions, []importCase{
+		{
+			name:  "single choice with title",
+			input: "::Q1:: What is 2 + 2? {=4 ~3 ~5}",
+			want:  []string{"1: single_choice_question / What is 2 + 2? / *4, 3, 5"},
+		},
+		{
+			name:  "multiple choice with weights",
+			input: "Pick the primes {~%50%2 ~%50%3 ~%-100%4}",
+			want:  []string{"1: multiple_choice_question / Pick the primes / *2(0.5), *3(0.5), 4"},
+		},
+		{
+			name:  "true and false",
+			input: "The sky is blue {T}\n\nThe sun is cold {FALSE}",
+			want: []string{
+				"1: true_false_question / The sky is blue / true",
+				"3: true_false_question / The sun is cold / false",
+			},
+		},
+		{
+			name:  "short answer",
+			input: "The capital of France is {=Paris =paris}.",
+			want:  []string{"1: fill_in_the_blank / The capital of France is ___ . / The capital of France is [Paris|paris] ."},
+		},
+		{
+			name:  "matching",
+			input: "Match the sounds {=cat -> meo =dog -> gâu}",
+			want:  []string{"1: match_choice_question / Match the sounds / cat->meo, dog->gâu"},
+		},
+		{
+			name:  "numeric",
+			input: "Pi? {#3.14:0.01}\n\nA number from 1 to 5 {#1..5}\n\nYear? {#=1822:0 =%50%1822:2}",
+			want: []string{
+				"1: numeric_question / Pi? / 3.14±0.01",
+				"3: numeric_question / A number from 1 to 5 / 3±2",
+				"5: numeric_question / Year? / 1822±0",
+			},
+		},
+		{
+			name:  "essay",
+			input: "Describe your summer {}",
+			want:  []string{"1: essay_question / Describe your summer"},
+		},
+		{
+			name:  "comments, category, feedback and escapes",
+			input: "// bank of questions\n$CATEGORY: $course$/top/math\n\n1 \\= 1? {T#right}\n",
+			want:  []string{"4: true_false_question / 1 = 1? / true / tags math"},
+		},
+		{
+			name:  "answer block over several lines",
+			input: "Which are fruits? {\n~%50%apple\n\n~%50%mango\n~carrot\n}\n\nNext {T}",
+			want: []string{
+				"1: multiple_choice_question / Which are fruits? / *apple(0.5), *mango(0.5), carrot",
+				"8: true_false_question / Next / true",
+			},
+		},
+		{
+			name:   "errors do not stop the file",
+			input:  "No answer block\n\nGood {T}\n\nBad range {#5..1}\n\nNot closed {=a",
+			want:   []string{"3: true_false_question / Good / true"},
+			errors: []string{"1", "5", "7"},
+		},
+	})
+}
+
+func TestParseAikenQuestions(t *testing.T) {
+	runImportCases(t, parseAikenQuestions, []importCase{
+		{
+			name:  "two questions",
+			input: "What is 2 + 2?\nA. 3\nB. 4\nANSWER: B\n\nWhat is 3 + 3?\nA) 6\nB) 9\nANSWER: a\n",
+			want: []string{
+				"1: single_choice_question / What is 2 + 2? / 3, *4",
+				"6: single_choice_question / What is 3 + 3? / *6, 9",
+			},
+		},
+		{
+			name:  "no blank line, text over several lines",
+			input: "Q1\nA. x\nB. y\nANSWER: A\nA long\nquestion\nA. x\nB. y\nANSWER: B",
+			want: []string{
+				"1: single_choice_question / Q1 / *x, y",
+				"5: single_choice_question / A long\nquestion / x, *y",
+			},
+		},
+		{
+			name:   "answer is not an option",
+			input:  "Q\nA. x\nB. y\nANSWER: C\n\nQ2\nA. x\nB. y\nANSWER: A",
+			want:   []string{"6: single_choice_question / Q2 / *x, y"},
+			errors: []string{"1"},
+		},
+		{
+			name:   "stray line between the options",
+			input:  "Q\nA. x\nnot an option\nB. y\nANSWER: A",
+			want:   []string{"1: single_choice_question / Q / *x, y"},
+			errors: []string{"3"},
+		},
+		{
+			name:   "missing answer line",
+			input:  "Q\nA. x\nANSWER: A\n\nLast question\nA. x\nB. y\n",
+			want:   []string{"1: single_choice_question / Q / *x"},
+			errors: []string{"5"},
+		},
+	})
+}
+
+func TestParseCSVQuestions(t *testing.T) {
+	runImportCases(t, parseCSVQuestions, []importCase{
+		{
+			name:   "empty file",
+			input:  "",
+			errors: []string{"1"},
+		},
+		{
+			name:   "missing column",
+			input:  "type,options\nessay_question,\n",
+			errors: []string{"1 question"},
+		},
+		{
+			name: "every type",
+			input: "\ufeffType,Question,Options,Answer,Score,Difficulty,Tolerance,Unit,Tags\n" +
+				"single_choice_question,2 + 2?,3|4|5,B,2,Easy,,,math|add\n" +
+				"multiple_choice_question,Primes?,2|3|4,1|2,,,,,\n" +
+				"multiple_choice_multiple,Only one is right,x|y,2,,,,,\n" +
+				"true_false_question,Sky is blue,,TRUE,,,,,\n" +
+				"numeric_question,Height?,,\"1,75\",,,0.05,m,\n" +
+				"fill_in_the_blank,___ and ___,,Ha Noi|Hà Nội;Huế,,,,,\n" +
+				"ordering_question,Order them,one|two|three,,,,,,\n" +
+				"match_choice_question,Match,cat=meo|dog=gâu,,,,,,\n" +
+				",,,,,,,,\n" +
+				"essay_question,Write,,,,,,,\n",
+			want: []string{
+				"2: single_choice_question / 2 + 2? / 3, *4, 5 / tags math|add / score 2 easy",
+				"3: multiple_choice_question / Primes? / *2, *3, 4",
+				"4: multiple_choice_question / Only one is right / x, *y",
+				"5: true_false_question / Sky is blue / true",
+				"6: numeric_question / Height? / 1.75±0.05 m",
+				"7: fill_in_the_blank / ___ and ___ / [Ha Noi|Hà Nội] and [Huế]",
+				"8: order_question / Order them / 1.one, 2.two, 3.three",
+				"9: match_choice_question / Match / cat->meo, dog->gâu",
+				"11: essay_question / Write",
+			},
+		},
+		{
+			name: "row errors",
+			input: "type,question,options,answer,score\n" +
+				"unknown,Q,,,\n" +
+				"single_choice_question,Q,a|b,3,\n" +
+				"true_false_question,Q,,maybe,\n" +
+				"fill_in_the_blank,One ___,,a;b,\n" +
+				"match_choice_question,Q,cat|dog,,\n" +
+				"hotspot_question,Q,,,\n" +
+				"essay_question,Q,,,lots\n" +
+				"essay_question,Still imported,,,\n",
+			want:   []string{"9: essay_question / Still imported"},
+			errors: []string{"2 type", "3 answer", "4 answer", "5 answer", "6 options", "7 type", "8 score"},
+		},
+		{
+			name:   "broken quote",
+			input:  "type,question\nessay_question,\"Q\"x\"\nessay_question,Fine\n",
+			want:   []string{"3: essay_question / Fine"},
+			errors: []string{"2"},
+		},
+	})
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuestionType describes everything the application does with one type of question.
+// Every type lives in its own qtype_*.go file and registers itself in init.
+// Hooks left nil mean the type has nothing to do at that step.
+type QuestionType struct {
+	Name    string
+	Aliases []string // Legacy names still found in stored questions
+
+	ManualGrading  bool   // Scored by a teacher through the grading queue
+	DefaultScoring string // Scoring mode used when the question sets none
+
+	Validate  func(q entity.Question, errs *entity.ValidationErrors) // Adds the broken type rules to errs
+	AssignIDs func(q *entity.Question)                               // Gives IDs to the parts that have none
+	Sanitize  func(q entity.Question, view *StudentQuestion)
+	AnswerKey func(q entity.Question, key *AnswerKey)
+	Shuffle   func(r *rand.Rand, q entity.Question, perm *entity.QuestionPermutation)
+	Reorder   func(q *entity.Question, perm entity.QuestionPermutation)
+	Grade     func(q entity.Question, response entity.QuestionAnswer) []gradedPart
+}
+
+var (
+	questionTypes       = make(map[string]*QuestionType)
+	questionTypeAliases = make(map[string]string)
+)
+
+// RegisterQuestionType adds a question type to the registry. Registering a name twice panics.
+func RegisterQuestionType(qt QuestionType) {
+	for _, name := range append([]string{qt.Name}, qt.Aliases...) {
+		if _, ok := questionTypeAliases[name]; ok {
+			panic(fmt.Sprintf("question type %q registered twice", name))
+		}
+		questionTypeAliases[name] = qt.Name
+	}
+	questionTypes[qt.Name] = &qt
+}
+
+// LookupQuestionType returns the registered type for a name or one of its aliases.
+func LookupQuestionType(name string) (*QuestionType, bool) {
+	canonical, ok := questionTypeAliases[name]
+	if !ok {
+		return nil, false
+	}
+	return questionTypes[canonical], true
+}
+
+// QuestionTypeNames returns the canonical names of the registered types.
+func QuestionTypeNames() []string {
+	names := make([]string, 0, len(questionTypes))
+	for name := range questionTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrepareQuestion normalizes a question before it is stored: a legacy type name is
+// replaced by the canonical one and missing IDs are assigned. It then checks the common
+// and the type-specific rules and returns every broken one as entity.ValidationErrors.
+func PrepareQuestion(q *entity.Question) error {
+	var errs entity.ValidationErrors
+	qt, ok := LookupQuestionType(q.Type)
+	if !ok {
+		errs.Add("type", "unknown question type %q", q.Type)
+		q.ValidateCommon(&errs)
+		return errs.Err()
+	}
+	q.Type = qt.Name
+	if qt.AssignIDs != nil {
+		qt.AssignIDs(q)
+	}
+	q.ValidateCommon(&errs)
+	if qt.Validate != nil {
+		qt.Validate(*q, &errs)
+	}
+	return errs.Err()
+}
+
+// assignID gives id a new value when it is empty.
+func assignID(id *primitive.ObjectID) {
+	if id.IsZero() {
+		*id = primitive.NewObjectID()
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrVersionNotFound  = errors.New("question version not found")
+	ErrVersionConflict  = errors.New("question was changed in the meantime, reload it and try again")
+)
+
+// QuestionDiff lists the fields changed between two versions of a question.
+type QuestionDiff struct {
+	QuestionID primitive.ObjectID   `json:"question_id"`
+	From       int                  `json:"from"`
+	To         int                  `json:"to"`
+	Changes    []entity.FieldChange `json:"changes"`
+}
+
+// ListVersions returns the version history of one of the author's questions, oldest first.
+func (uc *QuestionUseCase) ListVersions(ctx context.Context, author string, questionID primitive.ObjectID) ([]entity.QuestionVersion, error) {
+	current, err := uc.ownQuestion(ctx, author, questionID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := uc.VersionRepo.ListVersions(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	// Questions created before versioning have no snapshot until their first edit
+	if len(versions) == 0 {
+		versions = append(versions, entity.QuestionVersion{
+			QuestionID: current.ID,
+			Version:    current.CurrentVersion(),
+			Question:   *current,
+			EditedBy:   current.Metadata.Author,
+			CreatedAt:  current.Updated_At,
+		})
+	}
+	return versions, nil
+}
+
+// DiffVersions compares two versions of the author's question. A zero to compares with the current version.
+func (uc *QuestionUseCase) DiffVersions(ctx context.Context, author string, questionID primitive.ObjectID, from, to int) (*QuestionDiff, error) {
+	current, err := uc.ownQuestion(ctx, author, questionID)
+	if err != nil {
+		return nil, err
+	}
+	if to == 0 {
+		to = current.CurrentVersion()
+	}
+	before, err := uc.questionAt(ctx, *current, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := uc.questionAt(ctx, *current, to)
+	if err != nil {
+		return nil, err
+	}
+	return &QuestionDiff{QuestionID: questionID, From: from, To: to, Changes: DiffQuestions(before, after)}, nil
+}
+
+// RestoreVersion makes an earlier version the current content of the question again.
+// The restore is itself a new version, so it can be undone too.
+func (uc *QuestionUseCase) RestoreVersion(ctx context.Context, author string, questionID primitive.ObjectID, version int) (any, error) {
+	current, err := uc.ownQuestion(ctx, author, questionID)
+	if err != nil {
+		return nil, err
+	}
+	old, err := uc.questionAt(ctx, *current, version)
+	if err != nil {
+		return nil, err
+	}
+	old.ID = questionID
+	old.Metadata.Author = author
+	return uc.saveVersion(ctx, &old, author, version)
+}
+
+// saveVersion stores question as the next version of the stored question. The snapshot is
+// written first: its unique index lets only one of two concurrent edits through, the other
+// gets ErrVersionConflict, and the current content always has a history entry.
+func (uc *QuestionUseCase) saveVersion(ctx context.Context, question *entity.Question, editedBy string, restoredFrom int) (any, error) {
+	if err := PrepareQuestion(question); err != nil {
+		return nil, err
+	}
+	current, err := uc.ownQuestion(ctx, question.Metadata.Author, question.ID)
+	if err != nil {
+		return nil, err
+	}
+	// The content of a question from before versioning becomes version 1 on its first edit
+	if current.Version == 0 {
+		legacy := *current
+		legacy.Version = 1
+		if err := uc.snapshot(ctx, legacy, current.Metadata.Author, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	question.Version = current.CurrentVersion() + 1
+	question.Created_At = current.Created_At
+	question.Updated_At = time.Now()
+	if err := uc.snapshot(ctx, *question, editedBy, restoredFrom); err != nil {
+		return nil, err
+	}
+	updated, err := uc.QuestionRepo.UpdateQuestion(ctx, question)
+	if err != nil {
+		// Drop the snapshot so the version number can be used by the next edit
+		if derr := uc.VersionRepo.DeleteVersion(ctx, question.ID, question.Version); derr != nil {
+			log.Printf("question %s: %v", question.ID.Hex(), derr)
+		}
+		return nil, err
+	}
+	return updated, nil
+}
+
+// snapshot stores the question as a version, ErrVersionConflict when that version already exists.
+func (uc *QuestionUseCase) snapshot(ctx context.Context, question entity.Question, editedBy string, restoredFrom int) error {
+	err := uc.VersionRepo.CreateVersion(ctx, entity.QuestionVersion{
+		QuestionID:   question.ID,
+		Version:      question.CurrentVersion(),
+		Question:     question,
+		EditedBy:     editedBy,
+		CreatedAt:    time.Now(),
+		RestoredFrom: restoredFrom,
+	})
+	if errors.Is(err, repository.ErrDuplicateKey) {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+// ownQuestion loads the current question, ErrQuestionNotFound unless author wrote it.
+func (uc *QuestionUseCase) ownQuestion(ctx context.Context, author string, questionID primitive.ObjectID) (*entity.Question, error) {
+	if questionID.IsZero() {
+		return nil, ErrQuestionNotFound
+	}
+	questions, err := uc.QuestionRepo.GetQuestionsByIDs(ctx, []primitive.ObjectID{questionID})
+	if err != nil {
+		return nil, fmt.Errorf("load question: %w", err)
+	}
+	if len(questions) == 0 || questions[0].Metadata.Author != author {
+		return nil, ErrQuestionNotFound
+	}
+	return &questions[0], nil
+}
+
+// questionAt returns the content of the given version of current.
+func (uc *QuestionUseCase) questionAt(ctx context.Context, current entity.Question, version int) (entity.Question, error) {
+	if version == current.CurrentVersion() {
+		return current, nil
+	}
+	snapshot, err := uc.VersionRepo.GetVersion(ctx, current.ID, version)
+	if err != nil {
+		return entity.Question{}, err
+	}
+	if snapshot == nil {
+		return entity.Question{}, ErrVersionNotFound
+	}
+	return snapshot.Question, nil
+}
+
+// DiffQuestions compares the JSON fields of two questions, ignoring the bookkeeping
+// fields (ID, version, timestamps). Changes are sorted by field name.
+func DiffQuestions(before, after entity.Question) []entity.FieldChange {
+	a, b := questionFields(before), questionFields(after)
+	names := make(map[string]struct{}, len(a)+len(b))
+	for name := range a {
+		names[name] = struct{}{}
+	}
+	for name := range b {
+		names[name] = struct{}{}
+	}
+
+	changes := make([]entity.FieldChange, 0)
+	for name := range names {
+		switch name {
+		case "_id", "version", "created_at", "updated_at":
+			continue
+		}
+		if !reflect.DeepEqual(a[name], b[name]) {
+			changes = append(changes, entity.FieldChange{Field: name, Before: a[name], After: b[name]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+func questionFields(question entity.Question) map[string]any {
+	fields := map[string]any{}
+	data, err := json.Marshal(question)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// snapshotsFor loads the versions pinned on the attempts for the questions of ids edited or
+// deleted since; questions holds the current ones. Questions still at their pinned version need no snapshot.
+func (au *AnswerUseCase) snapshotsFor(ctx context.Context, attempts []entity.TestAnswer, ids []primitive.ObjectID, questions []entity.Question) (map[entity.QuestionVersionRef]entity.Question, error) {
+	wanted := make(map[primitive.ObjectID]struct{}, len(ids))
+	for _, id := range ids {
+		wanted[id] = struct{}{}
+	}
+	current := make(map[primitive.ObjectID]int, len(questions))
+	for _, question := range questions {
+		current[question.ID] = question.CurrentVersion()
+	}
+
+	var refs []entity.QuestionVersionRef
+	seen := make(map[entity.QuestionVersionRef]struct{})
+	for _, attempt := range attempts {
+		for _, ref := range attempt.QuestionVersions {
+			if _, ok := wanted[ref.QuestionID]; !ok {
+				continue
+			}
+			// Deleted questions are only left as snapshots
+			if version, ok := current[ref.QuestionID]; ok && version == ref.Version {
+				continue
+			}
+			if _, dup := seen[ref]; !dup {
+				seen[ref] = struct{}{}
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	snapshots := make(map[entity.QuestionVersionRef]entity.Question, len(refs))
+	if len(refs) == 0 {
+		return snapshots, nil
+	}
+	versions, err := au.versionRepo.GetVersions(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("load question versions: %w", err)
+	}
+	for _, v := range versions {
+		snapshots[entity.QuestionVersionRef{QuestionID: v.QuestionID, Version: v.Version}] = v.Question
+	}
+	return snapshots, nil
+}
+
+// pinnedQuestion returns the question as the attempt was shown it: its snapshot when it was edited
+// or deleted since, otherwise the current question from live. It reports false when there is neither.
+func pinnedQuestion(attempt entity.TestAnswer, id primitive.ObjectID, live map[primitive.ObjectID]entity.Question, snapshots map[entity.QuestionVersionRef]entity.Question) (entity.Question, bool) {
+	ref := entity.QuestionVersionRef{QuestionID: id, Version: attempt.VersionOf(id)}
+	if snapshot, ok := snapshots[ref]; ok {
+		snapshot.ID = id
+		return snapshot, true
+	}
+	question, ok := live[id]
+	return question, ok
+}
+
+// pinQuestions returns the questions of ids in the version pinned when the attempt started.
+// questions holds the current ones; questions deleted since come back from their snapshot.
+func (au *AnswerUseCase) pinQuestions(ctx context.Context, attempt entity.TestAnswer, ids []primitive.ObjectID, questions []entity.Question) ([]entity.Question, error) {
+	snapshots, err := au.snapshotsFor(ctx, []entity.TestAnswer{attempt}, ids, questions)
+	if err != nil {
+		return nil, err
+	}
+	live := questionsByID(questions)
+	pinned := make([]entity.Question, 0, len(ids))
+	for _, id := range ids {
+		if question, ok := pinnedQuestion(attempt, id, live, snapshots); ok {
+			pinned = append(pinned, question)
+		}
+	}
+	return pinned, nil
+}
+
+func questionsByID(questions []entity.Question) map[primitive.ObjectID]entity.Question {
+	byID := make(map[primitive.ObjectID]entity.Question, len(questions))
+	for _, question := range questions {
+		byID[question.ID] = question
+	}
+	return byID
+}
+
+// questionVersionRefs pins the current version of every question.
+func questionVersionRefs(questions []entity.Question) []entity.QuestionVersionRef {
+	refs := make([]entity.QuestionVersionRef, len(questions))
+	for i, question := range questions {
+		refs[i] = entity.QuestionVersionRef{QuestionID: question.ID, Version: question.CurrentVersion()}
+	}
+	return refs
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+func TestDeletedQuestionKeepsItsPinnedVersion(t *testing.T) {
+	ctx := context.Background()
+	env := newAnswerTestEnv()
+	env.test.ReviewPolicy = entity.ReviewImmediately
+	env.versions.versions = append(env.versions.versions, entity.QuestionVersion{
+		QuestionID: env.question.ID,
+		Version:    1,
+		Question:   env.question,
+	})
+	attempt := env.attempt(1, entity.AttemptSubmitted, time.Now().Add(-time.Hour))
+	env.answers.answers = append(env.answers.answers, attempt)
+
+	// Xóa câu hỏi sau khi bài làm đã ghim nó
+	if err := env.questions.DeleteQuestion(ctx, &env.question); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := env.uc.GradeAnswer(ctx, &attempt, env.test.QuestionIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalScore != 1 || result.MaxScore != 1 {
+		t.Errorf("grade = %v/%v, want 1/1", result.TotalScore, result.MaxScore)
+	}
+
+	review, err := env.uc.Review(ctx, env.class.ID, env.test.ID, attempt.ID, testStudentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(review.Questions) != 1 || review.Questions[0].ID != env.question.ID {
+		t.Fatalf("review questions = %+v, want the deleted question", review.Questions)
+	}
+	if review.Questions[0].Earned != 1 {
+		t.Errorf("earned = %v, want 1", review.Questions[0].Earned)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrReviewNotReleased = errors.New("answers are not released for review yet")
+
+// AttemptReview shows a closed attempt next to the answer key.
+type AttemptReview struct {
+	Answer    *entity.TestAnswer `json:"answer"`
+	Questions []ReviewQuestion   `json:"questions"`
+}
+
+// ReviewQuestion is a question as shown to the student, with their response,
+// the points earned and the question's suggestion text.
+type ReviewQuestion struct {
+	StudentQuestion
+	Response   *entity.QuestionAnswer `json:"response,omitempty"`
+	Earned     float32                `json:"earned"`
+	Suggestion []string               `json:"suggestion,omitempty"`
+}
+
+// Review returns the student's closed attempt with the correct answers, when the
+// test's review policy releases them. A zero attemptID reviews the latest closed attempt.
+func (au *AnswerUseCase) Review(ctx context.Context, classID, testID, attemptID primitive.ObjectID, emailID string) (*AttemptReview, error) {
+	var attempt *entity.TestAnswer
+	var err error
+	if attemptID.IsZero() {
+		attempt, err = au.GetResult(ctx, classID, testID, emailID)
+	} else {
+		attempt, err = au.findAttempt(ctx, entity.TestAnswer{ID: attemptID, EmailID: emailID})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attempt.IsOpen() {
+		return nil, ErrAttemptNotFound
+	}
+
+	if !attempt.ClassID.IsZero() {
+		classID = attempt.ClassID
+	}
+	test, err := au.studentTest(ctx, classID, attempt.TestId, attempt.Email)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !test.AnswersVisible(now) {
+		return nil, ErrReviewNotReleased
+	}
+
+	questions, err := au.attemptQuestions(ctx, attempt, test.QuestionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(map[primitive.ObjectID]*entity.QuestionAnswer, len(attempt.ListQuestionAnswer))
+	for i := range attempt.ListQuestionAnswer {
+		responses[attempt.ListQuestionAnswer[i].QuestionID] = &attempt.ListQuestionAnswer[i]
+	}
+
+	views := StudentQuestions(questions, *attempt, *test, now)
+	review := &AttemptReview{Answer: attempt, Questions: make([]ReviewQuestion, 0, len(views))}
+	for i, view := range views {
+		item := ReviewQuestion{
+			StudentQuestion: view,
+			Response:        responses[view.ID],
+			Suggestion:      questions[i].Suggestion,
+		}
+		if item.Response != nil {
+			item.Earned = item.Response.Score
+		}
+		review.Questions = append(review.Questions, item)
+	}
+	return review, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidRole = errors.New("role must be co_teacher, assistant or student")
+
+// RosterResult reports a roster change made for a list of emails.
+type RosterResult struct {
+	Members []entity.ClassMember `json:"members"` // Entries added or changed
+	Removed []string             `json:"removed,omitempty"`
+	Skipped []RosterSkip         `json:"skipped,omitempty"`
+}
+
+// RosterSkip is an email the change was not applied to, and why.
+type RosterSkip struct {
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
+
+// rosterChange returns the new entry of a member, nil to remove it, or a reason to skip it.
+// found is false for emails that are not on the roster yet.
+type rosterChange func(member entity.ClassMember, found bool) (*entity.ClassMember, string)
+
+// GetRoster lists the members of the class for users who may view it.
+func (uc *ClassUseCase) GetRoster(ctx context.Context, classID primitive.ObjectID, emailID, email string) ([]entity.ClassMember, error) {
+	class, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermView)
+	if err != nil {
+		return nil, err
+	}
+	return class.Roster(), nil
+}
+
+// AddMembers adds the emails as active members with the role, students by default.
+// Existing members and banned emails are skipped, remove them first.
+func (uc *ClassUseCase) AddMembers(ctx context.Context, classID primitive.ObjectID, emailID, email string, emails []string, role string) (*RosterResult, error) {
+	if role == "" {
+		role = entity.RoleStudent
+	}
+	if !entity.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	return uc.changeRoster(ctx, classID, emailID, email, emails, role, func(member entity.ClassMember, found bool) (*entity.ClassMember, string) {
+		if found {
+			return nil, "already on the roster as " + member.Status + " " + member.Role
+		}
+		if _, err := mail.ParseAddress(member.Email); err != nil {
+			return nil, "invalid email"
+		}
+		member.Role = role
+		member.Status = entity.MemberActive
+		return &member, ""
+	})
+}
+
+// ApproveMembers accepts students waiting to join.
+func (uc *ClassUseCase) ApproveMembers(ctx context.Context, classID primitive.ObjectID, emailID, email string, emails []string) (*RosterResult, error) {
+	return uc.changeRoster(ctx, classID, emailID, email, emails, "", func(member entity.ClassMember, found bool) (*entity.ClassMember, string) {
+		if !found || member.Status != entity.MemberPending {
+			return nil, "not waiting for approval"
+		}
+		member.Status = entity.MemberActive
+		return &member, ""
+	})
+}
+
+// RejectMembers turns down students waiting to join. They may ask again.
+func (uc *ClassUseCase) RejectMembers(ctx context.Context, classID primitive.ObjectID, emailID, email string, emails []string) (*RosterResult, error) {
+	return uc.changeRoster(ctx, classID, emailID, email, emails, "", func(member entity.ClassMember, found bool) (*entity.ClassMember, string) {
+		if !found || member.Status != entity.MemberPending {
+			return nil, "not waiting for approval"
+		}
+		return nil, ""
+	})
+}
+
+// RemoveMembers takes members off the roster, which also lifts a ban.
+func (uc *ClassUseCase) RemoveMembers(ctx context.Context, classID primitive.ObjectID, emailID, email string, emails []string) (*RosterResult, error) {
+	return uc.changeRoster(ctx, classID, emailID, email, emails, "", func(member entity.ClassMember, found bool) (*entity.ClassMember, string) {
+		if !found {
+			return nil, "not on the roster"
+		}
+		return nil, ""
+	})
+}
+
+// BanMembers removes the emails from the class and keeps them from joining again.
+// Emails that are not on the roster yet are banned too.
+func (uc *ClassUseCase) BanMembers(ctx context.Context, classID primitive.ObjectID, emailID, email string, emails []string) (*RosterResult, error) {
+	return uc.changeRoster(ctx, classID, emailID, email, emails, "", func(member entity.ClassMember, found bool) (*entity.ClassMember, string) {
+		if member.Status == entity.MemberBanned {
+			return nil, "already banned"
+		}
+		if !found {
+			member.Role = entity.RoleStudent
+		}
+		member.Status = entity.MemberBanned
+		return &member, ""
+	})
+}
+
+// SetMemberRole changes the role of an active member. Teachers and assistants are managed by the owner only.
+func (uc *ClassUseCase) SetMemberRole(ctx context.Context, classID primitive.ObjectID, emailID, email, memberEmail, role string) (*RosterResult, error) {
+	if !entity.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	return uc.changeRoster(ctx, classID, emailID, email, []string{memberEmail}, role, func(member entity.ClassMember, found bool) (*entity.ClassMember, string) {
+		if !found || member.Status != entity.MemberActive {
+			return nil, "not an active member"
+		}
+		member.Role = role
+		return &member, ""
+	})
+}
+
+// changeRoster applies change to every email for a user who may manage the roster. Teachers and
+// assistants, as well as the role given, can only be managed by the owner. The owner is never changed.
+func (uc *ClassUseCase) changeRoster(ctx context.Context, classID primitive.ObjectID, emailID, email string, emails []string, role string, change rosterChange) (*RosterResult, error) {
+	class, err := uc.authz.AuthorizeClass(ctx, classID, emailID, email, entity.PermManageRoster)
+	if err != nil {
+		return nil, err
+	}
+	isOwner := class.RoleOf(emailID, email) == entity.RoleOwner
+	if entity.IsStaffRole(role) && !isOwner {
+		return nil, ErrNotClassOwner
+	}
+
+	result := &RosterResult{Members: []entity.ClassMember{}}
+	seen := make(map[string]bool, len(emails))
+	for _, raw := range emails {
+		target := entity.NormalizeEmail(raw)
+		if target == "" || seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		member, found := class.Member(target)
+		if !found {
+			member = entity.ClassMember{Email: target}
+		}
+		switch {
+		case member.Role == entity.RoleOwner:
+			result.Skipped = append(result.Skipped, RosterSkip{Email: target, Reason: "the owner cannot be changed"})
+			continue
+		case found && entity.IsStaffRole(member.Role) && !isOwner:
+			result.Skipped = append(result.Skipped, RosterSkip{Email: target, Reason: "only the owner can change teachers"})
+			continue
+		}
+
+		updated, reason := change(member, found)
+		if reason != "" {
+			result.Skipped = append(result.Skipped, RosterSkip{Email: target, Reason: reason})
+			continue
+		}
+		if updated == nil {
+			if err := uc.repoClass.RemoveMember(ctx, classID, member.Email); err != nil {
+				return result, err
+			}
+			result.Removed = append(result.Removed, member.Email)
+			continue
+		}
+		if !found {
+			updated.AddedBy = email
+		}
+		updated.UpdatedAt = time.Now()
+		if err := uc.repoClass.SaveMember(ctx, classID, *updated); err != nil {
+			return result, err
+		}
+		result.Members = append(result.Members, *updated)
+	}
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewAttemptShuffle shuffles the question order and, through each type's Shuffle hook,
+// the choices of every question with a seed derived from the attempt ID. The questions are not modified.
+func NewAttemptShuffle(attemptID primitive.ObjectID, questions []entity.Question) *entity.AttemptShuffle {
+	seed := pkg.ShuffleSeed(attemptID)
+	r := pkg.NewSeededRand(seed)
+
+	shuffle := &entity.AttemptShuffle{
+		Seed:      seed,
+		Questions: make([]entity.QuestionPermutation, 0, len(questions)),
+	}
+	for _, question := range questions {
+		perm := entity.QuestionPermutation{QuestionID: question.ID}
+		if qt, ok := LookupQuestionType(question.Type); ok && qt.Shuffle != nil {
+			qt.Shuffle(r, question, &perm)
+		}
+		shuffle.Questions = append(shuffle.Questions, perm)
+	}
+	pkg.Shuffle(r, shuffle.Questions)
+	return shuffle
+}
+
+// ApplyShuffle returns copies of the questions in the order recorded for the attempt.
+// Questions and choices added after the attempt started follow in their stored order.
+func ApplyShuffle(questions []entity.Question, shuffle *entity.AttemptShuffle) []entity.Question {
+	ordered := make([]entity.Question, len(questions))
+	copy(ordered, questions)
+	if shuffle == nil {
+		return ordered
+	}
+
+	perms := make(map[primitive.ObjectID]entity.QuestionPermutation, len(shuffle.Questions))
+	questionOrder := make([]primitive.ObjectID, 0, len(shuffle.Questions))
+	for _, perm := range shuffle.Questions {
+		perms[perm.QuestionID] = perm
+		questionOrder = append(questionOrder, perm.QuestionID)
+	}
+
+	for i, question := range ordered {
+		perm, ok := perms[question.ID]
+		if !ok {
+			continue
+		}
+		if qt, ok := LookupQuestionType(question.Type); ok && qt.Reorder != nil {
+			qt.Reorder(&ordered[i], perm)
+		}
+	}
+	return reorderByID(ordered, questionOrder, func(q entity.Question) primitive.ObjectID { return q.ID })
+}
+
+// sortQuestionsByIDs puts the questions in the order of ids, e.g. the order of Test.QuestionIDs.
+func sortQuestionsByIDs(questions []entity.Question, ids []primitive.ObjectID) []entity.Question {
+	return reorderByID(questions, ids, func(q entity.Question) primitive.ObjectID { return q.ID })
+}
+
+func collectIDs[T any](items []T, id func(T) primitive.ObjectID) []primitive.ObjectID {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, len(items))
+	for i, item := range items {
+		ids[i] = id(item)
+	}
+	return ids
+}
+
+// reorderByID returns the items in the order of ids. Items missing from ids keep
+// their relative order at the end; IDs without an item are skipped.
+func reorderByID[T any](items []T, ids []primitive.ObjectID, id func(T) primitive.ObjectID) []T {
+	if len(items) == 0 {
+		return items
+	}
+	used := make([]bool, len(items))
+	ordered := make([]T, 0, len(items))
+	for _, want := range ids {
+		for i, item := range items {
+			if !used[i] && id(item) == want {
+				used[i] = true
+				ordered = append(ordered, item)
+				break
+			}
+		}
+	}
+	for i, item := range items {
+		if !used[i] {
+			ordered = append(ordered, item)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testObjectID returns a fixed ID so the shuffles are the same on every run.
+func testObjectID(n int) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%024x", n))
+	return id
+}
+
+func shuffleTestQuestion(n, options int) entity.Question {
+	q := entity.Question{ID: testObjectID(n), Type: entity.QuestionTypeSingleChoice}
+	for i := 0; i < options; i++ {
+		q.Options = append(q.Options, entity.Option{ID: testObjectID(n*100 + i), Text: fmt.Sprint(i)})
+	}
+	return q
+}
+
+func TestNewAttemptShuffle(t *testing.T) {
+	tests := []struct {
+		name      string
+		questions []entity.Question
+	}{
+		{"no questions", nil},
+		{"one question", []entity.Question{shuffleTestQuestion(1, 4)}},
+		{"choices and essays", []entity.Question{
+			shuffleTestQuestion(1, 4),
+			{ID: testObjectID(2), Type: entity.QuestionTypeEssay},
+			shuffleTestQuestion(3, 2),
+			shuffleTestQuestion(4, 6),
+			{ID: testObjectID(5), Type: "unknown_type"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := slices.Clone(tt.questions)
+			attemptID := testObjectID(42)
+			shuffle := NewAttemptShuffle(attemptID, tt.questions)
+
+			again := NewAttemptShuffle(attemptID, tt.questions)
+			if fmt.Sprint(again) != fmt.Sprint(shuffle) {
+				t.Errorf("same attempt gave another shuffle: %v and %v", shuffle, again)
+			}
+			if fmt.Sprint(before) != fmt.Sprint(tt.questions) {
+				t.Errorf("questions were modified")
+			}
+
+			if len(shuffle.Questions) != len(tt.questions) {
+				t.Fatalf("got %d permutations, want %d", len(shuffle.Questions), len(tt.questions))
+			}
+			perms := make(map[primitive.ObjectID]entity.QuestionPermutation)
+			for _, perm := range shuffle.Questions {
+				perms[perm.QuestionID] = perm
+			}
+			for _, q := range tt.questions {
+				perm, ok := perms[q.ID]
+				if !ok {
+					t.Fatalf("question %s missing from the shuffle", q.ID.Hex())
+				}
+				want := collectIDs(q.Options, optionID)
+				got := slices.Clone(perm.Options)
+				slices.SortFunc(want, func(a, b primitive.ObjectID) int { return slices.Compare(a[:], b[:]) })
+				slices.SortFunc(got, func(a, b primitive.ObjectID) int { return slices.Compare(a[:], b[:]) })
+				if !slices.Equal(got, want) {
+					t.Errorf("options of %s: got %v, want a permutation of %v", q.ID.Hex(), perm.Options, want)
+				}
+			}
+
+			// ApplyShuffle shows the questions and options in the recorded order
+			shown := ApplyShuffle(tt.questions, shuffle)
+			for i, q := range shown {
+				perm := shuffle.Questions[i]
+				if q.ID != perm.QuestionID {
+					t.Fatalf("question %d: got %s, want %s", i, q.ID.Hex(), perm.QuestionID.Hex())
+				}
+				if ids := collectIDs(q.Options, optionID); !slices.Equal(ids, perm.Options) {
+					t.Errorf("options of %s: got %v, want %v", q.ID.Hex(), ids, perm.Options)
+				}
+			}
+		})
+	}
+}
+
+func TestNewAttemptShuffleDiffersBetweenAttempts(t *testing.T) {
+	var questions []entity.Question
+	for i := 1; i <= 10; i++ {
+		questions = append(questions, shuffleTestQuestion(i, 4))
+	}
+	first := fmt.Sprint(NewAttemptShuffle(testObjectID(1), questions).Questions)
+	for n := 2; n <= 5; n++ {
+		if fmt.Sprint(NewAttemptShuffle(testObjectID(n), questions).Questions) != first {
+			return
+		}
+	}
+	t.Errorf("five attempts got the same order")
+}
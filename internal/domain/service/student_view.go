@@ -0,0 +1,135 @@
+package service
+
+import (
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StudentQuestion is the student-facing projection of a question. It never carries
+// Option.IsCorrect, FillInTheBlank.CorrectAnswer, OrderItem.Order or MatchOption.MatchId;
+// the answer key is attached separately, and only when the review policy allows it.
+type StudentQuestion struct {
+	ID              primitive.ObjectID     `json:"_id"`
+	Type            string                 `json:"type"`
+	QuestionContent entity.QuestionContent `json:"question_content"`
+	Score           float32                `json:"score"`
+	Options         []StudentChoice        `json:"options,omitempty"`
+	FillInTheBlanks []StudentBlank         `json:"fill_in_the_blanks,omitempty"`
+	OrderItems      []StudentChoice        `json:"order_items,omitempty"`
+	MatchItems      []StudentChoice        `json:"match_items,omitempty"`
+	MatchOptions    []StudentChoice        `json:"match_options,omitempty"`
+	Matrix          *StudentMatrix         `json:"matrix,omitempty"`
+	Units           []string               `json:"units,omitempty"` // numeric: accepted unit names
+	AnswerKey       *AnswerKey             `json:"answer_key,omitempty"`
+}
+
+// StudentMatrix is a matrix without the correct columns.
+type StudentMatrix struct {
+	Rows    []StudentChoice `json:"rows"`
+	Columns []StudentChoice `json:"columns"`
+}
+
+// StudentChoice is an option, order item, match item or match option without its answer data.
+type StudentChoice struct {
+	ID       primitive.ObjectID `json:"id"`
+	Text     string             `json:"text"`
+	ImageURL string             `json:"imageurl,omitempty"`
+}
+
+// StudentBlank is a blank without its accepted answers.
+type StudentBlank struct {
+	ID         primitive.ObjectID `json:"id"`
+	TextBefore string             `json:"text_before"`
+	TextAfter  string             `json:"text_after"`
+}
+
+// AnswerKey holds the correct answer of a question for review.
+type AnswerKey struct {
+	Options []primitive.ObjectID `json:"options,omitempty"` // Correct options, or order items in the correct order
+	Blanks  []BlankKey           `json:"blanks,omitempty"`
+	Matches []MatchKey           `json:"matches,omitempty"`
+
+	TrueFalse *bool                 `json:"true_false,omitempty"`
+	Numeric   *entity.NumericAnswer `json:"numeric,omitempty"`
+	Hotspots  []entity.Hotspot      `json:"hotspots,omitempty"` // Correct hotspots only
+	Matrix    []entity.MatrixAnswer `json:"matrix,omitempty"`
+}
+
+type BlankKey struct {
+	ID       primitive.ObjectID `json:"id"`
+	Accepted []string           `json:"accepted"`
+}
+
+// MatchKey pairs a match option with its correct match item.
+type MatchKey struct {
+	ID      primitive.ObjectID `json:"id"`
+	MatchId string             `json:"match_id"`
+}
+
+// StudentQuestions projects the questions for a student. Answer keys are only attached
+// once the attempt is closed and the test's review policy releases them.
+func StudentQuestions(questions []entity.Question, attempt entity.TestAnswer, test entity.Test, now time.Time) []StudentQuestion {
+	reveal := !attempt.IsOpen() && test.AnswersVisible(now)
+
+	views := make([]StudentQuestion, 0, len(questions))
+	for _, question := range questions {
+		view := NewStudentQuestion(question)
+		if reveal {
+			key := NewAnswerKey(question)
+			view.AnswerKey = &key
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// StudentAttempt hides the per-question scores, comments and grade log of the attempt until it is
+// closed and the test's review policy releases the answers, as they tell which answers were right.
+// The totals stay visible. Every attempt sent to a student goes through it.
+func StudentAttempt(attempt *entity.TestAnswer, test entity.Test, now time.Time) *entity.TestAnswer {
+	if attempt == nil || (!attempt.IsOpen() && test.AnswersVisible(now)) {
+		return attempt
+	}
+	hidden := *attempt
+	hidden.ListQuestionAnswer = entity.ResponsesOnly(attempt.ListQuestionAnswer)
+	hidden.GradeLog = nil
+	return &hidden
+}
+
+// StudentResult keeps only the totals of the result until the review policy releases the answers.
+func StudentResult(result GradeResult, test entity.Test, now time.Time) GradeResult {
+	if !test.AnswersVisible(now) {
+		result.Questions = nil
+	}
+	return result
+}
+
+// NewStudentQuestion copies only the fields a student may see before review.
+// The type-specific parts come from the type's Sanitize hook.
+func NewStudentQuestion(question entity.Question) StudentQuestion {
+	view := StudentQuestion{
+		ID:              question.ID,
+		Type:            question.Type,
+		QuestionContent: question.QuestionContent,
+		Score:           question.Score,
+	}
+	if qt, ok := LookupQuestionType(question.Type); ok {
+		view.Type = qt.Name
+		if qt.Sanitize != nil {
+			qt.Sanitize(question, &view)
+		}
+	}
+	return view
+}
+
+// NewAnswerKey extracts the correct answer of the question.
+func NewAnswerKey(question entity.Question) AnswerKey {
+	var key AnswerKey
+	if qt, ok := LookupQuestionType(question.Type); ok && qt.AnswerKey != nil {
+		qt.AnswerKey(question, &key)
+	}
+	return key
+}
@@ -9,16 +9,28 @@ import (
 )
 
 type TestUseCase struct {
-	TestRepo repository.TestRepository
+	TestRepo  repository.TestRepository
+	ClassRepo repository.ClassRepository
+	Questions *QuestionUseCase
+	Authz     *AuthorizationService
 }
 
-func NewTestUseCase(tr repository.TestRepository) *TestUseCase {
+func NewTestUseCase(tr repository.TestRepository, cr repository.ClassRepository, questions *QuestionUseCase, authz *AuthorizationService) *TestUseCase {
 	return &TestUseCase{
-		TestRepo: tr,
+		TestRepo:  tr,
+		ClassRepo: cr,
+		Questions: questions,
+		Authz:     authz,
 	}
 }
 
 func (uc *TestUseCase) CreateTest(ctx context.Context, test *entity.Test) (primitive.ObjectID, error) {
+	if err := test.ValidateDrawRules(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := test.PrepareSections(); err != nil {
+		return primitive.NilObjectID, err
+	}
 	return uc.TestRepo.CreateTest(ctx, test)
 }
 
@@ -26,12 +38,32 @@ func (uc *TestUseCase) GetTestsByAuthorEmail(ctx context.Context, email string)
 	return uc.TestRepo.GetTestsByAuthorEmail(ctx, email)
 }
 
-func (uc *TestUseCase) UpdateTest(ctx context.Context, test *entity.Test) (any, error) {
+// UpdateTest saves the test for users with the edit permission on it. The test keeps its author.
+func (uc *TestUseCase) UpdateTest(ctx context.Context, emailID, email string, test *entity.Test) (any, error) {
+	stored, err := uc.Authz.AuthorizeTest(ctx, test.ID, emailID, email, entity.PermEdit)
+	if err != nil {
+		return nil, err
+	}
+	test.EmailID = stored.EmailID
+	test.EmailName = stored.EmailName
+	if err := test.ValidateDrawRules(); err != nil {
+		return nil, err
+	}
+	if err := test.PrepareSections(); err != nil {
+		return nil, err
+	}
 	return uc.TestRepo.UpdateTest(ctx, test)
 }
 
-func (uc *TestUseCase) DeleteTest(ctx context.Context, id primitive.ObjectID, email string) error {
-	return uc.TestRepo.DeleteTest(ctx, id, email)
+// DeleteTest deletes the test and the grants on it. Only the author may delete it.
+func (uc *TestUseCase) DeleteTest(ctx context.Context, id primitive.ObjectID, emailID, email string) error {
+	if _, err := uc.Authz.AuthorizeTest(ctx, id, emailID, email, entity.PermOwn); err != nil {
+		return err
+	}
+	if err := uc.TestRepo.DeleteTest(ctx, id, emailID); err != nil {
+		return err
+	}
+	return uc.Authz.ForgetResource(ctx, entity.ResourceTest, id)
 }
 
 
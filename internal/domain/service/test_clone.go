@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrNotTemplate = errors.New("test is not a template")
+
+// CloneOptions controls how a test is copied.
+type CloneOptions struct {
+	TestName      string `json:"test_name,omitempty"`      // Defaults to the name of the source
+	CopyQuestions bool   `json:"copy_questions"`           // Copy the questions too, otherwise the clone shares them
+	OffsetMinutes int    `json:"offset_minutes,omitempty"` // Moves StartTime and EndTime, e.g. to the next semester
+	IsTemplate    bool   `json:"is_template,omitempty"`    // CloneTest only, instances are never templates
+}
+
+// CloneTest copies a test the author may view into the author's bank. With a classID the
+// source is the test as it was added to that class, otherwise a test of the bank.
+// The clone has no answers and its own timestamps.
+func (uc *TestUseCase) CloneTest(ctx context.Context, author, email string, classID, testID primitive.ObjectID, opts CloneOptions) (*entity.Test, error) {
+	source, err := uc.sourceTest(ctx, author, email, classID, testID)
+	if err != nil {
+		return nil, err
+	}
+	clone, err := uc.cloneTest(ctx, author, email, *source, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.TestRepo.InsertTest(ctx, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// InstantiateTemplate adds a new copy of a template to every class of classIDs.
+// The author needs the edit permission in all the classes, which is checked before anything
+// is created. The copies are then written class by class and not atomically: a storage error
+// partway through leaves the copies already added to the earlier classes.
+func (uc *TestUseCase) InstantiateTemplate(ctx context.Context, author, email string, templateID primitive.ObjectID, classIDs []primitive.ObjectID, opts CloneOptions) ([]entity.Test, error) {
+	template, err := uc.sourceTest(ctx, author, email, primitive.NilObjectID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsTemplate {
+		return nil, ErrNotTemplate
+	}
+
+	var classes []primitive.ObjectID
+	for _, classID := range classIDs {
+		if slices.Contains(classes, classID) {
+			continue
+		}
+		if _, err := uc.Authz.AuthorizeClass(ctx, classID, author, email, entity.PermEdit); err != nil {
+			return nil, err
+		}
+		classes = append(classes, classID)
+	}
+
+	opts.IsTemplate = false
+	instances := make([]entity.Test, 0, len(classes))
+	for _, classID := range classes {
+		instance, err := uc.cloneTest(ctx, author, email, *template, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := uc.TestRepo.InsertTest(ctx, &instance); err != nil {
+			return nil, err
+		}
+		if err := uc.ClassRepo.AddTestToClass(ctx, classID, instance); err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// sourceTest loads a test the author may view, from the class when classID is set.
+func (uc *TestUseCase) sourceTest(ctx context.Context, author, email string, classID, testID primitive.ObjectID) (*entity.Test, error) {
+	if !classID.IsZero() {
+		class, err := uc.Authz.AuthorizeClassTest(ctx, classID, testID, author, email, entity.PermView)
+		if err != nil {
+			return nil, err
+		}
+		for _, test := range class.Test {
+			if test.ID == testID {
+				return &test, nil
+			}
+		}
+		return nil, ErrTestNotFound
+	}
+
+	return uc.Authz.AuthorizeTest(ctx, testID, author, email, entity.PermView)
+}
+
+// cloneTest builds the copy of source with a new ID. The copied questions, if any, are stored here.
+func (uc *TestUseCase) cloneTest(ctx context.Context, author, email string, source entity.Test, opts CloneOptions) (entity.Test, error) {
+	clone := source
+	clone.ID = primitive.NewObjectID()
+	clone.TemplateID = source.ID
+	clone.IsTemplate = opts.IsTemplate
+	clone.EmailID = author
+	clone.EmailName = email
+	clone.AnswerUser = []string{}
+	clone.AnswersReleased = false
+	clone.CreatedAt = time.Now()
+	clone.UpdatedAt = clone.CreatedAt
+	if opts.TestName != "" {
+		clone.TestName = opts.TestName
+	}
+	if opts.OffsetMinutes != 0 {
+		clone.Shift(time.Duration(opts.OffsetMinutes) * time.Minute)
+	}
+
+	var copied map[primitive.ObjectID]primitive.ObjectID
+	if opts.CopyQuestions {
+		var err error
+		copied, err = uc.Questions.CopyQuestions(ctx, author, source.QuestionIDs)
+		if err != nil {
+			return entity.Test{}, err
+		}
+	}
+	clone.MapQuestionIDs(func(id primitive.ObjectID) (primitive.ObjectID, bool) {
+		if newID, ok := copied[id]; ok {
+			return newID, true
+		}
+		return id, true
+	})
+	return clone, nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNoOpenSection    = errors.New("attempt has no open section")
+	ErrSectionMismatch  = errors.New("section is not the current section of the attempt")
+	ErrNotLinearSection = errors.New("section does not use linear navigation")
+)
+
+// SubmitSection saves the answers of the current section and opens the next one.
+// Submitting the last section, or a section scoring below its MinScore, submits the attempt.
+func (au *AnswerUseCase) SubmitSection(ctx context.Context, answer entity.TestAnswer, sectionID primitive.ObjectID) (*entity.TestAnswer, error) {
+	attempt, test, err := au.loadSectionAttempt(ctx, answer, sectionID)
+	if err != nil {
+		return attempt, err
+	}
+	attempt.AcceptAnswers(answer.ListQuestionAnswer)
+	attempt.LastSavedAt = time.Now()
+	return au.endSection(ctx, attempt, test)
+}
+
+// NextQuestion saves the answer to the current question of a linear section and moves on,
+// with no way back. Moving past the last question submits the section.
+func (au *AnswerUseCase) NextQuestion(ctx context.Context, answer entity.TestAnswer, sectionID primitive.ObjectID) (*entity.TestAnswer, error) {
+	attempt, test, err := au.loadSectionAttempt(ctx, answer, sectionID)
+	if err != nil {
+		return attempt, err
+	}
+	progress, _ := attempt.ActiveSection()
+	if progress.Navigation != entity.NavigationLinear {
+		return StudentAttempt(attempt, *test, time.Now()), ErrNotLinearSection
+	}
+
+	attempt.AcceptAnswers(answer.ListQuestionAnswer)
+	attempt.LastSavedAt = time.Now()
+	progress.Position++
+	if progress.Position < len(progress.QuestionIDs) {
+		if err := au.storeOpenAttempt(ctx, attempt); err != nil {
+			return nil, fmt.Errorf("save attempt: %w", err)
+		}
+		return StudentAttempt(attempt, *test, time.Now()), nil
+	}
+	return au.endSection(ctx, attempt, test)
+}
+
+// loadSectionAttempt loads an open attempt whose current section is sectionID.
+// On error the attempt, if any, is the student's view, see StudentAttempt.
+func (au *AnswerUseCase) loadSectionAttempt(ctx context.Context, answer entity.TestAnswer, sectionID primitive.ObjectID) (*entity.TestAnswer, *entity.Test, error) {
+	attempt, test, err := au.loadAttempt(ctx, answer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !attempt.IsOpen() {
+		return StudentAttempt(attempt, *test, time.Now()), nil, ErrAttemptClosed
+	}
+	if _, err := au.closeIfExpired(ctx, attempt, test); err != nil {
+		return nil, nil, err
+	}
+	if !attempt.IsOpen() {
+		return StudentAttempt(attempt, *test, time.Now()), nil, ErrAttemptExpired
+	}
+
+	progress, ok := attempt.ActiveSection()
+	if !ok {
+		return StudentAttempt(attempt, *test, time.Now()), nil, ErrNoOpenSection
+	}
+	if progress.SectionID != sectionID {
+		return StudentAttempt(attempt, *test, time.Now()), nil, ErrSectionMismatch
+	}
+	return attempt, test, nil
+}
+
+// endSection completes the current section now and stores the attempt, submitting it
+// when no section is left.
+func (au *AnswerUseCase) endSection(ctx context.Context, attempt *entity.TestAnswer, test *entity.Test) (*entity.TestAnswer, error) {
+	done, err := au.completeSection(ctx, attempt, *test, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		submitted, err := entity.SubmitAnswer(*attempt)
+		if err != nil {
+			return nil, err
+		}
+		submitted.Late = submitted.IsLate(*test, submitted.EndTime)
+		if _, err := au.GradeAnswer(ctx, submitted, test.QuestionIDs); err != nil {
+			return nil, err
+		}
+		attempt = submitted
+	}
+
+	if err := au.storeOpenAttempt(ctx, attempt); err != nil {
+		return nil, fmt.Errorf("save attempt: %w", err)
+	}
+	return StudentAttempt(attempt, *test, time.Now()), nil
+}
+
+// planSections draws the questions of every section for a new attempt and opens the first one.
+func (au *AnswerUseCase) planSections(ctx context.Context, attempt *entity.TestAnswer, test entity.Test) error {
+	draw := newQuestionDraw(attempt.ID)
+	attempt.Sections = make([]entity.SectionProgress, 0, len(test.Sections))
+	for _, section := range test.Sections {
+		ids, err := au.draw(ctx, draw, test.EmailID, section.QuestionIDs, section.DrawRules)
+		if err != nil {
+			return fmt.Errorf("section %q: %w", section.Title, err)
+		}
+		attempt.Sections = append(attempt.Sections, entity.SectionProgress{
+			SectionID:   section.ID,
+			QuestionIDs: ids,
+			Navigation:  section.EffectiveNavigation(),
+			Status:      entity.SectionPending,
+		})
+	}
+	attempt.DrawnQuestions = draw.drawn
+	attempt.OpenSection(0, attempt.StartTime, test.Sections[0])
+	return nil
+}
+
+// groupShuffleBySection keeps the shuffled questions of each section together, in section
+// order, and records the shown order on the sections so linear navigation follows it.
+func groupShuffleBySection(shuffle *entity.AttemptShuffle, sections []entity.SectionProgress) {
+	if len(sections) == 0 {
+		return
+	}
+	shown := collectIDs(shuffle.Questions, func(p entity.QuestionPermutation) primitive.ObjectID { return p.QuestionID })
+	grouped := make([]primitive.ObjectID, 0, len(shown))
+	for i := range sections {
+		sections[i].QuestionIDs = reorderByID(sections[i].QuestionIDs, shown, func(id primitive.ObjectID) primitive.ObjectID { return id })
+		grouped = append(grouped, sections[i].QuestionIDs...)
+	}
+	shuffle.Questions = reorderByID(shuffle.Questions, grouped, func(p entity.QuestionPermutation) primitive.ObjectID { return p.QuestionID })
+}
+
+// closeExpiredSections completes the sections whose time ran out, each one opening the next
+// at its deadline. When no section is left the attempt is expired and graded.
+func (au *AnswerUseCase) closeExpiredSections(ctx context.Context, attempt *entity.TestAnswer, test *entity.Test, now time.Time) (GradeResult, error) {
+	changed, done := false, false
+	for !done {
+		progress, ok := attempt.ActiveSection()
+		if !ok || progress.Deadline.IsZero() || !now.After(progress.Deadline) {
+			break
+		}
+		var err error
+		if done, err = au.completeSection(ctx, attempt, *test, progress.Deadline); err != nil {
+			return GradeResult{}, err
+		}
+		changed = true
+	}
+	if !changed {
+		return GradeResult{}, nil
+	}
+
+	var result GradeResult
+	if done {
+		attempt.Expire(attempt.Sections[attempt.CurrentSection].EndedAt)
+		var err error
+		if result, err = au.GradeAnswer(ctx, attempt, test.QuestionIDs); err != nil {
+			return GradeResult{}, err
+		}
+	}
+	if err := au.storeOpenAttempt(ctx, attempt); err != nil {
+		return GradeResult{}, fmt.Errorf("close section: %w", err)
+	}
+	return result, nil
+}
+
+// completeSection scores the current section and opens the next one at the given time,
+// unless the score is below the section's MinScore. Responses waiting for manual grading
+// count as zero. It reports whether the attempt has no section left.
+func (au *AnswerUseCase) completeSection(ctx context.Context, attempt *entity.TestAnswer, test entity.Test, at time.Time) (bool, error) {
+	progress, ok := attempt.ActiveSection()
+	if !ok {
+		return true, nil
+	}
+	result, err := au.gradeQuestions(ctx, *attempt, progress.QuestionIDs)
+	if err != nil {
+		return false, err
+	}
+	progress.Status = entity.SectionCompleted
+	progress.EndedAt = at
+	progress.Score = result.TotalScore
+	progress.MaxScore = result.MaxScore
+
+	next := attempt.CurrentSection + 1
+	if next >= len(attempt.Sections) {
+		return true, nil
+	}
+	if section, _ := test.Section(progress.SectionID); progress.Score < section.MinScore {
+		attempt.LockRemainingSections()
+		return true, nil
+	}
+	section, _ := test.Section(attempt.Sections[next].SectionID)
+	attempt.OpenSection(next, at, section)
+	return false, nil
+}
+
+// gradeQuestions grades a copy of the attempt against some of its questions only.
+func (au *AnswerUseCase) gradeQuestions(ctx context.Context, attempt entity.TestAnswer, questionIDs []primitive.ObjectID) (GradeResult, error) {
+	questions, err := au.questionRepo.GetQuestionsByIDs(ctx, questionIDs)
+	if err != nil {
+		return GradeResult{}, fmt.Errorf("load questions: %w", err)
+	}
+	questions, err = au.pinQuestions(ctx, attempt, questionIDs, questions)
+	if err != nil {
+		return GradeResult{}, err
+	}
+	applyDrawnScores(questions, attempt.DrawnQuestions)
+	attempt.ListQuestionAnswer = slices.Clone(attempt.ListQuestionAnswer)
+	return au.grader.Grade(&attempt, questions), nil
+}
+
+// scoreSections copies the grades of the attempt into its sections.
+func scoreSections(attempt *entity.TestAnswer, result GradeResult) {
+	for i := range attempt.Sections {
+		progress := &attempt.Sections[i]
+		progress.Score, progress.MaxScore = 0, 0
+		for _, grade := range result.Questions {
+			if slices.Contains(progress.QuestionIDs, grade.QuestionID) {
+				progress.Score += grade.Score
+				progress.MaxScore += grade.MaxScore
+			}
+		}
+	}
+}
+
+// visibleQuestions keeps the questions the student may see in an open attempt with sections.
+func visibleQuestions(questions []entity.Question, attempt *entity.TestAnswer) []entity.Question {
+	if !attempt.IsOpen() || len(attempt.Sections) == 0 {
+		return questions
+	}
+	visible := attempt.VisibleQuestionIDs()
+	return slices.DeleteFunc(questions, func(q entity.Question) bool {
+		return !slices.Contains(visible, q.ID)
+	})
+}
@@ -0,0 +1,141 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+)
+
+// SMTPMailer sends mails through an SMTP server. Without a username it does not authenticate,
+// which is enough for a local relay such as MailHog.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	if port == "" {
+		port = "587"
+	}
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, mail entity.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg, err := buildMessage(m.From, mail, time.Now())
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	if err := smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{mail.To}, msg); err != nil {
+		return fmt.Errorf("failed to send mail to %s: %w", mail.To, err)
+	}
+	return nil
+}
+
+// FileMailer writes every mail as an .eml file in Dir instead of sending it. Dùng khi chạy local.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{Dir: dir, From: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, mail entity.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := time.Now()
+	msg, err := buildMessage(m.From, mail, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail dir: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405.000000000"), safeFilename(mail.To))
+	if err := os.WriteFile(filepath.Join(m.Dir, name), msg, 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
+
+// MemoryMailer keeps the mails in memory, for tests.
+type MemoryMailer struct {
+	mu    sync.Mutex
+	mails []entity.Mail
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, mail entity.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mails = append(m.mails, mail)
+	return nil
+}
+
+// Mails returns the mails sent so far, oldest first.
+func (m *MemoryMailer) Mails() []entity.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]entity.Mail(nil), m.mails...)
+}
+
+// buildMessage formats the mail as a plain text UTF-8 message.
+func buildMessage(from string, mail entity.Mail, at time.Time) ([]byte, error) {
+	// A line break in a header would let the caller add headers of their own
+	if strings.ContainsAny(from+mail.To+mail.Subject, "\r\n") {
+		return nil, fmt.Errorf("invalid mail header")
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(mail.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+func safeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -6,8 +6,10 @@ import (
 	"io"
 	"log"
 	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -63,6 +65,9 @@ func (r *FileAWSRepository) GetFile(ctx context.Context, email string, filename
 	}
 
 	result, err := r.S3Client.GetObjectWithContext(ctx, input)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, fmt.Errorf("%w: %s", repository.ErrFileNotFound, fileKey)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file: %v", err)
 	}
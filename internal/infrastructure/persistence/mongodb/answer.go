@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	entity "quiz-app/internal/domain/entities"
 	"quiz-app/internal/domain/repository"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type AnswerMongoRepository struct {
@@ -18,6 +21,19 @@ type AnswerMongoRepository struct {
 
 func NewAnswerMongoRepository() repository.AnswerRepository {
 	collRepo := NewCollRepository("dbapp", "answers")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// Two starts at once cannot both create attempt n. Answers from before attempts
+	// were numbered have no attempt_number and are left out.
+	err := collRepo.CreateIndexes(ctx, []mongo.IndexModel{{
+		Keys: bson.D{{Key: "test_id", Value: 1}, {Key: "email_id", Value: 1}, {Key: "attempt_number", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"attempt_number": bson.M{"$gt": 0}}),
+	}})
+	if err != nil {
+		log.Printf("answers: %v", err)
+	}
 	return &AnswerMongoRepository{
 		CollRepo: collRepo,
 	}
@@ -25,6 +41,9 @@ func NewAnswerMongoRepository() repository.AnswerRepository {
 
 func (r *AnswerMongoRepository) CreateAnswer(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error) {
 	insertedID, err := r.CollRepo.Create(ctx, answer)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("attempt %d: %w", answer.AttemptNumber, repository.ErrDuplicateKey)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -38,16 +57,50 @@ func (r *AnswerMongoRepository) CreateAnswer(ctx context.Context, answer entity.
 }
 
 func (r *AnswerMongoRepository) UpdateAnswer(ctx context.Context, answer entity.TestAnswer) (*entity.TestAnswer, error) {
-	filter := primitive.M{"test_id": answer.TestId, "email_id": answer.EmailID}
+	// Each attempt is its own document
+	filter := primitive.M{"_id": answer.ID, "email_id": answer.EmailID}
 
 	updateResult, err := r.CollRepo.Update(ctx, filter, primitive.M{"$set": answer})
-	if err != nil || updateResult.MatchedCount == 0 {
+	if err != nil {
 		return nil, err
 	}
+	if updateResult.MatchedCount == 0 {
+		return nil, errors.New("no answer found")
+	}
 
 	return &answer, nil
 }
 
+// UpdateOpenAnswer compares and sets: a late autosave cannot reopen a closed attempt, and an
+// attempt closed by the expiry sweeper and a submit at once is only stored once.
+func (r *AnswerMongoRepository) UpdateOpenAnswer(ctx context.Context, answer entity.TestAnswer) (bool, error) {
+	filter := primitive.M{
+		"_id":      answer.ID,
+		"email_id": answer.EmailID,
+		"status":   primitive.M{"$in": primitive.A{entity.AttemptInProgress, nil}}, // nil: answers stored before attempts had a status
+	}
+	updateResult, err := r.CollRepo.Update(ctx, filter, primitive.M{"$set": answer})
+	if err != nil {
+		return false, err
+	}
+	return updateResult.MatchedCount == 1, nil
+}
+
+// UpdateGradedAnswer compares and sets on grade_version so two graders of one attempt do not
+// overwrite each other's grade.
+func (r *AnswerMongoRepository) UpdateGradedAnswer(ctx context.Context, answer entity.TestAnswer) (bool, error) {
+	filter := primitive.M{"_id": answer.ID, "email_id": answer.EmailID, "grade_version": answer.GradeVersion}
+	if answer.GradeVersion == 0 {
+		filter["grade_version"] = primitive.M{"$in": primitive.A{0, nil}} // nil: never graded by hand
+	}
+	answer.GradeVersion++
+	updateResult, err := r.CollRepo.Update(ctx, filter, primitive.M{"$set": answer})
+	if err != nil {
+		return false, err
+	}
+	return updateResult.MatchedCount == 1, nil
+}
+
 func (r *AnswerMongoRepository) GetAnswer(ctx context.Context, filter bson.M) (entity.TestAnswer, error) {
 	result, err := r.CollRepo.GetFilter(ctx, filter)
 	if err != nil {
@@ -42,8 +42,15 @@ func (r *ClassMongoRepository) CreateClass(ctx context.Context, class *entity.Cl
 
 // GetClassByAuthorEmail fetches all classes for a given author's email ID.
 func (r *ClassMongoRepository) GetClassByAuthorEmail(ctx context.Context, email string) ([]any, error) {
-	// Define a filter based on email_id
-	filter := bson.M{"author_mail": email}
+	// Classes the user owns, co-teaches or assists
+	filter := bson.M{"$or": bson.A{
+		bson.M{"author_mail": email},
+		bson.M{"members": bson.M{"$elemMatch": bson.M{
+			"email":  entity.NormalizeEmail(email),
+			"role":   bson.M{"$in": bson.A{entity.RoleCoTeacher, entity.RoleAssistant}},
+			"status": entity.MemberActive,
+		}}},
+	}}
 
 	// Query the database to retrieve all matching documents
 	results, err := r.CollRepo.GetAll(ctx, filter)
@@ -56,7 +63,8 @@ func (r *ClassMongoRepository) GetClassByAuthorEmail(ctx context.Context, email
 }
 
 func (r *ClassMongoRepository) GetAllClassByEmail(ctx context.Context, email string) ([]any, error) {
-	filter := bson.M{"students_accept": email}
+	// Roster entries are stored lowercased, older ones as they were typed
+	filter := bson.M{"students_accept": bson.M{"$in": bson.A{email, entity.NormalizeEmail(email)}}}
 	projection := bson.M{"test_id": 1, "class_name": 1, "author_mail": 1, "tags": 1, "_id": 1}
 	classes, err := r.CollRepo.GetWithProjection(ctx, filter, projection)
 	if err != nil {
@@ -78,8 +86,8 @@ func (r *ClassMongoRepository) UpdateClass(ctx context.Context, class *entity.Cl
 }
 
 // DeleteClass implements repository.ClassRepository.DeleteClass
-func (r *ClassMongoRepository) DeleteClass(ctx context.Context, emailID string, id primitive.ObjectID) error {
-	filter := bson.M{"email_id": emailID, "_id": id}
+func (r *ClassMongoRepository) DeleteClass(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id}
 	_, err := r.CollRepo.Delete(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("failed to delete class: %w", err)
@@ -87,67 +95,82 @@ func (r *ClassMongoRepository) DeleteClass(ctx context.Context, emailID string,
 	return nil
 }
 
-func (r *ClassMongoRepository) JoinClass(ctx context.Context, classID primitive.ObjectID, email string) error {
-	// Tạo filter để tìm class theo _id
-	filter := bson.M{"_id": classID}
-
-	// Tìm class dựa trên classID để kiểm tra giá trị của is_public
-	result, err := r.CollRepo.GetFilter(ctx, filter)
-	if err != nil {
-		return fmt.Errorf("failed to find class: %w", err)
-	}
-
-	if result == nil {
-		return fmt.Errorf("no class found with the given ID")
+// SaveMember adds or replaces the roster entry of member.Email and keeps the older lists in step:
+// active students are in students_accept, pending students in students_wait, anyone else in neither.
+func (r *ClassMongoRepository) SaveMember(ctx context.Context, classID primitive.ObjectID, member entity.ClassMember) error {
+	if err := r.initArrays(ctx, classID, "members", "students_accept", "students_wait"); err != nil {
+		return fmt.Errorf("failed to save member: %w", err)
 	}
 
-	// Thực hiện type assertion để đảm bảo result có kiểu map[string]interface{}
-	classDoc, ok := result.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("unexpected type for class document, expected map[string]interface{} but got %T", result)
+	acceptOp, waitOp := "$pull", "$pull"
+	if member.Role == entity.RoleStudent && member.Status == entity.MemberActive {
+		acceptOp = "$addToSet"
 	}
-
-	// Kiểm tra giá trị của is_public
-	isPublic, ok := classDoc["is_public"].(bool)
-	if !ok {
-		return fmt.Errorf("is_public field not found or is not of type bool, found type: %T", classDoc["is_public"])
+	if member.Role == entity.RoleStudent && member.Status == entity.MemberPending {
+		waitOp = "$addToSet"
 	}
+	update := bson.M{"$set": bson.M{"members.$": member}}
+	addUpdate(update, acceptOp, "students_accept", member.Email)
+	addUpdate(update, waitOp, "students_wait", member.Email)
 
-	// Khởi tạo students_accept và students_wait nếu chúng là null
-	update := bson.M{}
-	if studentsAccept, ok := classDoc["students_accept"]; !ok || studentsAccept == nil {
-		update["$set"] = bson.M{"students_accept": bson.A{}}
+	result, err := r.CollRepo.Update(ctx, bson.M{"_id": classID, "members.email": member.Email}, update)
+	if err != nil {
+		return fmt.Errorf("failed to save member: %w", err)
 	}
-	if studentsWait, ok := classDoc["students_wait"]; !ok || studentsWait == nil {
-		update["$set"] = bson.M{"students_wait": bson.A{}}
+	if result.MatchedCount > 0 {
+		return nil
 	}
 
-	// Thực hiện cập nhật ban đầu nếu cần
-	if len(update) > 0 {
-		_, err = r.CollRepo.Update(ctx, filter, update)
-		if err != nil {
-			return fmt.Errorf("failed to initialize students fields: %w", err)
-		}
+	delete(update, "$set")
+	addUpdate(update, "$push", "members", member)
+	result, err = r.CollRepo.Update(ctx, bson.M{"_id": classID, "members.email": bson.M{"$ne": member.Email}}, update)
+	if err != nil {
+		return fmt.Errorf("failed to save member: %w", err)
 	}
-
-	// Cập nhật students_accept hoặc students_wait dựa trên is_public
-	if isPublic {
-		_, err = r.CollRepo.Update(ctx, filter, bson.M{
-			"$addToSet": bson.M{"students_accept": email},
-		})
-	} else {
-		_, err = r.CollRepo.Update(ctx, filter, bson.M{
-			"$addToSet": bson.M{"students_wait": email},
-		})
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no class found with the given ID")
 	}
+	return nil
+}
 
+// RemoveMember deletes the email from the roster and the older lists.
+func (r *ClassMongoRepository) RemoveMember(ctx context.Context, classID primitive.ObjectID, email string) error {
+	result, err := r.CollRepo.Update(ctx, bson.M{"_id": classID}, bson.M{
+		"$pull": bson.M{
+			"members":         bson.M{"email": email},
+			"students_accept": email,
+			"students_wait":   email,
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update class: %w", err)
+		return fmt.Errorf("failed to remove member: %w", err)
 	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no class found with the given ID")
+	}
+	return nil
+}
 
+// initArrays replaces missing or null array fields with empty arrays, which $push and $addToSet need.
+func (r *ClassMongoRepository) initArrays(ctx context.Context, classID primitive.ObjectID, fields ...string) error {
+	for _, field := range fields {
+		if _, err := r.CollRepo.Update(ctx, bson.M{"_id": classID, field: nil}, bson.M{"$set": bson.M{field: bson.A{}}}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// addUpdate adds field: value under the update operator op.
+func addUpdate(update bson.M, op, field string, value any) {
+	fields, ok := update[op].(bson.M)
+	if !ok {
+		fields = bson.M{}
+		update[op] = fields
+	}
+	fields[field] = value
+}
+
 func (r *ClassMongoRepository) GetQuestionOfTest(ctx context.Context, classID, testID primitive.ObjectID, email string) ([]primitive.ObjectID, primitive.M, error) {
 	filter := bson.M{
 		"_id": classID,
@@ -182,8 +205,9 @@ func (r *ClassMongoRepository) GetQuestionOfTest(ctx context.Context, classID, t
 		return nil, nil, fmt.Errorf("invalid test document format")
 	}
 
+	// Tests built only from draw rules have no fixed question_ids
 	questionIDsRaw, ok := testDoc["question_ids"].(bson.A)
-	if !ok {
+	if !ok && len(asBsonA(testDoc["draw_rules"])) == 0 {
 		return nil, nil, fmt.Errorf("question_ids not found or invalid")
 	}
 	fmt.Println(questionIDsRaw)
@@ -200,6 +224,142 @@ func (r *ClassMongoRepository) GetQuestionOfTest(ctx context.Context, classID, t
 	return questionIDs, testDoc, nil
 }
 
+func asBsonA(value any) bson.A {
+	array, _ := value.(bson.A)
+	return array
+}
+
+// GetTestOfClass returns the test embedded in the class, including its question IDs.
+func (r *ClassMongoRepository) GetTestOfClass(ctx context.Context, classID, testID primitive.ObjectID) (*entity.Test, error) {
+	filter := bson.M{
+		"_id":  classID,
+		"test": bson.M{"$elemMatch": bson.M{"_id": testID}},
+	}
+	projection := bson.M{"test.$": 1}
+
+	result, err := r.CollRepo.GetOneWithProjection(ctx, filter, projection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test from class: %w", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no class found")
+	}
+
+	tests, ok := result["test"].(bson.A)
+	if !ok || len(tests) == 0 {
+		return nil, fmt.Errorf("no matching test found")
+	}
+
+	var test entity.Test
+	bsonBytes, err := bson.Marshal(tests[0])
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling test: %v", err)
+	}
+	if err := bson.Unmarshal(bsonBytes, &test); err != nil {
+		return nil, fmt.Errorf("error unmarshaling test: %v", err)
+	}
+	return &test, nil
+}
+
+// GetClassByID returns the class with its embedded tests and accommodations.
+func (r *ClassMongoRepository) GetClassByID(ctx context.Context, id primitive.ObjectID) (*entity.Class, error) {
+	result, err := r.CollRepo.GetFilter(ctx, bson.M{"_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find class: %w", err)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("no class found with the given ID")
+	}
+
+	var class entity.Class
+	bsonBytes, err := bson.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling class: %v", err)
+	}
+	if err := bson.Unmarshal(bsonBytes, &class); err != nil {
+		return nil, fmt.Errorf("error unmarshaling class: %v", err)
+	}
+	return &class, nil
+}
+
+// SetAnswersReleased sets the manual answer release flag of a test in the class.
+func (r *ClassMongoRepository) SetAnswersReleased(ctx context.Context, classID, testID primitive.ObjectID, released bool) error {
+	filter := bson.M{"_id": classID, "test._id": testID}
+	result, err := r.CollRepo.Update(ctx, filter, bson.M{
+		"$set": bson.M{"test.$.answers_released": released},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release answers: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no matching test found")
+	}
+	return nil
+}
+
+// SetBlindGrading sets the blind grading flag of a test in the class.
+func (r *ClassMongoRepository) SetBlindGrading(ctx context.Context, classID, testID primitive.ObjectID, blind bool) error {
+	filter := bson.M{"_id": classID, "test._id": testID}
+	result, err := r.CollRepo.Update(ctx, filter, bson.M{
+		"$set": bson.M{"test.$.blind_grading": blind},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set blind grading: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no matching test found")
+	}
+	return nil
+}
+
+// AddTestToClass appends a copy of the test to the class.
+func (r *ClassMongoRepository) AddTestToClass(ctx context.Context, classID primitive.ObjectID, test entity.Test) error {
+	// Classes created without tests store null, which $push cannot append to
+	if err := r.initArrays(ctx, classID, "test", "test_id"); err != nil {
+		return fmt.Errorf("failed to add test to class: %w", err)
+	}
+	result, err := r.CollRepo.Update(ctx, bson.M{"_id": classID}, bson.M{
+		"$push":     bson.M{"test": test},
+		"$addToSet": bson.M{"test_id": test.ID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add test to class: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no class found with the given ID")
+	}
+	return nil
+}
+
+// SetAccommodation replaces the student's accommodation for the same test (or the whole class).
+func (r *ClassMongoRepository) SetAccommodation(ctx context.Context, classID primitive.ObjectID, acc entity.Accommodation) error {
+	if err := r.RemoveAccommodation(ctx, classID, acc.Email, acc.TestID); err != nil {
+		return err
+	}
+	_, err := r.CollRepo.Update(ctx, bson.M{"_id": classID}, bson.M{
+		"$push": bson.M{"accommodations": acc},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set accommodation: %w", err)
+	}
+	return nil
+}
+
+// RemoveAccommodation deletes the student's accommodation. A zero testID targets the class-wide one.
+func (r *ClassMongoRepository) RemoveAccommodation(ctx context.Context, classID primitive.ObjectID, email string, testID primitive.ObjectID) error {
+	match := bson.M{"email": email, "test_id": bson.M{"$exists": false}}
+	if !testID.IsZero() {
+		match["test_id"] = testID
+	}
+	_, err := r.CollRepo.Update(ctx, bson.M{"_id": classID}, bson.M{
+		"$pull": bson.M{"accommodations": match},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove accommodation: %w", err)
+	}
+	return nil
+}
+
 func (r *ClassMongoRepository) GetAllTestOfClass(ctx context.Context, email string, ids primitive.ObjectID) ([]any, error) {
 	filter := bson.M{
 		"_id": ids,
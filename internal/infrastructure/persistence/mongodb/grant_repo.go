@@ -0,0 +1,122 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GrantMongoRepository implements the repository.GrantRepository interface
+type GrantMongoRepository struct {
+	CollRepo repository.CRUDMongoDB
+}
+
+func NewGrantMongoRepository() repository.GrantRepository {
+	repo := &GrantMongoRepository{
+		CollRepo: NewCollRepository("dbapp", "grants"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// One grant per user and resource; the email index backs GetGrantsByEmail
+	err := repo.CollRepo.CreateIndexes(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "resource_type", Value: 1}, {Key: "resource_id", Value: 1}, {Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "email", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("grants: %v", err)
+	}
+	return repo
+}
+
+// SaveGrant inserts a grant without an ID, and replaces the permissions of an existing one.
+func (r *GrantMongoRepository) SaveGrant(ctx context.Context, grant *entity.Grant) error {
+	if grant.ID.IsZero() {
+		grant.ID = primitive.NewObjectID()
+		if _, err := r.CollRepo.Create(ctx, grant); err != nil {
+			return fmt.Errorf("failed to create grant: %w", err)
+		}
+		return nil
+	}
+
+	update := bson.M{"$set": bson.M{
+		"permissions": grant.Permissions,
+		"granted_by":  grant.GrantedBy,
+		"updated_at":  grant.UpdatedAt,
+	}}
+	if _, err := r.CollRepo.Update(ctx, bson.M{"_id": grant.ID}, update); err != nil {
+		return fmt.Errorf("failed to update grant: %w", err)
+	}
+	return nil
+}
+
+func (r *GrantMongoRepository) DeleteGrant(ctx context.Context, resourceType string, resourceID primitive.ObjectID, email string) error {
+	filter := bson.M{"resource_type": resourceType, "resource_id": resourceID, "email": email}
+	if _, err := r.CollRepo.Delete(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete grant: %w", err)
+	}
+	return nil
+}
+
+func (r *GrantMongoRepository) GetGrant(ctx context.Context, resourceType string, resourceID primitive.ObjectID, email string) (*entity.Grant, error) {
+	filter := bson.M{"resource_type": resourceType, "resource_id": resourceID, "email": email}
+	result, err := r.CollRepo.GetOneWithProjection(ctx, filter, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grant: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	var grant entity.Grant
+	if err := decodeGrant(result, &grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (r *GrantMongoRepository) GetGrantsByResource(ctx context.Context, resourceType string, resourceID primitive.ObjectID) ([]entity.Grant, error) {
+	return r.getGrants(ctx, bson.M{"resource_type": resourceType, "resource_id": resourceID})
+}
+
+func (r *GrantMongoRepository) GetGrantsByEmail(ctx context.Context, email string) ([]entity.Grant, error) {
+	return r.getGrants(ctx, bson.M{"email": email})
+}
+
+func (r *GrantMongoRepository) getGrants(ctx context.Context, filter bson.M) ([]entity.Grant, error) {
+	results, err := r.CollRepo.GetAllWithOption(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", err)
+	}
+	grants := make([]entity.Grant, 0, len(results))
+	for _, result := range results {
+		var grant entity.Grant
+		if err := decodeGrant(result, &grant); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+func decodeGrant(result any, grant *entity.Grant) error {
+	bsonBytes, err := bson.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("error marshaling grant: %v", err)
+	}
+	if err := bson.Unmarshal(bsonBytes, grant); err != nil {
+		return fmt.Errorf("error unmarshaling grant: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvitationMongoRepository implements the repository.InvitationRepository interface
+type InvitationMongoRepository struct {
+	CollRepo repository.CRUDMongoDB
+}
+
+func NewInvitationMongoRepository() repository.InvitationRepository {
+	repo := &InvitationMongoRepository{
+		CollRepo: NewCollRepository("dbapp", "invitations"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := repo.CollRepo.CreateIndexes(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "class_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("invitations: %v", err)
+	}
+	return repo
+}
+
+func (r *InvitationMongoRepository) SaveInvitation(ctx context.Context, invitation *entity.Invitation) error {
+	if invitation.ID.IsZero() {
+		invitation.ID = primitive.NewObjectID()
+		if _, err := r.CollRepo.Create(ctx, invitation); err != nil {
+			return fmt.Errorf("failed to create invitation: %w", err)
+		}
+		return nil
+	}
+
+	update := bson.M{"$set": bson.M{
+		"status":      invitation.Status,
+		"send_error":  invitation.SendError,
+		"send_count":  invitation.SendCount,
+		"sent_at":     invitation.SentAt,
+		"expires_at":  invitation.ExpiresAt,
+		"accepted_at": invitation.AcceptedAt,
+	}}
+	if _, err := r.CollRepo.Update(ctx, bson.M{"_id": invitation.ID}, update); err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *InvitationMongoRepository) GetInvitation(ctx context.Context, id primitive.ObjectID) (*entity.Invitation, error) {
+	result, err := r.CollRepo.GetOneWithProjection(ctx, bson.M{"_id": id}, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	var invitation entity.Invitation
+	if err := decodeInvitation(result, &invitation); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *InvitationMongoRepository) GetInvitationsByClass(ctx context.Context, classID primitive.ObjectID) ([]entity.Invitation, error) {
+	results, err := r.CollRepo.GetAllWithOption(ctx, bson.M{"class_id": classID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	invitations := make([]entity.Invitation, 0, len(results))
+	for _, result := range results {
+		var invitation entity.Invitation
+		if err := decodeInvitation(result, &invitation); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, nil
+}
+
+func decodeInvitation(result any, invitation *entity.Invitation) error {
+	bsonBytes, err := bson.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("error marshaling invitation: %v", err)
+	}
+	if err := bson.Unmarshal(bsonBytes, invitation); err != nil {
+		return fmt.Errorf("error unmarshaling invitation: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JoinCodeMongoRepository implements the repository.JoinCodeRepository interface.
+// Codes and their audit log live in two collections.
+type JoinCodeMongoRepository struct {
+	CollRepo repository.CRUDMongoDB
+	UsesRepo repository.CRUDMongoDB
+}
+
+func NewJoinCodeMongoRepository() repository.JoinCodeRepository {
+	repo := &JoinCodeMongoRepository{
+		CollRepo: NewCollRepository("dbapp", "join_codes"),
+		UsesRepo: NewCollRepository("dbapp", "join_code_uses"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := repo.CollRepo.CreateIndexes(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "class_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("join_codes: %v", err)
+	}
+	err = repo.UsesRepo.CreateIndexes(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "class_id", Value: 1}, {Key: "code_id", Value: 1}, {Key: "at", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("join_code_uses: %v", err)
+	}
+	return repo
+}
+
+func (r *JoinCodeMongoRepository) CreateJoinCode(ctx context.Context, code *entity.JoinCode) error {
+	if code.ID.IsZero() {
+		code.ID = primitive.NewObjectID()
+	}
+	if _, err := r.CollRepo.Create(ctx, code); err != nil {
+		return fmt.Errorf("failed to create join code: %w", err)
+	}
+	return nil
+}
+
+func (r *JoinCodeMongoRepository) GetJoinCode(ctx context.Context, code string) (*entity.JoinCode, error) {
+	return r.getJoinCode(ctx, bson.M{"code": code})
+}
+
+func (r *JoinCodeMongoRepository) GetJoinCodeByID(ctx context.Context, id primitive.ObjectID) (*entity.JoinCode, error) {
+	return r.getJoinCode(ctx, bson.M{"_id": id})
+}
+
+func (r *JoinCodeMongoRepository) getJoinCode(ctx context.Context, filter bson.M) (*entity.JoinCode, error) {
+	result, err := r.CollRepo.GetOneWithProjection(ctx, filter, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join code: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	var code entity.JoinCode
+	if err := decodeJoinCodeDocument(result, &code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *JoinCodeMongoRepository) GetJoinCodesByClass(ctx context.Context, classID primitive.ObjectID) ([]entity.JoinCode, error) {
+	results, err := r.CollRepo.GetAllWithOption(ctx, bson.M{"class_id": classID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list join codes: %w", err)
+	}
+	codes := make([]entity.JoinCode, 0, len(results))
+	for _, result := range results {
+		var code entity.JoinCode
+		if err := decodeJoinCodeDocument(result, &code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// UseJoinCode checks the state of the code and counts the use in one update, so two
+// students cannot both take the last use.
+func (r *JoinCodeMongoRepository) UseJoinCode(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":        id,
+		"revoked_at": bson.M{"$exists": false},
+		"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"expires_at": bson.M{"$exists": false}}, bson.M{"expires_at": bson.M{"$gt": at}}}},
+			bson.M{"$or": bson.A{bson.M{"max_uses": bson.M{"$lte": 0}}, bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}}}},
+		},
+	}
+	result, err := r.CollRepo.Update(ctx, filter, bson.M{"$inc": bson.M{"uses": 1}})
+	if err != nil {
+		return false, fmt.Errorf("failed to use join code: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *JoinCodeMongoRepository) ReleaseJoinCodeUse(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "uses": bson.M{"$gt": 0}}
+	if _, err := r.CollRepo.Update(ctx, filter, bson.M{"$inc": bson.M{"uses": -1}}); err != nil {
+		return fmt.Errorf("failed to release join code use: %w", err)
+	}
+	return nil
+}
+
+func (r *JoinCodeMongoRepository) RevokeJoinCode(ctx context.Context, id primitive.ObjectID, by string, at time.Time) error {
+	filter := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+	if _, err := r.CollRepo.Update(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at, "revoked_by": by}}); err != nil {
+		return fmt.Errorf("failed to revoke join code: %w", err)
+	}
+	return nil
+}
+
+func (r *JoinCodeMongoRepository) AddJoinCodeUse(ctx context.Context, use entity.JoinCodeUse) error {
+	if use.ID.IsZero() {
+		use.ID = primitive.NewObjectID()
+	}
+	if _, err := r.UsesRepo.Create(ctx, use); err != nil {
+		return fmt.Errorf("failed to log join code use: %w", err)
+	}
+	return nil
+}
+
+func (r *JoinCodeMongoRepository) GetJoinCodeUses(ctx context.Context, classID, codeID primitive.ObjectID) ([]entity.JoinCodeUse, error) {
+	filter := bson.M{"class_id": classID}
+	if !codeID.IsZero() {
+		filter["code_id"] = codeID
+	}
+	results, err := r.UsesRepo.GetAllWithOption(ctx, filter, options.Find().SetSort(bson.D{{Key: "at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list join code uses: %w", err)
+	}
+	uses := make([]entity.JoinCodeUse, 0, len(results))
+	for _, result := range results {
+		var use entity.JoinCodeUse
+		if err := decodeJoinCodeDocument(result, &use); err != nil {
+			return nil, err
+		}
+		uses = append(uses, use)
+	}
+	return uses, nil
+}
+
+// decodeJoinCodeDocument decodes a join code or a log entry.
+func decodeJoinCodeDocument(result any, v any) error {
+	bsonBytes, err := bson.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("error marshaling join code: %v", err)
+	}
+	if err := bson.Unmarshal(bsonBytes, v); err != nil {
+		return fmt.Errorf("error unmarshaling join code: %v", err)
+	}
+	return nil
+}
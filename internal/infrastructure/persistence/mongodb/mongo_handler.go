@@ -30,6 +30,14 @@ func (r *CollRepository) Create(ctx context.Context, document any) (any, error)
 	return result.InsertedID, nil
 }
 
+func (r *CollRepository) CreateMany(ctx context.Context, documents []any) ([]any, error) {
+	result, err := r.Collection.InsertMany(ctx, documents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create documents: %w", err)
+	}
+	return result.InsertedIDs, nil
+}
+
 func (r *CollRepository) GetAll(ctx context.Context, filter any) ([]any, error) {
 	cursor, err := r.Collection.Find(ctx, filter)
 	if err != nil {
@@ -150,3 +158,19 @@ func (r *CollRepository) Delete(ctx context.Context, filter any) (*mongo.DeleteR
 	}
 	return result, nil
 }
+
+func (r *CollRepository) Count(ctx context.Context, filter any) (int64, error) {
+	count, err := r.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return count, nil
+}
+
+// CreateIndexes creates the indexes that do not exist yet; existing ones are left as they are.
+func (r *CollRepository) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	if _, err := r.Collection.Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
+	return nil
+}
@@ -2,13 +2,18 @@ package persistence
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"log"
+	"sort"
+	"time"
 
 	entity "quiz-app/internal/domain/entities"
 	"quiz-app/internal/domain/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -20,9 +25,16 @@ type QuestionMongoRepository struct {
 // NewQuestionMongoRepository creates a new instance of QuestionMongoRepository
 func NewQuestionMongoRepository() repository.QuestionRepository {
 	collRepo := NewCollRepository("dbapp", "questions")
-	return &QuestionMongoRepository{
+	repo := &QuestionMongoRepository{
 		CollRepo: collRepo,
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		log.Printf("questions: %v", err)
+	}
+	return repo
 }
 
 func (r *QuestionMongoRepository) GetAllQuestions(ctx context.Context, questionIDs []primitive.ObjectID) ([]primitive.M, error) {
@@ -62,6 +74,67 @@ func (r *QuestionMongoRepository) GetAllQuestions(ctx context.Context, questionI
 	return results, nil
 }
 
+// GetQuestionsByIDs returns the full question documents (answer keys included) for the given IDs.
+func (r *QuestionMongoRepository) GetQuestionsByIDs(ctx context.Context, questionIDs []primitive.ObjectID) ([]entity.Question, error) {
+	if len(questionIDs) == 0 {
+		return nil, fmt.Errorf("no question IDs provided")
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": questionIDs}}
+	results, err := r.CollRepo.GetWithProjection(ctx, filter, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+
+	questions := make([]entity.Question, 0, len(results))
+	for _, result := range results {
+		var question entity.Question
+		bsonBytes, err := bson.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling question: %v", err)
+		}
+		if err := bson.Unmarshal(bsonBytes, &question); err != nil {
+			return nil, fmt.Errorf("error unmarshaling question: %v", err)
+		}
+		questions = append(questions, question)
+	}
+	return questions, nil
+}
+
+// GetQuestionIDsByRule returns the IDs of the author's questions matching the draw rule, sorted by ID.
+func (r *QuestionMongoRepository) GetQuestionIDsByRule(ctx context.Context, author string, rule entity.DrawRule) ([]primitive.ObjectID, error) {
+	filter := bson.M{"metadata.author": author}
+	if len(rule.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": rule.Tags}
+	}
+	if rule.Type != "" {
+		filter["type"] = rule.Type
+	}
+	if rule.Difficulty != "" {
+		filter["difficulty"] = rule.Difficulty
+	}
+
+	results, err := r.CollRepo.GetWithProjection(ctx, filter, bson.M{"_id": 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions for draw rule: %w", err)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(results))
+	for _, result := range results {
+		doc, ok := result.(bson.M)
+		if !ok {
+			continue
+		}
+		if id, ok := doc["_id"].(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Hex() < ids[j].Hex()
+	})
+	return ids, nil
+}
+
 // CreateQuestion implements repository.QuestionRepository.CreateQuestion
 func (r *QuestionMongoRepository) CreateQuestion(ctx context.Context, question *entity.Question) (any, error) {
 	insertedID, err := r.CollRepo.Create(ctx, question)
@@ -73,6 +146,18 @@ func (r *QuestionMongoRepository) CreateQuestion(ctx context.Context, question *
 	return question, nil
 }
 
+// CreateQuestions implements repository.QuestionRepository.CreateQuestions
+func (r *QuestionMongoRepository) CreateQuestions(ctx context.Context, questions []entity.Question) error {
+	documents := make([]any, len(questions))
+	for i, question := range questions {
+		documents[i] = question
+	}
+	if _, err := r.CollRepo.CreateMany(ctx, documents); err != nil {
+		return fmt.Errorf("failed to create questions: %w", err)
+	}
+	return nil
+}
+
 func (r *QuestionMongoRepository) GetAllQuestionsByUser(ctx context.Context, email_id string, limit, page int) ([]any, error) {
 	// Create a filter to get questions by email_id
 	filter := bson.M{"metadata.author": email_id}
@@ -83,7 +168,7 @@ func (r *QuestionMongoRepository) GetAllQuestionsByUser(ctx context.Context, ema
 	findOpts.SetSkip(int64(page * limit)) // Calculate offset based on page and limit
 
 	// Fetch questions using the filter and options
-	allQuestions, err := r.CollRepo.GetAll(ctx, filter)
+	allQuestions, err := r.CollRepo.GetAllWithOption(ctx, filter, findOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get questions by email_id: %w", err)
 	}
@@ -113,3 +198,212 @@ func (r *QuestionMongoRepository) DeleteQuestion(ctx context.Context, question *
 	}
 	return nil
 }
+
+// questionIndexes back SearchQuestions and GetQuestionIDsByRule. Every query filters on the author.
+var questionIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "metadata.author", Value: 1}, {Key: "question_content.text", Value: "text"}}},
+	{Keys: bson.D{{Key: "metadata.author", Value: 1}, {Key: "type", Value: 1}, {Key: "_id", Value: -1}}},
+	{Keys: bson.D{{Key: "metadata.author", Value: 1}, {Key: "tags", Value: 1}, {Key: "_id", Value: -1}}},
+	{Keys: bson.D{{Key: "metadata.author", Value: 1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}},
+	{Keys: bson.D{{Key: "metadata.author", Value: 1}, {Key: "score", Value: -1}, {Key: "_id", Value: -1}}},
+}
+
+// EnsureIndexes creates the indexes of the questions collection.
+func (r *QuestionMongoRepository) EnsureIndexes(ctx context.Context) error {
+	return r.CollRepo.CreateIndexes(ctx, questionIndexes)
+}
+
+// questionSortFields maps the sort keys to document fields. created_at sorts by _id,
+// which carries the creation time and exists on every question, old ones included.
+var questionSortFields = map[string]string{
+	entity.QuestionSortCreated: "_id",
+	entity.QuestionSortUpdated: "updated_at",
+	entity.QuestionSortScore:   "score",
+}
+
+// questionCursor is the position after the last question of a page.
+// It is stored as base64 BSON so the sort value keeps its type (date, double, null).
+type questionCursor struct {
+	Sort  string             `bson:"s"`
+	Desc  bool               `bson:"d"`
+	Value bson.RawValue      `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+// SearchQuestions implements repository.QuestionRepository.SearchQuestions with keyset pagination
+// on (sort field, _id), so pages stay stable while questions are added.
+func (r *QuestionMongoRepository) SearchQuestions(ctx context.Context, query entity.QuestionQuery) (entity.QuestionPage, error) {
+	field, ok := questionSortFields[query.SortBy]
+	if !ok {
+		return entity.QuestionPage{}, fmt.Errorf("unknown sort %q", query.SortBy)
+	}
+
+	filter := questionQueryFilter(query)
+	total, err := r.CollRepo.Count(ctx, filter)
+	if err != nil {
+		return entity.QuestionPage{}, fmt.Errorf("failed to count questions: %w", err)
+	}
+
+	pageFilter := filter
+	if query.Cursor != "" {
+		cursor, err := decodeQuestionCursor(query.Cursor)
+		if err != nil || cursor.Sort != query.SortBy || cursor.Desc != query.Desc {
+			return entity.QuestionPage{}, entity.ErrInvalidCursor
+		}
+		pageFilter = bson.M{"$and": bson.A{filter, keysetFilter(field, query.Desc, cursor)}}
+	}
+
+	direction := 1
+	if query.Desc {
+		direction = -1
+	}
+	sortKeys := bson.D{{Key: "_id", Value: direction}}
+	if field != "_id" {
+		sortKeys = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+	}
+	// One extra document tells whether there is a next page
+	findOpts := options.Find().SetSort(sortKeys).SetLimit(int64(query.Limit + 1))
+
+	results, err := r.CollRepo.GetAllWithOption(ctx, pageFilter, findOpts)
+	if err != nil {
+		return entity.QuestionPage{}, fmt.Errorf("failed to search questions: %w", err)
+	}
+
+	page := entity.QuestionPage{Questions: make([]entity.Question, 0, len(results)), Total: total}
+	for i, result := range results {
+		if i == query.Limit {
+			last := results[i-1].(bson.M)
+			page.NextCursor, err = encodeQuestionCursor(query, field, last)
+			if err != nil {
+				return entity.QuestionPage{}, err
+			}
+			break
+		}
+		var question entity.Question
+		bsonBytes, err := bson.Marshal(result)
+		if err != nil {
+			return entity.QuestionPage{}, fmt.Errorf("error marshaling question: %v", err)
+		}
+		if err := bson.Unmarshal(bsonBytes, &question); err != nil {
+			return entity.QuestionPage{}, fmt.Errorf("error unmarshaling question: %v", err)
+		}
+		page.Questions = append(page.Questions, question)
+	}
+	return page, nil
+}
+
+// questionQueryFilter turns the query into a filter, without the cursor.
+func questionQueryFilter(query entity.QuestionQuery) bson.M {
+	filter := bson.M{"metadata.author": query.Author}
+	if len(query.Types) > 0 {
+		filter["type"] = bson.M{"$in": query.Types}
+	}
+	if len(query.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": query.Tags}
+	}
+	if query.Text != "" {
+		filter["$text"] = bson.M{"$search": query.Text}
+	}
+	if query.Difficulty != "" {
+		filter["difficulty"] = query.Difficulty
+	}
+
+	score := bson.M{}
+	if query.MinScore != nil {
+		score["$gte"] = *query.MinScore
+	}
+	if query.MaxScore != nil {
+		score["$lte"] = *query.MaxScore
+	}
+	if len(score) > 0 {
+		filter["score"] = score
+	}
+
+	// The creation time is read from _id, see questionSortFields
+	created := bson.M{}
+	if !query.CreatedFrom.IsZero() {
+		created["$gte"] = primitive.NewObjectIDFromTimestamp(query.CreatedFrom)
+	}
+	if !query.CreatedTo.IsZero() {
+		// _id only has second precision: include the whole last second
+		created["$lt"] = primitive.NewObjectIDFromTimestamp(query.CreatedTo.Add(time.Second))
+	}
+	if len(created) > 0 {
+		filter["_id"] = created
+	}
+
+	updated := bson.M{}
+	if !query.UpdatedFrom.IsZero() {
+		updated["$gte"] = query.UpdatedFrom
+	}
+	if !query.UpdatedTo.IsZero() {
+		updated["$lte"] = query.UpdatedTo
+	}
+	if len(updated) > 0 {
+		filter["updated_at"] = updated
+	}
+	return filter
+}
+
+// keysetFilter matches the documents after the cursor. Documents without the sort field
+// sort as null: before every value ascending, after every value descending.
+func keysetFilter(field string, desc bool, cursor questionCursor) bson.M {
+	after, sameAfter := "$gt", bson.M{"$gt": cursor.ID}
+	if desc {
+		after, sameAfter = "$lt", bson.M{"$lt": cursor.ID}
+	}
+	if field == "_id" {
+		return bson.M{"_id": sameAfter}
+	}
+
+	if cursor.Value.Type == bson.TypeNull {
+		if desc {
+			return bson.M{field: nil, "_id": sameAfter}
+		}
+		return bson.M{"$or": bson.A{
+			bson.M{field: nil, "_id": sameAfter},
+			bson.M{field: bson.M{"$ne": nil}},
+		}}
+	}
+
+	branches := bson.A{
+		bson.M{field: bson.M{after: cursor.Value}},
+		bson.M{field: cursor.Value, "_id": sameAfter},
+	}
+	if desc {
+		branches = append(branches, bson.M{field: nil})
+	}
+	return bson.M{"$or": branches}
+}
+
+func encodeQuestionCursor(query entity.QuestionQuery, field string, last bson.M) (string, error) {
+	id, _ := last["_id"].(primitive.ObjectID)
+	cursor := questionCursor{Sort: query.SortBy, Desc: query.Desc, ID: id, Value: bson.RawValue{Type: bson.TypeNull}}
+	if value, ok := last[field]; ok && field != "_id" && value != nil {
+		t, data, err := bson.MarshalValue(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode cursor: %w", err)
+		}
+		cursor.Value = bson.RawValue{Type: t, Value: data}
+	}
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeQuestionCursor(encoded string) (questionCursor, error) {
+	var cursor questionCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	if cursor.ID.IsZero() {
+		return cursor, entity.ErrInvalidCursor
+	}
+	return cursor, nil
+}
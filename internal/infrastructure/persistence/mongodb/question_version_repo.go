@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QuestionVersionMongoRepository implements the repository.QuestionVersionRepository interface
+type QuestionVersionMongoRepository struct {
+	CollRepo repository.CRUDMongoDB
+}
+
+func NewQuestionVersionMongoRepository() repository.QuestionVersionRepository {
+	repo := &QuestionVersionMongoRepository{
+		CollRepo: NewCollRepository("dbapp", "question_versions"),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// The unique index also stops two concurrent edits from both becoming version n+1:
+	// the snapshot is stored before the question, the second edit fails with ErrDuplicateKey
+	err := repo.CollRepo.CreateIndexes(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "question_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}})
+	if err != nil {
+		log.Printf("question_versions: %v", err)
+	}
+	return repo
+}
+
+func (r *QuestionVersionMongoRepository) CreateVersion(ctx context.Context, version entity.QuestionVersion) error {
+	_, err := r.CollRepo.Create(ctx, version)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("version %d: %w", version.Version, repository.ErrDuplicateKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store question version: %w", err)
+	}
+	return nil
+}
+
+func (r *QuestionVersionMongoRepository) DeleteVersion(ctx context.Context, questionID primitive.ObjectID, version int) error {
+	if _, err := r.CollRepo.Delete(ctx, bson.M{"question_id": questionID, "version": version}); err != nil {
+		return fmt.Errorf("failed to delete question version: %w", err)
+	}
+	return nil
+}
+
+func (r *QuestionVersionMongoRepository) CreateVersions(ctx context.Context, versions []entity.QuestionVersion) error {
+	documents := make([]any, len(versions))
+	for i, version := range versions {
+		documents[i] = version
+	}
+	if _, err := r.CollRepo.CreateMany(ctx, documents); err != nil {
+		return fmt.Errorf("failed to store question versions: %w", err)
+	}
+	return nil
+}
+
+func (r *QuestionVersionMongoRepository) GetVersion(ctx context.Context, questionID primitive.ObjectID, version int) (*entity.QuestionVersion, error) {
+	result, err := r.CollRepo.GetOneWithProjection(ctx, bson.M{"question_id": questionID, "version": version}, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question version: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	var v entity.QuestionVersion
+	if err := decodeQuestionVersion(result, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *QuestionVersionMongoRepository) ListVersions(ctx context.Context, questionID primitive.ObjectID) ([]entity.QuestionVersion, error) {
+	results, err := r.CollRepo.GetAllWithOption(ctx, bson.M{"question_id": questionID}, options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list question versions: %w", err)
+	}
+	return decodeQuestionVersions(results)
+}
+
+func (r *QuestionVersionMongoRepository) GetVersions(ctx context.Context, refs []entity.QuestionVersionRef) ([]entity.QuestionVersion, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	pairs := make(bson.A, 0, len(refs))
+	for _, ref := range refs {
+		pairs = append(pairs, bson.M{"question_id": ref.QuestionID, "version": ref.Version})
+	}
+	results, err := r.CollRepo.GetWithProjection(ctx, bson.M{"$or": pairs}, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question versions: %w", err)
+	}
+	return decodeQuestionVersions(results)
+}
+
+func decodeQuestionVersions(results []any) ([]entity.QuestionVersion, error) {
+	versions := make([]entity.QuestionVersion, 0, len(results))
+	for _, result := range results {
+		var v entity.QuestionVersion
+		if err := decodeQuestionVersion(result, &v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func decodeQuestionVersion(result any, v *entity.QuestionVersion) error {
+	bsonBytes, err := bson.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("error marshaling question version: %v", err)
+	}
+	if err := bson.Unmarshal(bsonBytes, v); err != nil {
+		return fmt.Errorf("error unmarshaling question version: %v", err)
+	}
+	return nil
+}
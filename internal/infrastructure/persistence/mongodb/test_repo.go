@@ -84,3 +84,35 @@ func (r *TestMongoRepository) DeleteTest(ctx context.Context, id primitive.Objec
 	}
 	return nil
 }
+
+// GetTestByID implements repository.TestRepository.GetTestByID
+func (r *TestMongoRepository) GetTestByID(ctx context.Context, id primitive.ObjectID) (*entity.Test, error) {
+	result, err := r.CollRepo.GetFilter(ctx, bson.M{"_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	var test entity.Test
+	bsonBytes, err := bson.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling test: %v", err)
+	}
+	if err := bson.Unmarshal(bsonBytes, &test); err != nil {
+		return nil, fmt.Errorf("error unmarshaling test: %v", err)
+	}
+	return &test, nil
+}
+
+// InsertTest implements repository.TestRepository.InsertTest
+func (r *TestMongoRepository) InsertTest(ctx context.Context, test *entity.Test) error {
+	if test.AnswerUser == nil {
+		test.AnswerUser = []string{}
+	}
+	if _, err := r.CollRepo.Create(ctx, test); err != nil {
+		return fmt.Errorf("failed to insert test: %w", err)
+	}
+	return nil
+}
@@ -1,406 +1,319 @@
 package routes
 
-// import (
-// 	"encoding/json"
-// 	"fmt"
-// 	"net/http"
-
-// 	entity "quiz-app/internal/domain/entities"
-// 	"quiz-app/internal/domain/service"
-// 	"quiz-app/internal/pkg"
-
-// 	"go.mongodb.org/mongo-driver/bson/primitive"
-// )
-
-// type Question struct {
-// 	ID              string       `json:"_id"`
-// 	CreatedAt       string       `json:"created_at"`
-// 	Options         []Option     `json:"options"`
-// 	QuestionContent QuestionText `json:"question_content"`
-// 	Score           float64      `json:"score"`
-// 	Tags            []string     `json:"tags"`
-// 	Type            string       `json:"type"`
-// 	UpdatedAt       string       `json:"updated_at"`
-// }
-
-// type Metadata struct {
-// 	Author string `json:"author"`
-// }
-
-// type Option struct {
-// 	ID        string `json:"id"`
-// 	IsCorrect bool   `json:"iscorrect"`
-// 	Text      string `json:"text"`
-// 	Match     string `json:"match"`
-// 	ImageURL  string `json:"imageurl"`
-// 	Order     int    `json:"order"`
-// }
-
-// type QuestionText struct {
-// 	Text string `json:"text"`
-// }
-
-// type QuestionsFromRedis []Question
-
-// type RouterAnswer struct {
-// 	auth *service.AuthHandler
-
-// 	testUseCase     *service.TestUseCase
-// 	answerUseCase   *service.AnswerUseCase
-// 	questionUseCase *service.QuestionUseCase
-// 	redisUseCase    *service.RedisUseCase
-// }
-
-// func NewRouterAnswer(s *service.AnswerUseCase, t *service.TestUseCase, q *service.QuestionUseCase, r *service.RedisUseCase, auth *service.AuthHandler) RouterAnswer {
-// 	return RouterAnswer{
-// 		auth: auth,
-
-// 		answerUseCase:   s,
-// 		testUseCase:     t,
-// 		redisUseCase:    r,
-// 		questionUseCase: q,
-// 	}
-// }
-
-// func (rc RouterAnswer) GetAnswerRouter(r *Router) {
-// 	r.Router.Handle("/answer/update", rc.auth.AuthMiddleware(http.HandlerFunc(rc.updateAnswer))).Methods("POST")
-
-// 	r.Router.Handle("/answer/get", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getAnswer))).Methods("POST")
-
-// 	r.Router.Handle("/answer/user", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getAllAnswerByEmail))).Methods("GET")
-
-// }
-
-// func (rc RouterAnswer) updateAnswer(w http.ResponseWriter, req *http.Request) {
-// 	emailId := req.Context().Value("email_id").(string)
-// 	email := req.Context().Value("email").(string)
-
-// 	var newAnswer entity.TestAnswer
-// 	if err := json.NewDecoder(req.Body).Decode(&newAnswer); err != nil {
-// 		fmt.Println(err)
-// 		pkg.SendError(w, "Invalid answer field", http.StatusBadRequest)
-// 		return
-// 	}
-
-// 	newAnswer.EmailID = emailId
-// 	newAnswer.Email = email
-
-// 	idQuestions, err := rc.redisUseCase.HGetAll(req.Context(), newAnswer.TestId.Hex()+"_id")
-// 	idOptions, err := rc.redisUseCase.HGetAll(req.Context(), newAnswer.TestId.Hex())
-// 	rc.processIdOption(&newAnswer, idOptions, idQuestions)
-
-// 	cacheKeyTestQuestion := fmt.Sprintf("questions_id_%s", newAnswer.TestId.Hex())
-// 	cacheKeyTestQuestionDefault := fmt.Sprintf("questions_default_%s", newAnswer.TestId.Hex())
-// 	questionsFromRedis, err := rc.redisUseCase.HGetAll(req.Context(), cacheKeyTestQuestion)
-// 	if err != nil {
-// 		return
-// 	}
-// 	questionsDefaultFromRedis, err := rc.redisUseCase.Get(req.Context(), cacheKeyTestQuestionDefault)
-// 	if err != nil {
-// 		return
-// 	}
-
-// 	// Assuming your questions are stored under a specific key like "questions"
-// 	questionsJson, ok := questionsFromRedis["questions"]
-// 	if !ok {
-// 		fmt.Println("No questions found in Redis")
-// 		return
-// 	}
-
-// 	fmt.Println("questionsDefaultFromRedis: ", questionsDefaultFromRedis)
-
-// 	questions, err := decodeQuestions([]byte(questionsJson))
-// 	if err != nil {
-// 		fmt.Println("Failed to decode questions:", err)
-// 		return
-// 	}
-
-// 	newAnswer.TotalScore = float32(rc.processScore(questions[newAnswer.TestId.Hex()], &newAnswer))
-
-// 	err = rc.answerUseCase.UpdateAnswer(req.Context(), newAnswer)
-// 	if err != nil {
-// 		pkg.SendError(w, "Failed to create answer: "+err.Error(), http.StatusInternalServerError)
-// 		return
-// 	}
-// 	pkg.SendResponse(w, http.StatusCreated, newAnswer)
-// }
-
-// // decodeQuestions decodes a JSON byte array into a map[string][]map[string]interface{}
-// func decodeQuestions(questionsJson []byte) (map[string]map[string]map[string]interface{}, error) {
-// 	var questionsMap map[string]map[string]map[string]interface{}
-// 	err := json.Unmarshal(questionsJson, &questionsMap)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	return questionsMap, nil
-// }
-
-// func (rc RouterAnswer) processIdOption(answer *entity.TestAnswer, IdOption map[string]string, IdQuestion map[string]string) {
-// 	// Iterate through the list of questions in the answer
-// 	for i, question := range answer.ListQuestionAnswer {
-// 		// Convert the question ObjectID to its hex string
-// 		questionID := question.QuestionID.Hex()
-// 		// Check if the question ID exists in the IdOption map (from Redis)
-// 		if newQuestionID, ok := IdQuestion[questionID]; ok {
-// 			answer.ListQuestionAnswer[i].QuestionID, _ = primitive.ObjectIDFromHex(newQuestionID)
-// 			// Check if the question has options and update the option IDs
-// 			if len(question.Options) > 0 || len(question.FillInTheBlanks) > 0 {
-// 				// Get the option mapping from the IdOption map (which holds a JSON object)
-// 				if optionMappingStr, ok := IdOption[questionID]; ok {
-// 					// Unmarshal the option mapping (it's stored as a string in Redis, e.g., a JSON object)
-// 					optionMapping := make(map[string]string)
-// 					err := json.Unmarshal([]byte(optionMappingStr), &optionMapping)
-// 					if err != nil {
-// 						fmt.Println("Failed to unmarshal option mapping:", err)
-// 						continue
-// 					}
-// 					switch answer.ListQuestionAnswer[i].Type {
-// 					case "fill_in_the_blank":
-// 						for j, fillInData := range question.FillInTheBlanks {
-// 							oldOptionID := fillInData.ID.Hex()
-// 							// If the old option ID exists in the option mapping, update the option ID
-// 							if newOptionID, ok := optionMapping[oldOptionID]; ok {
-// 								// Convert the new Option ID to primitive.ObjectID
-// 								if newID, err := primitive.ObjectIDFromHex(newOptionID); err == nil {
-// 									answer.ListQuestionAnswer[i].FillInTheBlanks[j].ID = newID
-// 								} else {
-// 									fmt.Printf("Error converting new Option ID to ObjectID: %v\n", err)
-// 								}
-// 							}
-// 						}
-// 					default:
-// 						// Iterate through the options and replace their IDs based on the optionMapping
-// 						for j, opt := range question.Options {
-// 							oldOptionID := opt.ID.Hex()
-// 							// If the old option ID exists in the option mapping, update the option ID
-// 							if newOptionID, ok := optionMapping[oldOptionID]; ok {
-// 								answer.ListQuestionAnswer[i].Options[j].ID, _ = primitive.ObjectIDFromHex(newOptionID)
-// 							}
-// 						}
-// 					}
-// 				}
-// 			}
-// 		}
-// 	}
-// }
-
-// func (rc RouterAnswer) processScore(questions map[string]map[string]interface{}, answer *entity.TestAnswer) float64 {
-// 	var totalScore float64 = 0.0
-
-// 	// Check if there are any questions
-// 	if len(questions) == 0 {
-// 		fmt.Println("Error: No questions found")
-// 		return totalScore
-// 	}
-// 	// Iterate through the user's answers
-// 	for _, userAnswer := range answer.ListQuestionAnswer {
-// 		// Get question data based on user's answer
-// 		questionData, exists := questions[userAnswer.QuestionID.Hex()]
-// 		if !exists {
-// 			fmt.Println("Error: Question not found for ID:", userAnswer.QuestionID.Hex())
-// 			continue
-// 		}
-
-// 		// Print and validate question type
-// 		questionType, ok := questionData["type"].(string)
-// 		if !ok {
-// 			fmt.Println("Error: type is not of type string")
-// 			continue
-// 		}
-// 		// Print and validate score
-// 		score, ok := questionData["score"].(float64)
-// 		if !ok {
-// 			fmt.Println("Error: score is not of type float32")
-// 			continue
-// 		}
-// 		// Process the question based on its type
-// 		switch questionType {
-// 		case "order_question":
-// 			// Access the specific options for the order question
-// 			if optionMap, ok := questionData["optionMap"].(map[string]interface{}); ok {
-// 				fmt.Println(optionMap)
-// 				fmt.Printf("%T", optionMap[userAnswer.QuestionID.Hex()])
-
-// 				if options, ok := optionMap[userAnswer.QuestionID.Hex()].([]interface{}); ok {
-// 					totalScore += rc.processOrderQuestion(userAnswer.Options, options, score)
-// 				} else {
-// 					fmt.Println("Error: Options not found for order question.")
-// 				}
-// 				fmt.Println(totalScore)
-// 			} else {
-// 				fmt.Println("Error: optionMap is not of type map[string]interface{}")
-// 			}
-// 		case "multiple_choice_question":
-// 			// Access the specific options for the order question
-
-// 			if optionMap, ok := questionData["optionMap"].(map[string]interface{}); ok {
-// 				fmt.Println(optionMap)
-// 				fmt.Printf("%T", optionMap[userAnswer.QuestionID.Hex()])
-// 				if options, ok := optionMap[userAnswer.QuestionID.Hex()].([]interface{}); ok {
-// 					totalScore += rc.processMultipleChoice(userAnswer.Options, options, score)
-// 				} else {
-// 					fmt.Println("Error: Options not found for multi question.")
-// 				}
-// 				fmt.Println(totalScore)
-
-// 			} else {
-// 				fmt.Println("Error: optionMap is not of type map[string]interface{}")
-// 			}
-// 		case "fill_in_the_blank":
-// 			// Access the specific options for the order question
-// 			if optionMap, ok := questionData["optionMap"].(map[string]interface{}); ok {
-// 				if options, ok := optionMap[userAnswer.QuestionID.Hex()].([]interface{}); ok {
-// 					totalScore += rc.processFillInTheBlank(userAnswer.FillInTheBlanks, options, score)
-// 				} else {
-// 					fmt.Println("Error: Options not found for fill in blank question.")
-// 				}
-// 				fmt.Println(totalScore)
-
-// 			} else {
-// 				fmt.Println("Error: optionMap is not of type map[string]interface{}")
-// 			}
-// 		case "match_choice_question":
-// 			// Access the specific options for the order question
-// 			if optionMap, ok := questionData["optionMap"].(map[string]interface{}); ok {
-// 				if options, ok := optionMap[userAnswer.QuestionID.Hex()].(map[string]interface{}); ok {
-// 					totalScore += rc.processMatchChoice(userAnswer.Options, options, score)
-// 				} else {
-// 					fmt.Println("Error: Options not found for match question.")
-// 				}
-// 				fmt.Println(totalScore)
-
-// 			} else {
-// 				fmt.Println("Error: optionMap is not of type map[string]interface{}")
-// 			}
-// 		case "single_choice_question":
-// 			if optionMap, ok := questionData["optionMap"].(map[string]interface{}); ok {
-// 				if options, ok := optionMap[userAnswer.QuestionID.Hex()].([]interface{}); ok {
-// 					totalScore += rc.processSingleChoice(userAnswer.Options, options, score)
-// 				} else {
-// 					fmt.Println("Error: Options not found for single question.")
-// 				}
-// 				fmt.Println(totalScore)
-
-// 			} else {
-// 				fmt.Println("Error: optionMap is not of type map[string]interface{}")
-// 			}
-// 		default:
-// 			fmt.Println("Unknown question type:", questionType)
-// 		}
-
-// 	}
-// 	return totalScore
-// }
-
-// func (rc RouterAnswer) processOrderQuestion(userOptions []entity.OptionAnswer, questionData []interface{}, score float64) float64 {
-// 	for i, v := range questionData {
-// 		if v != userOptions[i].ID.Hex() {
-// 			return 0
-// 		}
-// 	}
-// 	return score
-// }
-
-// func (rc RouterAnswer) processMultipleChoice(userOptions []entity.OptionAnswer, questionData []interface{}, score float64) float64 {
-// 	fmt.Println("Option: ", userOptions)
-// 	fmt.Println("questionData: ", questionData)
-
-// 	// Tạo một bộ để lưu trữ các ID của tùy chọn đã chọn
-// 	selectedOptionIDs := make(map[string]struct{})
-// 	for _, option := range userOptions {
-// 		selectedOptionIDs[option.ID.Hex()] = struct{}{}
-// 	}
-
-// 	matchingCount := 0
-
-// 	// Kiểm tra các questionData và đếm số lượng khớp
-// 	for _, optionID := range questionData {
-// 		optionId, err := primitive.ObjectIDFromHex(optionID.(string))
-
-// 		fmt.Println(optionID)
-// 		fmt.Println(optionId)
-
-// 		if err == nil {
-// 			if _, exists := selectedOptionIDs[optionId.Hex()]; exists {
-// 				matchingCount++
-// 			}
-// 		}
-
-// 	}
-
-// 	// Kiểm tra số lượng khớp có bằng số lượng tùy chọn không
-// 	if matchingCount != len(questionData) {
-// 		fmt.Println("FALSE")
-// 		return 0
-// 	}
-
-// 	return score
-// }
-
-// func (rc RouterAnswer) processFillInTheBlank(userAnswers []entity.FillInTheBlank, questionData []interface{}, score float64) float64 {
-// 	totalScore := float64(0)
-
-// 	// Tạo một bản đồ để dễ dàng tra cứu câu trả lời đúng theo ID
-// 	correctAnswers := make(map[string]string)
-// 	for _, v := range questionData {
-// 		if data, ok := v.(map[string]interface{}); ok {
-// 			if id, ok := data["id"].(string); ok {
-// 				if correctAnswer, ok := data["correct_answer"].(string); ok {
-// 					correctAnswers[id] = correctAnswer // Lưu ID và câu trả lời đúng vào bản đồ
-// 				}
-// 			}
-// 		}
-// 	}
-
-// 	// Lặp qua các câu trả lời của người dùng
-// 	for _, userAnswer := range userAnswers {
-// 		if correctAnswer, exists := correctAnswers[userAnswer.ID.Hex()]; exists {
-// 			fmt.Println("correctAnswer, userAnswer", correctAnswer, userAnswer.CorrectAnswer)
-// 			if correctAnswer == userAnswer.CorrectAnswer {
-// 				fmt.Println(correctAnswer, userAnswer)
-// 				totalScore += score / float64(len(userAnswers)) // Cộng điểm nếu câu trả lời đúng
-// 			}
-// 		}
-// 	}
-
-// 	return totalScore
-// }
-
-// func (rc RouterAnswer) processMatchChoice(userOptions []entity.OptionAnswer, questionData map[string]interface{}, score float64) float64 {
-// 	if len(userOptions) != len(questionData) {
-// 		return 0
-// 	}
-
-// 	for _, userOption := range userOptions {
-// 		fmt.Println(questionData[userOption.MatchId.Hex()])
-// 		fmt.Println(userOption.ID.Hex())
-// 		if questionData[userOption.MatchId.Hex()] != userOption.ID.Hex() {
-// 			return 0
-// 		}
-// 	}
-// 	fmt.Println(score)
-// 	return score
-// }
-
-// func (rc RouterAnswer) processSingleChoice(userOptions []entity.OptionAnswer, questionData []interface{}, score float64) float64 {
-
-// 	if userOptions[0].ID.Hex() != questionData[0] {
-// 		return 0
-// 	}
-// 	return score
-// }
-
-// func (rc RouterAnswer) getAnswer(w http.ResponseWriter, req *http.Request) {
-
-// }
-
-// func (rc RouterAnswer) getAllAnswerByEmail(w http.ResponseWriter, req *http.Request) {
-// 	email := req.Context().Value("email").(string)
-
-// 	answers, err := rc.answerUseCase.GetAllAnswerByEmail(req.Context(), email)
-// 	if err != nil {
-// 		pkg.SendError(w, "Failed to retrieve answers: "+err.Error(), http.StatusInternalServerError)
-// 		return
-// 	}
-// 	pkg.SendResponse(w, http.StatusOK, answers)
-// }
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/service"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RouterAnswer struct {
+	auth *service.AuthHandler
+
+	answerUseCase *service.AnswerUseCase
+}
+
+func NewRouterAnswer(s *service.AnswerUseCase, auth *service.AuthHandler) RouterAnswer {
+	return RouterAnswer{
+		auth: auth,
+
+		answerUseCase: s,
+	}
+}
+
+func (rc RouterAnswer) GetAnswerRouter(r *Router) {
+	// Attempt lifecycle: start -> save (autosave) -> submit -> result
+	r.Router.Handle("/answer/start", rc.auth.AuthMiddleware(http.HandlerFunc(rc.startAttempt))).Methods("POST")
+	r.Router.Handle("/answer/save", rc.auth.AuthMiddleware(http.HandlerFunc(rc.saveProgress))).Methods("POST")
+	r.Router.Handle("/answer/submit", rc.auth.AuthMiddleware(http.HandlerFunc(rc.submitAnswer))).Methods("POST")
+	r.Router.Handle("/answer/result", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getResult))).Methods("POST")
+	r.Router.Handle("/answer/history", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getAttemptHistory))).Methods("POST")
+	r.Router.Handle("/answer/review", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getReview))).Methods("POST")
+
+	// Tests with sections: submit the current section, or move on in a linear one
+	r.Router.Handle("/answer/section/submit", rc.auth.AuthMiddleware(http.HandlerFunc(rc.submitSection))).Methods("POST")
+	r.Router.Handle("/answer/section/next", rc.auth.AuthMiddleware(http.HandlerFunc(rc.nextQuestion))).Methods("POST")
+
+	// Manual grading of essay and short answer questions (grade permission)
+	r.Router.Handle("/answer/grading/queue", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getGradingQueue))).Methods("POST")
+	r.Router.Handle("/answer/grading/grade", rc.auth.AuthMiddleware(http.HandlerFunc(rc.gradeResponse))).Methods("POST")
+
+	r.Router.Handle("/answer/get", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getAnswer))).Methods("POST")
+
+	r.Router.Handle("/answer/user", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getAllAnswerByEmail))).Methods("GET")
+
+}
+
+type attemptRequest struct {
+	ClassID primitive.ObjectID `json:"class_id"`
+	TestID  primitive.ObjectID `json:"test_id"`
+}
+
+func (rc RouterAnswer) startAttempt(w http.ResponseWriter, req *http.Request) {
+	emailId := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
+
+	var reqBody attemptRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	attempt, err := rc.answerUseCase.StartAttempt(req.Context(), reqBody.ClassID, reqBody.TestID, emailId, email)
+	if err != nil {
+		sendAttemptError(w, err, attempt)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, attempt)
+}
+
+func (rc RouterAnswer) saveProgress(w http.ResponseWriter, req *http.Request) {
+	newAnswer, ok := decodeAnswer(w, req)
+	if !ok {
+		return
+	}
+
+	attempt, err := rc.answerUseCase.SaveProgress(req.Context(), newAnswer)
+	if err != nil {
+		sendAttemptError(w, err, attempt)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, attempt)
+}
+
+// submitAnswer grades the submitted answer on the server and closes the attempt.
+// Any score sent by the client is ignored.
+// Per-question scores are only sent once the review policy releases the answers.
+func (rc RouterAnswer) submitAnswer(w http.ResponseWriter, req *http.Request) {
+	newAnswer, ok := decodeAnswer(w, req)
+	if !ok {
+		return
+	}
+
+	submitted, result, err := rc.answerUseCase.Submit(req.Context(), newAnswer)
+	if errors.Is(err, service.ErrAttemptExpired) {
+		pkg.SendResponse(w, http.StatusGone, primitive.M{"error": err.Error(), "answer": submitted, "result": result})
+		return
+	}
+	if err != nil {
+		sendAttemptError(w, err, submitted)
+		return
+	}
+	pkg.SendResponse(w, http.StatusCreated, primitive.M{"answer": submitted, "result": result})
+}
+
+// sectionRequest is the attempt's answers along with the section the client is on.
+type sectionRequest struct {
+	entity.TestAnswer
+	SectionID primitive.ObjectID `json:"section_id"`
+}
+
+func (rc RouterAnswer) submitSection(w http.ResponseWriter, req *http.Request) {
+	reqBody, ok := decodeSectionRequest(w, req)
+	if !ok {
+		return
+	}
+
+	attempt, err := rc.answerUseCase.SubmitSection(req.Context(), reqBody.TestAnswer, reqBody.SectionID)
+	if err != nil {
+		sendAttemptError(w, err, attempt)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, attempt)
+}
+
+func (rc RouterAnswer) nextQuestion(w http.ResponseWriter, req *http.Request) {
+	reqBody, ok := decodeSectionRequest(w, req)
+	if !ok {
+		return
+	}
+
+	attempt, err := rc.answerUseCase.NextQuestion(req.Context(), reqBody.TestAnswer, reqBody.SectionID)
+	if err != nil {
+		sendAttemptError(w, err, attempt)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, attempt)
+}
+
+func decodeSectionRequest(w http.ResponseWriter, req *http.Request) (sectionRequest, bool) {
+	var reqBody sectionRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid answer field", http.StatusBadRequest)
+		return sectionRequest{}, false
+	}
+
+	reqBody.EmailID = req.Context().Value("email_id").(string)
+	reqBody.Email = req.Context().Value("email").(string)
+	return reqBody, true
+}
+
+func (rc RouterAnswer) getResult(w http.ResponseWriter, req *http.Request) {
+	emailId := req.Context().Value("email_id").(string)
+
+	var reqBody attemptRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	attempt, err := rc.answerUseCase.GetResult(req.Context(), reqBody.ClassID, reqBody.TestID, emailId)
+	if err != nil {
+		sendAttemptError(w, err, nil)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, attempt)
+}
+
+func (rc RouterAnswer) getAttemptHistory(w http.ResponseWriter, req *http.Request) {
+	emailId := req.Context().Value("email_id").(string)
+
+	var reqBody attemptRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	history, err := rc.answerUseCase.GetAttemptHistory(req.Context(), reqBody.ClassID, reqBody.TestID, emailId)
+	if err != nil {
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, history)
+}
+
+// getReview shows the submitted answers next to the correct ones, when the test's review policy allows it.
+func (rc RouterAnswer) getReview(w http.ResponseWriter, req *http.Request) {
+	emailId := req.Context().Value("email_id").(string)
+
+	var reqBody struct {
+		attemptRequest
+		AnswerID primitive.ObjectID `json:"answer_id"` // Optional, defaults to the latest closed attempt
+	}
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	review, err := rc.answerUseCase.Review(req.Context(), reqBody.ClassID, reqBody.TestID, reqBody.AnswerID, emailId)
+	if errors.Is(err, service.ErrReviewNotReleased) {
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendAttemptError(w, err, nil)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, review)
+}
+
+func (rc RouterAnswer) getGradingQueue(w http.ResponseWriter, req *http.Request) {
+	emailId := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
+
+	var reqBody attemptRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	queue, err := rc.answerUseCase.GradingQueue(req.Context(), emailId, email, reqBody.ClassID, reqBody.TestID)
+	if err != nil {
+		sendAttemptError(w, err, nil)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, queue)
+}
+
+func (rc RouterAnswer) gradeResponse(w http.ResponseWriter, req *http.Request) {
+	emailId := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
+
+	var reqBody struct {
+		AnswerID   primitive.ObjectID `json:"answer_id"`
+		QuestionID primitive.ObjectID `json:"question_id"`
+		Score      float32            `json:"score"`
+		Comment    string             `json:"comment"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	graded, err := rc.answerUseCase.GradeResponse(req.Context(), emailId, email, reqBody.AnswerID, reqBody.QuestionID, reqBody.Score, reqBody.Comment)
+	if err != nil {
+		sendAttemptError(w, err, nil)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, graded)
+}
+
+// decodeAnswer reads a TestAnswer from the body and binds it to the caller.
+func decodeAnswer(w http.ResponseWriter, req *http.Request) (entity.TestAnswer, bool) {
+	var newAnswer entity.TestAnswer
+	if err := json.NewDecoder(req.Body).Decode(&newAnswer); err != nil {
+		pkg.SendError(w, "Invalid answer field", http.StatusBadRequest)
+		return entity.TestAnswer{}, false
+	}
+
+	newAnswer.EmailID = req.Context().Value("email_id").(string)
+	newAnswer.Email = req.Context().Value("email").(string)
+	return newAnswer, true
+}
+
+// sendAttemptError maps attempt lifecycle errors to HTTP status codes.
+func sendAttemptError(w http.ResponseWriter, err error, attempt *entity.TestAnswer) {
+	switch {
+	case errors.Is(err, service.ErrAttemptNotFound):
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrAttemptExpired):
+		pkg.SendResponse(w, http.StatusGone, primitive.M{"error": err.Error(), "answer": attempt})
+	case errors.Is(err, service.ErrAttemptClosed), errors.Is(err, service.ErrNoOpenSection), errors.Is(err, service.ErrSectionMismatch):
+		pkg.SendResponse(w, http.StatusConflict, primitive.M{"error": err.Error(), "answer": attempt})
+	case errors.Is(err, service.ErrGradeConflict):
+		pkg.SendError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrNoAttemptsLeft):
+		pkg.SendResponse(w, http.StatusForbidden, primitive.M{"error": err.Error(), "answer": attempt})
+	case errors.Is(err, service.ErrAttemptCooldown):
+		pkg.SendResponse(w, http.StatusTooManyRequests, primitive.M{"error": err.Error(), "answer": attempt})
+	case errors.Is(err, service.ErrNotClassOwner), errors.Is(err, service.ErrPermissionDenied), errors.Is(err, service.ErrNotClassMember), errors.Is(err, service.ErrTestNotOpen):
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+	default:
+		pkg.SendError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (rc RouterAnswer) getAnswer(w http.ResponseWriter, req *http.Request) {
+	emailId := req.Context().Value("email_id").(string)
+
+	var reqBody struct {
+		TestID primitive.ObjectID `json:"test_id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	answer, err := rc.answerUseCase.GetAnswer(req.Context(), reqBody.TestID, emailId)
+	if err != nil {
+		pkg.SendError(w, "Answer not found", http.StatusNotFound)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, answer)
+}
+
+func (rc RouterAnswer) getAllAnswerByEmail(w http.ResponseWriter, req *http.Request) {
+	email := req.Context().Value("email").(string)
+
+	answers, err := rc.answerUseCase.GetAllAnswerByEmail(req.Context(), email)
+	if err != nil {
+		pkg.SendError(w, "Failed to retrieve answers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, answers)
+}
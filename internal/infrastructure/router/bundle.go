@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-app/internal/domain/service"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxBundleUpload caps the size of an uploaded bundle, media included.
+const maxBundleUpload = 100 << 20
+
+type RouterBundle struct {
+	auth *service.AuthHandler
+
+	bundleUseCase *service.BundleUseCase
+}
+
+func NewRouterBundle(s *service.BundleUseCase, auth *service.AuthHandler) RouterBundle {
+	return RouterBundle{
+		auth: auth,
+
+		bundleUseCase: s,
+	}
+}
+
+func (rb RouterBundle) GetBundleRouter(r *Router) {
+	// Export as ?format=qti (IMS QTI 2.1) or ?format=json (bundle, re-importable)
+	r.Router.Handle("/questions/export", rb.auth.AuthMiddleware(http.HandlerFunc(rb.exportQuestions))).Methods("GET")
+	r.Router.Handle("/tests/export", rb.auth.AuthMiddleware(http.HandlerFunc(rb.exportTest))).Methods("GET")
+	r.Router.Handle("/questions/import/bundle", rb.auth.AuthMiddleware(http.HandlerFunc(rb.importBundle))).Methods("POST")
+}
+
+// exportQuestions exports the questions of ?ids= (comma separated), or the whole bank.
+func (rb RouterBundle) exportQuestions(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
+
+	var ids []primitive.ObjectID
+	for _, hex := range splitQueryList(req.URL.Query()["ids"]) {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			pkg.SendError(w, "Invalid question id "+hex, http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	format := exportFormat(req)
+	data, err := rb.bundleUseCase.ExportQuestions(req.Context(), emailID, email, format, ids)
+	if err != nil {
+		sendExportError(w, err)
+		return
+	}
+	sendPackage(w, "questions", format, data)
+}
+
+func (rb RouterBundle) exportTest(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
+
+	testID, err := primitive.ObjectIDFromHex(req.URL.Query().Get("test_id"))
+	if err != nil {
+		pkg.SendError(w, "Invalid test id", http.StatusBadRequest)
+		return
+	}
+
+	format := exportFormat(req)
+	data, err := rb.bundleUseCase.ExportTest(req.Context(), emailID, email, format, testID)
+	if err != nil {
+		sendExportError(w, err)
+		return
+	}
+	sendPackage(w, "test-"+testID.Hex(), format, data)
+}
+
+// importBundle imports a JSON bundle sent as the "file" field of a multipart form or as the raw body.
+func (rb RouterBundle) importBundle(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
+	req.Body = http.MaxBytesReader(w, req.Body, maxBundleUpload)
+	dryRun, _ := strconv.ParseBool(req.URL.Query().Get("dry_run"))
+
+	var body io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := req.FormFile("file")
+		if err != nil {
+			pkg.SendError(w, "Error retrieving the file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		pkg.SendError(w, "Failed to read the bundle", http.StatusBadRequest)
+		return
+	}
+
+	result, err := rb.bundleUseCase.ImportBundle(req.Context(), emailID, email, data, dryRun)
+	if errors.Is(err, service.ErrInvalidBundle) {
+		pkg.SendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		pkg.SendError(w, "Failed to import bundle", http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusOK
+	if result.Imported > 0 {
+		status = http.StatusCreated
+	}
+	pkg.SendResponse(w, status, result)
+}
+
+// exportFormat reads ?format=, the JSON bundle by default.
+func exportFormat(req *http.Request) string {
+	if format := strings.ToLower(req.URL.Query().Get("format")); format != "" {
+		return format
+	}
+	return service.ExportFormatJSON
+}
+
+func sendPackage(w http.ResponseWriter, name, format string, data []byte) {
+	filename := fmt.Sprintf("%s-%s-%s.zip", name, format, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func sendExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownExportFormat):
+		pkg.SendError(w, "format must be qti or json", http.StatusBadRequest)
+	case errors.Is(err, service.ErrQuestionNotFound), errors.Is(err, service.ErrTestNotFound):
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrPermissionDenied):
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+	default:
+		pkg.SendError(w, "Failed to export", http.StatusInternalServerError)
+	}
+}
@@ -1,12 +1,14 @@
 package routes
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
+	"unicode"
 
 	entity "quiz-app/internal/domain/entities"
 	"quiz-app/internal/domain/service"
@@ -18,22 +20,35 @@ import (
 type routerClass struct {
 	auth         service.AuthHandler
 	classUseCase service.ClassUseCase
-	redisUseCase service.RedisUseCase
+	joinCodes    *service.JoinCodeUseCase
 }
 
-func NewRouterClass(classUC service.ClassUseCase, redisUC service.RedisUseCase, auth service.AuthHandler) routerClass {
+func NewRouterClass(classUC service.ClassUseCase, joinCodes *service.JoinCodeUseCase, auth service.AuthHandler) routerClass {
 	return routerClass{
 		auth:         auth,
 		classUseCase: classUC,
-		redisUseCase: redisUC,
+		joinCodes:    joinCodes,
 	}
 }
 
 // ==== Request Body Structs ====
+
+// classIDRequest creates a code through the older /class/codeclass route. TestID is not used any more.
 type classIDRequest struct {
-	ID     string   `json:"_id"`
-	Minute int      `json:"minute"`
-	TestID []string `json:"test_id"`
+	ID     primitive.ObjectID `json:"_id"`
+	Minute int                `json:"minute"`
+	TestID []string           `json:"test_id"`
+}
+
+type joinCodeRequest struct {
+	ClassID          primitive.ObjectID `json:"class_id"`
+	ExpiresInMinutes int                `json:"expires_in_minutes"` // 0 = không hết hạn
+	MaxUses          int                `json:"max_uses"`           // 0 = unlimited
+	AllowedDomains   []string           `json:"allowed_domains"`
+}
+
+type revokeJoinCodeRequest struct {
+	CodeID primitive.ObjectID `json:"code_id"`
 }
 
 type classDeleteRequest struct {
@@ -44,6 +59,37 @@ type joinClassRequest struct {
 	ID string `json:"_id"`
 }
 
+type accommodationRequest struct {
+	ClassID       primitive.ObjectID   `json:"class_id"`
+	Accommodation entity.Accommodation `json:"accommodation"`
+}
+
+type releaseAnswersRequest struct {
+	ClassID  primitive.ObjectID `json:"class_id"`
+	TestID   primitive.ObjectID `json:"test_id"`
+	Released bool               `json:"released"`
+}
+
+type blindGradingRequest struct {
+	ClassID primitive.ObjectID `json:"class_id"`
+	TestID  primitive.ObjectID `json:"test_id"`
+	Blind   bool               `json:"blind"`
+}
+
+// rosterRequest targets a list of emails. An entry may hold several emails separated
+// by commas, semicolons or spaces, e.g. a column pasted from a spreadsheet.
+type rosterRequest struct {
+	ClassID primitive.ObjectID `json:"class_id"`
+	Emails  []string           `json:"emails"`
+	Role    string             `json:"role,omitempty"`
+}
+
+type accommodationDeleteRequest struct {
+	ClassID primitive.ObjectID `json:"class_id"`
+	Email   string             `json:"email"`
+	TestID  primitive.ObjectID `json:"test_id"`
+}
+
 // ==== Helpers ====
 func DecodeJSONBody[T any](w http.ResponseWriter, r *http.Request, dst *T) bool {
 	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
@@ -53,14 +99,6 @@ func DecodeJSONBody[T any](w http.ResponseWriter, r *http.Request, dst *T) bool
 	return true
 }
 
-func generateRandomKey() (string, error) {
-	bytes := make([]byte, 5)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", fmt.Errorf("failed to generate random key: %w", err)
-	}
-	return hex.EncodeToString(bytes), nil
-}
-
 // ==== Handlers ====
 func (rc routerClass) createClass(w http.ResponseWriter, req *http.Request) {
 	emailID, _ := req.Context().Value("email_id").(string)
@@ -75,8 +113,9 @@ func (rc routerClass) createClass(w http.ResponseWriter, req *http.Request) {
 	newClass.UpdatedAt = time.Now()
 	newClass.AuthorMail = email
 	newClass.EmailID = emailID
-	newClass.StudentAccept = []string{emailID}
+	newClass.StudentAccept = []string{}
 	newClass.StudentsWait = []string{}
+	newClass.Members = nil
 
 	if newClass.TestID == nil {
 		newClass.TestID = []primitive.ObjectID{}
@@ -125,12 +164,9 @@ func (rc routerClass) updateClass(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	classToUpdate.EmailID = emailID
-	classToUpdate.AuthorMail = email
-
-	updatedClass, err := rc.classUseCase.UpdateClass(req.Context(), &classToUpdate)
+	updatedClass, err := rc.classUseCase.UpdateClass(req.Context(), emailID, email, &classToUpdate)
 	if err != nil {
-		pkg.SendError(w, "Failed to update class", http.StatusInternalServerError)
+		sendClassError(w, err)
 		return
 	}
 
@@ -147,13 +183,14 @@ func (rc routerClass) deleteClass(w http.ResponseWriter, req *http.Request) {
 
 	err := rc.classUseCase.DeleteClass(req.Context(), emailID, reqBody.ID)
 	if err != nil {
-		pkg.SendError(w, "Error deleting class", http.StatusInternalServerError)
+		sendClassError(w, err)
 		return
 	}
 
 	pkg.SendResponse(w, http.StatusOK, fmt.Sprintf("Class %v deleted", reqBody.ID.Hex()))
 }
 
+// joinClass adds the caller to the class of the join code sent as _id.
 func (rc routerClass) joinClass(w http.ResponseWriter, req *http.Request) {
 	email, _ := req.Context().Value("email").(string)
 
@@ -162,51 +199,196 @@ func (rc routerClass) joinClass(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	dataJSON, err := rc.redisUseCase.Get(req.Context(), reqBody.ID)
+	member, err := rc.joinCodes.RedeemJoinCode(req.Context(), reqBody.ID, email)
 	if err != nil {
-		pkg.SendError(w, "Error fetching class from Redis", http.StatusInternalServerError)
+		sendJoinCodeError(w, err)
 		return
 	}
 
-	var redisData struct {
-		ClassID     string   `json:"class_id"`
-		EmailAuthor string   `json:"email"`
-		TestID      []string `json:"test_id"`
+	pkg.SendResponse(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Joined class: %s", reqBody.ID),
+		"member":  member,
+	})
+}
+
+// setAccommodation stores extra time or a custom window for one student of the class.
+func (rc routerClass) setAccommodation(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody accommodationRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	acc, err := rc.classUseCase.SetAccommodation(req.Context(), emailID, email, reqBody.ClassID, reqBody.Accommodation)
+	if err != nil {
+		sendClassError(w, err)
+		return
 	}
-	if err := json.Unmarshal([]byte(dataJSON), &redisData); err != nil {
-		pkg.SendError(w, "Error decoding Redis data", http.StatusInternalServerError)
+
+	pkg.SendResponse(w, http.StatusOK, acc)
+}
+
+func (rc routerClass) removeAccommodation(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody accommodationDeleteRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
 		return
 	}
 
-	classID, err := primitive.ObjectIDFromHex(redisData.ClassID)
+	err := rc.classUseCase.RemoveAccommodation(req.Context(), emailID, email, reqBody.ClassID, reqBody.Email, reqBody.TestID)
+	if err != nil {
+		sendClassError(w, err)
+		return
+	}
+
+	pkg.SendResponse(w, http.StatusOK, "Accommodation removed")
+}
+
+// releaseAnswers lets the owner release the answer keys of a test with the manual review policy.
+func (rc routerClass) releaseAnswers(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody releaseAnswersRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	err := rc.classUseCase.ReleaseAnswers(req.Context(), emailID, email, reqBody.ClassID, reqBody.TestID, reqBody.Released)
+	if err != nil {
+		sendClassError(w, err)
+		return
+	}
+
+	pkg.SendResponse(w, http.StatusOK, reqBody)
+}
+
+// setBlindGrading lets the owner hide student emails from the graders of a test.
+func (rc routerClass) setBlindGrading(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody blindGradingRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	err := rc.classUseCase.SetBlindGrading(req.Context(), emailID, email, reqBody.ClassID, reqBody.TestID, reqBody.Blind)
+	if err != nil {
+		sendClassError(w, err)
+		return
+	}
+
+	pkg.SendResponse(w, http.StatusOK, reqBody)
+}
+
+func (rc routerClass) getRoster(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	classID, err := primitive.ObjectIDFromHex(req.URL.Query().Get("class_id"))
 	if err != nil {
 		pkg.SendError(w, "Invalid class ID", http.StatusBadRequest)
 		return
 	}
 
-	var testIDs []primitive.ObjectID
-	for i, tid := range redisData.TestID {
-		id, err := primitive.ObjectIDFromHex(tid)
-		if err != nil {
-			pkg.SendError(w, fmt.Sprintf("Invalid test ID at index %d: %s", i, tid), http.StatusBadRequest)
-			return
+	roster, err := rc.classUseCase.GetRoster(req.Context(), classID, emailID, email)
+	if err != nil {
+		sendClassError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, roster)
+}
+
+// addMembers bulk-adds emails to the class with the role of the request, students by default.
+func (rc routerClass) addMembers(w http.ResponseWriter, req *http.Request) {
+	rc.changeRoster(w, req, func(ctx context.Context, r rosterRequest, emailID, email string) (*service.RosterResult, error) {
+		return rc.classUseCase.AddMembers(ctx, r.ClassID, emailID, email, r.Emails, r.Role)
+	})
+}
+
+func (rc routerClass) approveMembers(w http.ResponseWriter, req *http.Request) {
+	rc.changeRoster(w, req, func(ctx context.Context, r rosterRequest, emailID, email string) (*service.RosterResult, error) {
+		return rc.classUseCase.ApproveMembers(ctx, r.ClassID, emailID, email, r.Emails)
+	})
+}
+
+func (rc routerClass) rejectMembers(w http.ResponseWriter, req *http.Request) {
+	rc.changeRoster(w, req, func(ctx context.Context, r rosterRequest, emailID, email string) (*service.RosterResult, error) {
+		return rc.classUseCase.RejectMembers(ctx, r.ClassID, emailID, email, r.Emails)
+	})
+}
+
+func (rc routerClass) removeMembers(w http.ResponseWriter, req *http.Request) {
+	rc.changeRoster(w, req, func(ctx context.Context, r rosterRequest, emailID, email string) (*service.RosterResult, error) {
+		return rc.classUseCase.RemoveMembers(ctx, r.ClassID, emailID, email, r.Emails)
+	})
+}
+
+func (rc routerClass) banMembers(w http.ResponseWriter, req *http.Request) {
+	rc.changeRoster(w, req, func(ctx context.Context, r rosterRequest, emailID, email string) (*service.RosterResult, error) {
+		return rc.classUseCase.BanMembers(ctx, r.ClassID, emailID, email, r.Emails)
+	})
+}
+
+// setMemberRole gives the role of the request to the first email.
+func (rc routerClass) setMemberRole(w http.ResponseWriter, req *http.Request) {
+	rc.changeRoster(w, req, func(ctx context.Context, r rosterRequest, emailID, email string) (*service.RosterResult, error) {
+		if len(r.Emails) != 1 {
+			return nil, errors.New("exactly one email is required")
 		}
-		testIDs = append(testIDs, id)
+		return rc.classUseCase.SetMemberRole(ctx, r.ClassID, emailID, email, r.Emails[0], r.Role)
+	})
+}
+
+func (rc routerClass) changeRoster(w http.ResponseWriter, req *http.Request, change func(ctx context.Context, r rosterRequest, emailID, email string) (*service.RosterResult, error)) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody rosterRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
 	}
+	reqBody.Emails = splitEmails(reqBody.Emails)
 
-	err = rc.classUseCase.JoinClass(req.Context(), classID, testIDs, redisData.EmailAuthor, email)
+	result, err := change(req.Context(), reqBody, emailID, email)
 	if err != nil {
-		pkg.SendError(w, "Error joining class", http.StatusInternalServerError)
+		sendClassError(w, err)
 		return
 	}
+	pkg.SendResponse(w, http.StatusOK, result)
+}
 
-	pkg.SendResponse(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Joined class: %s", reqBody.ID),
-	})
+func splitEmails(entries []string) []string {
+	var emails []string
+	for _, entry := range entries {
+		emails = append(emails, strings.FieldsFunc(entry, func(r rune) bool {
+			return r == ',' || r == ';' || unicode.IsSpace(r)
+		})...)
+	}
+	return emails
+}
+
+// sendClassError maps class permission errors to HTTP status codes.
+func sendClassError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotClassOwner), errors.Is(err, service.ErrPermissionDenied), errors.Is(err, service.ErrMemberBanned):
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrNotClassMember):
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+	default:
+		pkg.SendError(w, err.Error(), http.StatusBadRequest)
+	}
 }
 
+// createCodeClass creates a join code valid for ?minute= minutes and answers the code only, for older clients.
 func (rc routerClass) createCodeClass(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
 	email, _ := req.Context().Value("email").(string)
 
 	var reqBody classIDRequest
@@ -214,35 +396,107 @@ func (rc routerClass) createCodeClass(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	key, err := generateRandomKey()
+	code, err := rc.joinCodes.CreateJoinCode(req.Context(), emailID, email, reqBody.ID, service.JoinCodeOptions{ExpiresInMinutes: reqBody.Minute})
+	if err != nil {
+		sendJoinCodeError(w, err)
+		return
+	}
+
+	pkg.SendResponse(w, http.StatusOK, code.Code)
+}
+
+func (rc routerClass) createJoinCode(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody joinCodeRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	code, err := rc.joinCodes.CreateJoinCode(req.Context(), emailID, email, reqBody.ClassID, service.JoinCodeOptions{
+		ExpiresInMinutes: reqBody.ExpiresInMinutes,
+		MaxUses:          reqBody.MaxUses,
+		AllowedDomains:   splitEmails(reqBody.AllowedDomains),
+	})
+	if err != nil {
+		sendJoinCodeError(w, err)
+		return
+	}
+
+	pkg.SendResponse(w, http.StatusCreated, code)
+}
+
+func (rc routerClass) getJoinCodes(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	classID, err := primitive.ObjectIDFromHex(req.URL.Query().Get("class_id"))
+	if err != nil {
+		pkg.SendError(w, "Invalid class ID", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := rc.joinCodes.ListJoinCodes(req.Context(), emailID, email, classID)
 	if err != nil {
-		pkg.SendError(w, "Failed to generate code", http.StatusInternalServerError)
+		sendJoinCodeError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, codes)
+}
+
+func (rc routerClass) revokeJoinCode(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody revokeJoinCodeRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
 		return
 	}
 
-	data := struct {
-		ClassID string   `json:"class_id"`
-		Email   string   `json:"email"`
-		TestID  []string `json:"test_id"`
-	}{
-		ClassID: reqBody.ID,
-		Email:   email,
-		TestID:  reqBody.TestID,
+	code, err := rc.joinCodes.RevokeJoinCode(req.Context(), emailID, email, reqBody.CodeID)
+	if err != nil {
+		sendJoinCodeError(w, err)
+		return
 	}
+	pkg.SendResponse(w, http.StatusOK, code)
+}
+
+// getJoinCodeLog returns who used the codes of ?class_id=, or only ?code_id=.
+func (rc routerClass) getJoinCodeLog(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
 
-	dataJSON, err := json.Marshal(data)
+	classID, err := primitive.ObjectIDFromHex(req.URL.Query().Get("class_id"))
 	if err != nil {
-		pkg.SendError(w, "Failed to encode data", http.StatusInternalServerError)
+		pkg.SendError(w, "Invalid class ID", http.StatusBadRequest)
+		return
+	}
+	codeID, ok := queryObjectID(w, req, "code_id")
+	if !ok {
 		return
 	}
 
-	err = rc.redisUseCase.Set(req.Context(), key, string(dataJSON), time.Duration(reqBody.Minute)*time.Minute)
+	uses, err := rc.joinCodes.JoinCodeLog(req.Context(), emailID, email, classID, codeID)
 	if err != nil {
-		pkg.SendError(w, "Failed to store code", http.StatusInternalServerError)
+		sendJoinCodeError(w, err)
 		return
 	}
+	pkg.SendResponse(w, http.StatusOK, uses)
+}
 
-	pkg.SendResponse(w, http.StatusOK, key)
+// sendJoinCodeError maps join code errors to HTTP status codes, and class errors through sendClassError.
+func sendJoinCodeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrJoinCodeNotFound):
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrJoinCodeExpired), errors.Is(err, service.ErrJoinCodeRevoked), errors.Is(err, service.ErrJoinCodeUsedUp):
+		pkg.SendError(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrDomainNotAllowed):
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+	default:
+		sendClassError(w, err)
+	}
 }
 
 // ==== Router Setup ====
@@ -254,4 +508,23 @@ func (rc routerClass) GetClassRouter(r *Router) {
 	r.Router.Handle("/getclass", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getAllClassByEmail))).Methods("GET")
 	r.Router.Handle("/class/codeclass", rc.auth.AuthMiddleware(http.HandlerFunc(rc.createCodeClass))).Methods("POST")
 	r.Router.Handle("/class/joinclass", rc.auth.AuthMiddleware(http.HandlerFunc(rc.joinClass))).Methods("POST")
+	r.Router.Handle("/class/accommodation", rc.auth.AuthMiddleware(http.HandlerFunc(rc.setAccommodation))).Methods("POST")
+	r.Router.Handle("/class/accommodation", rc.auth.AuthMiddleware(http.HandlerFunc(rc.removeAccommodation))).Methods("DELETE")
+	r.Router.Handle("/class/release", rc.auth.AuthMiddleware(http.HandlerFunc(rc.releaseAnswers))).Methods("POST")
+	r.Router.Handle("/class/blind-grading", rc.auth.AuthMiddleware(http.HandlerFunc(rc.setBlindGrading))).Methods("POST")
+
+	// Join codes: need manage_roster, every use is logged
+	r.Router.Handle("/class/codes", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getJoinCodes))).Methods("GET")
+	r.Router.Handle("/class/codes", rc.auth.AuthMiddleware(http.HandlerFunc(rc.createJoinCode))).Methods("POST")
+	r.Router.Handle("/class/codes/revoke", rc.auth.AuthMiddleware(http.HandlerFunc(rc.revokeJoinCode))).Methods("POST")
+	r.Router.Handle("/class/codes/log", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getJoinCodeLog))).Methods("GET")
+
+	// Roster: needs manage_roster to change it (owner and co-teachers by role), view to see it
+	r.Router.Handle("/class/roster", rc.auth.AuthMiddleware(http.HandlerFunc(rc.getRoster))).Methods("GET")
+	r.Router.Handle("/class/roster/add", rc.auth.AuthMiddleware(http.HandlerFunc(rc.addMembers))).Methods("POST")
+	r.Router.Handle("/class/roster/approve", rc.auth.AuthMiddleware(http.HandlerFunc(rc.approveMembers))).Methods("POST")
+	r.Router.Handle("/class/roster/reject", rc.auth.AuthMiddleware(http.HandlerFunc(rc.rejectMembers))).Methods("POST")
+	r.Router.Handle("/class/roster/remove", rc.auth.AuthMiddleware(http.HandlerFunc(rc.removeMembers))).Methods("POST")
+	r.Router.Handle("/class/roster/ban", rc.auth.AuthMiddleware(http.HandlerFunc(rc.banMembers))).Methods("POST")
+	r.Router.Handle("/class/roster/role", rc.auth.AuthMiddleware(http.HandlerFunc(rc.setMemberRole))).Methods("POST")
 }
@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"quiz-app/internal/domain/service"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RouterInvitation struct {
+	auth *service.AuthHandler
+
+	invitations *service.InvitationUseCase
+}
+
+func NewRouterInvitation(invitations *service.InvitationUseCase, auth *service.AuthHandler) RouterInvitation {
+	return RouterInvitation{
+		auth: auth,
+
+		invitations: invitations,
+	}
+}
+
+func (ri RouterInvitation) GetInvitationRouter(r *Router) {
+	// Managed by users with the manage_roster permission on the class
+	r.Router.Handle("/class/invitations", ri.auth.AuthMiddleware(http.HandlerFunc(ri.getInvitations))).Methods("GET")
+	r.Router.Handle("/class/invitations", ri.auth.AuthMiddleware(http.HandlerFunc(ri.invite))).Methods("POST")
+	r.Router.Handle("/class/invitations/resend", ri.auth.AuthMiddleware(http.HandlerFunc(ri.resendInvitation))).Methods("POST")
+	r.Router.Handle("/class/invitations/revoke", ri.auth.AuthMiddleware(http.HandlerFunc(ri.revokeInvitation))).Methods("POST")
+
+	// Called by the page of the link in the mail, with the token of the link
+	r.Router.Handle("/class/invitations/accept", ri.auth.AuthMiddleware(http.HandlerFunc(ri.acceptInvitation))).Methods("POST")
+}
+
+type inviteRequest struct {
+	ClassID       primitive.ObjectID `json:"class_id"`
+	Emails        []string           `json:"emails"` // Có thể dán cả danh sách, cách nhau bởi dấu phẩy
+	ExpiresInDays int                `json:"expires_in_days"`
+	Message       string             `json:"message"`
+}
+
+type invitationIDRequest struct {
+	InvitationID  primitive.ObjectID `json:"invitation_id"`
+	ExpiresInDays int                `json:"expires_in_days"`
+}
+
+type acceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+func (ri RouterInvitation) getInvitations(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	classID, err := primitive.ObjectIDFromHex(req.URL.Query().Get("class_id"))
+	if err != nil {
+		pkg.SendError(w, "Invalid class ID", http.StatusBadRequest)
+		return
+	}
+
+	invitations, err := ri.invitations.ListInvitations(req.Context(), emailID, email, classID)
+	if err != nil {
+		sendInvitationError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, invitations)
+}
+
+func (ri RouterInvitation) invite(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody inviteRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	result, err := ri.invitations.Invite(req.Context(), emailID, email, reqBody.ClassID, splitEmails(reqBody.Emails), service.InvitationOptions{
+		ExpiresInDays: reqBody.ExpiresInDays,
+		Message:       reqBody.Message,
+	})
+	if err != nil {
+		sendInvitationError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusCreated, result)
+}
+
+func (ri RouterInvitation) resendInvitation(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody invitationIDRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	invitation, err := ri.invitations.ResendInvitation(req.Context(), emailID, email, reqBody.InvitationID, reqBody.ExpiresInDays)
+	if err != nil {
+		sendInvitationError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, invitation)
+}
+
+func (ri RouterInvitation) revokeInvitation(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody invitationIDRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	invitation, err := ri.invitations.RevokeInvitation(req.Context(), emailID, email, reqBody.InvitationID)
+	if err != nil {
+		sendInvitationError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, invitation)
+}
+
+func (ri RouterInvitation) acceptInvitation(w http.ResponseWriter, req *http.Request) {
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody acceptInvitationRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	member, err := ri.invitations.AcceptInvitation(req.Context(), reqBody.Token, email)
+	if err != nil {
+		sendInvitationError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, member)
+}
+
+// sendInvitationError maps invitation errors to HTTP status codes, and class errors through sendClassError.
+func sendInvitationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvitationNotFound):
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvitationExpired), errors.Is(err, service.ErrInvitationRevoked), errors.Is(err, service.ErrInvitationUsed):
+		pkg.SendError(w, err.Error(), http.StatusGone)
+	case errors.Is(err, service.ErrInvitationEmailMismatch):
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+	default:
+		sendClassError(w, err)
+	}
+}
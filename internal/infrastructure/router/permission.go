@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/service"
+	"quiz-app/internal/pkg"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RouterPermission struct {
+	auth *service.AuthHandler
+
+	authz *service.AuthorizationService
+}
+
+func NewRouterPermission(authz *service.AuthorizationService, auth *service.AuthHandler) RouterPermission {
+	return RouterPermission{
+		auth: auth,
+
+		authz: authz,
+	}
+}
+
+func (rp RouterPermission) GetPermissionRouter(r *Router) {
+	// What the caller may do with ?resource_type=class|test&resource_id=
+	r.Router.Handle("/permissions", rp.auth.AuthMiddleware(http.HandlerFunc(rp.getPermissions))).Methods("GET")
+	r.Router.Handle("/permissions/shared", rp.auth.AuthMiddleware(http.HandlerFunc(rp.getSharedWithMe))).Methods("GET")
+
+	// Grants on a class or test, managed by its owner
+	r.Router.Handle("/permissions/grants", rp.auth.AuthMiddleware(http.HandlerFunc(rp.getGrants))).Methods("GET")
+	r.Router.Handle("/permissions/grants", rp.auth.AuthMiddleware(http.HandlerFunc(rp.grantPermissions))).Methods("POST")
+	r.Router.Handle("/permissions/grants", rp.auth.AuthMiddleware(http.HandlerFunc(rp.revokePermissions))).Methods("DELETE")
+}
+
+type grantRequest struct {
+	ResourceType string             `json:"resource_type"`
+	ResourceID   primitive.ObjectID `json:"resource_id"`
+	Email        string             `json:"email"`
+	Permissions  []string           `json:"permissions"`
+}
+
+func (rp RouterPermission) getPermissions(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	resourceID, ok := queryObjectID(w, req, "resource_id")
+	if !ok {
+		return
+	}
+	perms, err := rp.authz.Permissions(req.Context(), emailID, email, req.URL.Query().Get("resource_type"), resourceID)
+	if err != nil {
+		sendPermissionError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, perms)
+}
+
+// getSharedWithMe lists the classes and tests other users gave the caller permissions on.
+func (rp RouterPermission) getSharedWithMe(w http.ResponseWriter, req *http.Request) {
+	email, _ := req.Context().Value("email").(string)
+
+	grants, err := rp.authz.SharedWith(req.Context(), email)
+	if err != nil {
+		sendPermissionError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, grants)
+}
+
+func (rp RouterPermission) getGrants(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	resourceID, ok := queryObjectID(w, req, "resource_id")
+	if !ok {
+		return
+	}
+	grants, err := rp.authz.ListGrants(req.Context(), emailID, email, req.URL.Query().Get("resource_type"), resourceID)
+	if err != nil {
+		sendPermissionError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, grants)
+}
+
+// grantPermissions gives an email permissions on a class or test, replacing those it had.
+func (rp RouterPermission) grantPermissions(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody grantRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	grant, err := rp.authz.GrantPermissions(req.Context(), emailID, email, entity.Grant{
+		ResourceType: reqBody.ResourceType,
+		ResourceID:   reqBody.ResourceID,
+		Email:        reqBody.Email,
+		Permissions:  reqBody.Permissions,
+	})
+	if err != nil {
+		sendPermissionError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, grant)
+}
+
+func (rp RouterPermission) revokePermissions(w http.ResponseWriter, req *http.Request) {
+	emailID, _ := req.Context().Value("email_id").(string)
+	email, _ := req.Context().Value("email").(string)
+
+	var reqBody grantRequest
+	if !DecodeJSONBody(w, req, &reqBody) {
+		return
+	}
+
+	err := rp.authz.RevokePermissions(req.Context(), emailID, email, reqBody.ResourceType, reqBody.ResourceID, reqBody.Email)
+	if err != nil {
+		sendPermissionError(w, err)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, reqBody)
+}
+
+// queryObjectID reads an optional ObjectID from the query string, answering 400 when it is malformed.
+func queryObjectID(w http.ResponseWriter, req *http.Request, name string) (primitive.ObjectID, bool) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return primitive.NilObjectID, true
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		pkg.SendError(w, "Invalid "+name, http.StatusBadRequest)
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// sendPermissionError maps authorization errors to HTTP status codes.
+func sendPermissionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotClassOwner), errors.Is(err, service.ErrPermissionDenied):
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrTestNotFound):
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+	default:
+		pkg.SendError(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -3,12 +3,17 @@ package routes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	entity "quiz-app/internal/domain/entities"
 	"quiz-app/internal/domain/service"
 	"quiz-app/internal/pkg"
+	utils "quiz-app/internal/util"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,12 +21,14 @@ import (
 
 type RoutesQuestion struct {
 	auth            service.AuthHandler
+	authz           *service.AuthorizationService
 	questionUseCase service.QuestionUseCase
 }
 
-func NewRouterQuestion(questionUseCase service.QuestionUseCase, auth service.AuthHandler) *RoutesQuestion {
+func NewRouterQuestion(questionUseCase service.QuestionUseCase, authz *service.AuthorizationService, auth service.AuthHandler) *RoutesQuestion {
 	return &RoutesQuestion{
 		questionUseCase: questionUseCase,
+		authz:           authz,
 		auth:            auth,
 	}
 }
@@ -29,6 +36,13 @@ func NewRouterQuestion(questionUseCase service.QuestionUseCase, auth service.Aut
 func (rq *RoutesQuestion) GetQuestionRouter(r *Router) {
 	r.Handle("/questions", rq.auth.AuthMiddleware(http.HandlerFunc(rq.createQuestions))).Methods("POST")
 	r.Handle("/questions", rq.auth.AuthMiddleware(http.HandlerFunc(rq.getAllQuestions))).Methods("GET")
+	r.Handle("/questions/search", rq.auth.AuthMiddleware(http.HandlerFunc(rq.searchQuestions))).Methods("GET")
+	r.Handle("/questions/import", rq.auth.AuthMiddleware(http.HandlerFunc(rq.importQuestions))).Methods("POST")
+
+	// Version history of a question
+	r.Handle("/questions/versions", rq.auth.AuthMiddleware(http.HandlerFunc(rq.getVersions))).Methods("GET")
+	r.Handle("/questions/versions/diff", rq.auth.AuthMiddleware(http.HandlerFunc(rq.diffVersions))).Methods("GET")
+	r.Handle("/questions/versions/restore", rq.auth.AuthMiddleware(http.HandlerFunc(rq.restoreVersion))).Methods("POST")
 	r.Handle("/questions", rq.auth.AuthMiddleware(http.HandlerFunc(rq.updateQuestion))).Methods("PATCH")
 	r.Handle("/questions", rq.auth.AuthMiddleware(http.HandlerFunc(rq.deleteQuestion))).Methods("DELETE")
 }
@@ -38,44 +52,20 @@ func (r *RoutesQuestion) createQuestions(w http.ResponseWriter, req *http.Reques
 	fmt.Println("req.Body: ", req.Body)
 	var question entity.Question
 	if err := json.NewDecoder(req.Body).Decode(&question); err != nil {
-		// fmt.Println("question: ", question)
-
-		// fmt.Println("err: ", err)
-		// pkg.SendError(w, "Question not created", http.StatusInternalServerError)
-		// return
-	}
-	// Tạo ID mới cho các câu hỏi dựa trên loại câu hỏi
-	switch question.Type {
-	case "fill_in_the_blank":
-		for i := range question.FillInTheBlanks {
-			question.FillInTheBlanks[i].ID = primitive.NewObjectID()
-			fmt.Println(question.FillInTheBlanks[i].ID)
-		}
-	case "match_choice_question":
-		for i := range question.MatchItems {
-			question.MatchItems[i].ID = primitive.NewObjectID()
-		}
-		for i := range question.MatchItems {
-			question.MatchItems[i].ID = primitive.NewObjectID()
-		}
-	case "multiple_choice_question", "single_choice_question", "order_question":
-		for i := range question.Options {
-			question.Options[i].ID = primitive.NewObjectID()
-		}
-	default:
-		fmt.Println("Error: Unknown question type")
+		pkg.SendError(w, "Invalid question data", http.StatusBadRequest)
+		return
 	}
 
 	// Add metadata and timestamps
 	question.Metadata.Author = userID
 	// Gán thời gian tạo và cập nhật
 	now := time.Now()
-	fmt.Println(now)
+	question.Created_At = now
+	question.Updated_At = now
 
 	insertedQuestion, err := r.questionUseCase.CreateQuestion(context.TODO(), &question)
-
 	if err != nil {
-		pkg.SendError(w, "Question not created", http.StatusInternalServerError)
+		sendQuestionError(w, err, "Question not created")
 		return
 	}
 	// Send a successful response with the inserted ID
@@ -107,72 +97,260 @@ func (rq *RoutesQuestion) getAllQuestions(w http.ResponseWriter, req *http.Reque
 	pkg.SendResponse(w, http.StatusOK, questions)
 }
 
-func (r *RoutesQuestion) updateQuestion(w http.ResponseWriter, req *http.Request) {
-	emailID := req.Context().Value("email_id").(string)
+// searchQuestions lists the caller's question bank.
+// Query: type, tag (repeatable or comma separated), q, difficulty, min_score, max_score,
+// created_from, created_to, updated_from, updated_to (ISO 8601), sort, order (asc|desc), limit, cursor.
+func (rq *RoutesQuestion) searchQuestions(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	query := entity.QuestionQuery{
+		Author:     req.Context().Value("email_id").(string),
+		Types:      splitQueryList(params["type"]),
+		Tags:       splitQueryList(params["tag"]),
+		Text:       strings.TrimSpace(params.Get("q")),
+		Difficulty: params.Get("difficulty"),
+		SortBy:     params.Get("sort"),
+		Desc:       params.Get("order") == "desc",
+		Cursor:     params.Get("cursor"),
+	}
 
-	var question entity.Question
-	if err := json.NewDecoder(req.Body).Decode(&question); err != nil {
-		fmt.Println(err)
-		pkg.SendError(w, "Question not updated", http.StatusInternalServerError)
+	switch query.SortBy {
+	case "", entity.QuestionSortCreated, entity.QuestionSortUpdated, entity.QuestionSortScore:
+	default:
+		pkg.SendError(w, "Invalid sort", http.StatusBadRequest)
 		return
 	}
-	fmt.Println("question: ", question)
-	question.Metadata.Author = emailID
-	switch question.Type {
-	case "fill_in_the_blank":
-		for i, v := range question.FillInTheBlanks {
-			if primitive.NilObjectID == v.ID {
-				question.FillInTheBlanks[i].ID = primitive.NewObjectID()
-			}
+	if query.SortBy != "" && params.Get("order") == "" {
+		query.Desc = true
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			pkg.SendError(w, "Invalid limit", http.StatusBadRequest)
+			return
 		}
-	case "match_choice_question":
-		for i, v := range question.MatchItems {
-			if primitive.NilObjectID == v.ID {
-				question.MatchItems[i].ID = primitive.NewObjectID()
+		query.Limit = n
+	}
+	for name, target := range map[string]**float32{"min_score": &query.MinScore, "max_score": &query.MaxScore} {
+		if value := params.Get(name); value != "" {
+			score, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				pkg.SendError(w, "Invalid "+name, http.StatusBadRequest)
+				return
 			}
+			s := float32(score)
+			*target = &s
 		}
-		for i, v := range question.MatchOptions {
-			if primitive.NilObjectID == v.ID {
-				question.MatchOptions[i].ID = primitive.NewObjectID()
+	}
+	for name, target := range map[string]*time.Time{
+		"created_from": &query.CreatedFrom, "created_to": &query.CreatedTo,
+		"updated_from": &query.UpdatedFrom, "updated_to": &query.UpdatedTo,
+	} {
+		if value := params.Get(name); value != "" {
+			t, err := utils.StringToTime(value)
+			if err != nil {
+				pkg.SendError(w, "Invalid "+name, http.StatusBadRequest)
+				return
 			}
+			*target = t
 		}
+	}
 
-	case "multiple_choice_question", "single_choice_question":
-		for i, v := range question.Options {
-			if primitive.NilObjectID == v.ID {
-				question.Options[i].ID = primitive.NewObjectID()
-			}
+	page, err := rq.questionUseCase.SearchQuestions(req.Context(), query)
+	if errors.Is(err, entity.ErrInvalidCursor) {
+		pkg.SendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		pkg.SendError(w, "Failed to search questions", http.StatusInternalServerError)
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, page)
+}
+
+// importQuestions reads a CSV, GIFT or Aiken file, either as the "file" field of a multipart
+// form or as the raw body. ?format= defaults to the file extension, ?dry_run=true only validates.
+func (rq *RoutesQuestion) importQuestions(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+	req.Body = http.MaxBytesReader(w, req.Body, 10<<20)
+
+	format := req.URL.Query().Get("format")
+	dryRun, _ := strconv.ParseBool(req.URL.Query().Get("dry_run"))
+
+	var body io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := req.FormFile("file")
+		if err != nil {
+			pkg.SendError(w, "Error retrieving the file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
 		}
+	}
 
-	case "order_question":
-		for i, v := range question.OrderItems {
-			if primitive.NilObjectID == v.ID {
-				question.OrderItems[i].ID = primitive.NewObjectID()
+	result, err := rq.questionUseCase.ImportQuestions(req.Context(), emailID, format, body, dryRun)
+	if errors.Is(err, service.ErrUnknownImportFormat) {
+		pkg.SendError(w, "format must be csv, gift or aiken", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		pkg.SendError(w, "Failed to import questions", http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusOK
+	if result.Imported > 0 {
+		status = http.StatusCreated
+	}
+	pkg.SendResponse(w, status, result)
+}
+
+// splitQueryList accepts both ?tag=a&tag=b and ?tag=a,b.
+func splitQueryList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
 			}
 		}
+	}
+	return list
+}
 
-	default:
-		fmt.Println("Error: Unknown question type")
+// updateQuestion saves a question of the caller, or with ?test_id= a question of a test
+// the caller may edit. The question keeps its author.
+func (r *RoutesQuestion) updateQuestion(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
+
+	testID, ok := queryObjectID(w, req, "test_id")
+	if !ok {
+		return
 	}
+	var question entity.Question
+	if err := json.NewDecoder(req.Body).Decode(&question); err != nil {
+		fmt.Println(err)
+		pkg.SendError(w, "Question not updated", http.StatusInternalServerError)
+		return
+	}
+	stored, err := r.authz.AuthorizeQuestion(req.Context(), question.ID, testID, emailID, email, entity.PermEdit)
+	if err != nil {
+		sendQuestionError(w, err, "Failed to update question")
+		return
+	}
+	question.Metadata.Author = stored.Metadata.Author
 	now := time.Now()
 	question.Updated_At = now
 
-	questionUpdated, err := r.questionUseCase.UpdateQuestion(context.TODO(), &question)
+	questionUpdated, err := r.questionUseCase.UpdateQuestion(req.Context(), emailID, &question)
 	if err != nil {
-		pkg.SendError(w, "Failed to update question", http.StatusInternalServerError)
+		sendQuestionError(w, err, "Failed to update question")
 		return
 	}
 	pkg.SendResponse(w, http.StatusOK, questionUpdated)
 
 }
 
+// sendQuestionError answers 422 with the field errors of an invalid question,
+// and 500 with message for anything else.
+func sendQuestionError(w http.ResponseWriter, err error, message string) {
+	var invalid entity.ValidationErrors
+	switch {
+	case errors.As(err, &invalid):
+		pkg.SendResponse(w, http.StatusUnprocessableEntity, map[string]any{"error": "Invalid question", "fields": invalid})
+	case errors.Is(err, service.ErrQuestionNotFound), errors.Is(err, service.ErrVersionNotFound):
+		pkg.SendError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrPermissionDenied):
+		pkg.SendError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrVersionConflict):
+		pkg.SendError(w, err.Error(), http.StatusConflict)
+	default:
+		pkg.SendError(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (rq *RoutesQuestion) getVersions(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+
+	questionID, err := primitive.ObjectIDFromHex(req.URL.Query().Get("question_id"))
+	if err != nil {
+		pkg.SendError(w, "Invalid question_id", http.StatusBadRequest)
+		return
+	}
+	versions, err := rq.questionUseCase.ListVersions(req.Context(), emailID, questionID)
+	if err != nil {
+		sendQuestionError(w, err, "Failed to get versions")
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, versions)
+}
+
+// diffVersions compares ?from= with ?to=, the current version when to is left out.
+func (rq *RoutesQuestion) diffVersions(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+	params := req.URL.Query()
+
+	questionID, err := primitive.ObjectIDFromHex(params.Get("question_id"))
+	if err != nil {
+		pkg.SendError(w, "Invalid question_id", http.StatusBadRequest)
+		return
+	}
+	from, err := strconv.Atoi(params.Get("from"))
+	if err != nil || from <= 0 {
+		pkg.SendError(w, "Invalid from", http.StatusBadRequest)
+		return
+	}
+	to := 0
+	if value := params.Get("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil || to <= 0 {
+			pkg.SendError(w, "Invalid to", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := rq.questionUseCase.DiffVersions(req.Context(), emailID, questionID, from, to)
+	if err != nil {
+		sendQuestionError(w, err, "Failed to compare versions")
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, diff)
+}
+
+func (rq *RoutesQuestion) restoreVersion(w http.ResponseWriter, req *http.Request) {
+	emailID := req.Context().Value("email_id").(string)
+
+	var reqBody struct {
+		QuestionID primitive.ObjectID `json:"question_id"`
+		Version    int                `json:"version"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		pkg.SendError(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	question, err := rq.questionUseCase.RestoreVersion(req.Context(), emailID, reqBody.QuestionID, reqBody.Version)
+	if err != nil {
+		sendQuestionError(w, err, "Failed to restore version")
+		return
+	}
+	pkg.SendResponse(w, http.StatusOK, question)
+}
+
+// deleteQuestion deletes a question of the caller. Questions are never deleted through a shared test.
 func (rq *RoutesQuestion) deleteQuestion(w http.ResponseWriter, req *http.Request) {
 	emailID := req.Context().Value("email_id").(string)
+	email := req.Context().Value("email").(string)
 	var question entity.Question
 	if err := json.NewDecoder(req.Body).Decode(&question); err != nil {
 		pkg.SendError(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
+	if _, err := rq.authz.AuthorizeQuestion(req.Context(), question.ID, primitive.NilObjectID, emailID, email, entity.PermOwn); err != nil {
+		sendQuestionError(w, err, "Failed to delete question")
+		return
+	}
 	question.Metadata.Author = emailID
 	err := rq.questionUseCase.DeleteQuestion(context.TODO(), &question)
 	if err != nil {
@@ -2,13 +2,16 @@ package initialize
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	entity "quiz-app/internal/domain/entities"
+	"quiz-app/internal/domain/repository"
 	"quiz-app/internal/domain/service"
+	"quiz-app/internal/infrastructure/mail"
 	"quiz-app/internal/infrastructure/persistence/aws"
 	persistence "quiz-app/internal/infrastructure/persistence/mongodb"
 	redisdb "quiz-app/internal/infrastructure/persistence/redis"
@@ -33,6 +36,8 @@ func InitApp() {
 
 func SeedSampleQuestions(author string) []*entity.Question {
 	now := time.Now()
+	// Match options reference the hex ID of their match item
+	dog, cat, cow := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
 
 	return []*entity.Question{
 		// 1. SINGLE CHOICE
@@ -130,14 +135,14 @@ func SeedSampleQuestions(author string) []*entity.Question {
 				Text: "Match the animal with the sound it makes.",
 			},
 			MatchItems: []entity.MatchItem{
-				{ID: primitive.NewObjectID(), Text: "Dog"},
-				{ID: primitive.NewObjectID(), Text: "Cat"},
-				{ID: primitive.NewObjectID(), Text: "Cow"},
+				{ID: dog, Text: "Dog"},
+				{ID: cat, Text: "Cat"},
+				{ID: cow, Text: "Cow"},
 			},
 			MatchOptions: []entity.MatchOption{
-				{ID: primitive.NewObjectID(), Text: "Bark", MatchId: "Dog"},
-				{ID: primitive.NewObjectID(), Text: "Meow", MatchId: "Cat"},
-				{ID: primitive.NewObjectID(), Text: "Moo", MatchId: "Cow"},
+				{ID: primitive.NewObjectID(), Text: "Bark", MatchId: dog.Hex()},
+				{ID: primitive.NewObjectID(), Text: "Meow", MatchId: cat.Hex()},
+				{ID: primitive.NewObjectID(), Text: "Moo", MatchId: cow.Hex()},
 			},
 			Metadata:   entity.Metadata{Author: author},
 			Tags:       []string{"animals", "kids"},
@@ -207,25 +212,39 @@ func InitRouter() {
 	classRepo := persistence.NewClassMongoRepository()
 	testRepo := persistence.NewTestMongoRepository()
 	questionRepo := persistence.NewQuestionMongoRepository()
+	questionVersionRepo := persistence.NewQuestionVersionMongoRepository()
 	fileRepo := persistence.NewFileMongoRepository()
 	answerRepo := persistence.NewAnswerMongoRepository()
+	grantRepo := persistence.NewGrantMongoRepository()
+	joinCodeRepo := persistence.NewJoinCodeMongoRepository()
+	invitationRepo := persistence.NewInvitationMongoRepository()
 
 	// Initialize use cases
+	authzService := service.NewAuthorizationService(classRepo, testRepo, questionRepo, grantRepo)
 	userUseCase := service.NewUserUseCase(userRepo)
-	classUseCase := service.NewClassUseCase(classRepo, testRepo)
-	questionUseCase := service.NewQuestionUseCase(questionRepo)
-	testUseCase := service.NewTestUseCase(testRepo)
+	classUseCase := service.NewClassUseCase(classRepo, testRepo, authzService)
+	joinCodeUseCase := service.NewJoinCodeUseCase(joinCodeRepo, classRepo, classUseCase, authzService)
+	invitationUseCase := service.NewInvitationUseCase(invitationRepo, classRepo, newMailer(), authzService, invitationSecret(), invitationURL())
+	questionUseCase := service.NewQuestionUseCase(questionRepo, questionVersionRepo)
+	testUseCase := service.NewTestUseCase(testRepo, classRepo, questionUseCase, authzService)
 	fileUseCase := service.NewFileUseCase(fileRepo)
-	answerUseCase := service.NewAnswerUseCase(answerRepo)
+	answerUseCase := service.NewAnswerUseCase(answerRepo, questionRepo, questionVersionRepo, classRepo, authzService)
 
 	awsS3UseCase := aws.NewFileAWSRepository("quiz-app-image-storage", "ap-southeast-2")
+	bundleUseCase := service.NewBundleUseCase(questionUseCase, testRepo, fileRepo, awsS3UseCase, authzService)
 
 	go routes.NewRoutesAuth(router, *authService, *userUseCase, *redisUseCase, *classUseCase).SetLoginRoute()
 	routes.NewRouterTest(*testUseCase, *classUseCase, *questionUseCase, *answerUseCase, *redisUseCase, *authHandler).GetTestRouter(router)
-	routes.NewRouterQuestion(*questionUseCase, *authHandler).GetQuestionRouter(router)
-	routes.NewRouterClass(*classUseCase, *redisUseCase, *authHandler).GetClassRouter(router)
+	routes.NewRouterQuestion(*questionUseCase, authzService, *authHandler).GetQuestionRouter(router)
+	routes.NewRouterClass(*classUseCase, joinCodeUseCase, *authHandler).GetClassRouter(router)
 	routes.NewRoutesFile(fileUseCase, awsS3UseCase, authHandler).GetRoutesFile(router)
-	// routes.NewRouterAnswer(answerUseCase, testUseCase, questionUseCase, redisUseCase, authHandler).GetAnswerRouter(router)
+	routes.NewRouterAnswer(answerUseCase, authHandler).GetAnswerRouter(router)
+	routes.NewRouterBundle(bundleUseCase, authHandler).GetBundleRouter(router)
+	routes.NewRouterPermission(authzService, authHandler).GetPermissionRouter(router)
+	routes.NewRouterInvitation(invitationUseCase, authHandler).GetInvitationRouter(router)
+
+	// Auto-submit attempts whose time ran out
+	go answerUseCase.RunExpirySweeper(context.Background(), time.Minute)
 
 	// Apply CORS handler
 	handler := c.Handler(router)
@@ -237,6 +256,45 @@ func InitRouter() {
 
 }
 
+// newMailer sends mails over SMTP when SMTP_HOST is set, otherwise writes them to MAIL_DIR
+// (default "mail_outbox") so invitation links can be opened in local dev.
+func newMailer() repository.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@quiz-app.local"
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		return mail.NewSMTPMailer(host, os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+	}
+	dir := os.Getenv("MAIL_DIR")
+	if dir == "" {
+		dir = "mail_outbox"
+	}
+	log.Printf("SMTP_HOST not set, mails are written to %s", dir)
+	return mail.NewFileMailer(dir, from)
+}
+
+// invitationSecret signs the invitation links, JWT_SECRET is used when INVITATION_SECRET is not set.
+// The server does not start without one: links signed with an empty key can be forged.
+func invitationSecret() []byte {
+	secret := os.Getenv("INVITATION_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		log.Fatal("INVITATION_SECRET or JWT_SECRET must be set to sign invitation links")
+	}
+	return []byte(secret)
+}
+
+// invitationURL is the page of the frontend that accepts an invitation with ?token=.
+func invitationURL() string {
+	if url := os.Getenv("INVITATION_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:5173/invitations/accept"
+}
+
 // Hàm xử lý cho route "/"
 func homeHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "Welcome to the home page!")